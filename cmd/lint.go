@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/hooziwang/syl-md2doc/internal/input"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/lint"
+	"github.com/spf13/cobra"
+)
+
+const lintLongHelp = `在转换前检查 Markdown 源文件中的常见问题，输出 NDJSON 诊断（带行号）。
+
+检查项：
+1. unclosed-fence（error）：代码块围栏未闭合。
+2. missing-image（error）：引用的本地图片不存在。
+3. table-columns：表头与分隔行列数不一致（error），数据行列数不一致（warn）。
+4. broken-link（warn）：相对链接指向的文件不存在。
+5. heading-jump（warn）：标题层级跳级（如 H1 直接到 H3）。
+
+存在 error 级诊断时返回非 0。`
+
+func newLintCmd(stdout io.Writer, stderr io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:           "lint [inputs...]",
+		Short:         "检查 Markdown 源文件（不转换）",
+		Long:          lintLongHelp,
+		Example:       "  syl-md2doc lint /abs/docs/a.md /abs/docs/chapter",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				emitNDJSON(stderr, "error", "invalid_input", "缺少输入参数", map[string]any{
+					"required": "至少一个 .md 文件或目录",
+					"args":     args,
+				}, suggestionForTopError("至少提供一个输入"))
+				return errBuildFailed
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				emitNDJSON(stderr, "error", "cwd_read_failed", "读取当前目录失败", map[string]any{
+					"error": err.Error(),
+				}, "检查运行目录是否可访问，或在可访问目录中重试")
+				return errBuildFailed
+			}
+
+			start := time.Now()
+			sources, _, fails, err := input.Discover(args, cwd)
+			if err != nil {
+				emitNDJSON(stderr, "error", "lint_aborted", "lint 启动失败", map[string]any{
+					"error":  err.Error(),
+					"inputs": absPaths(cwd, args),
+				}, suggestionForTopError(err.Error()))
+				return errBuildFailed
+			}
+			for _, f := range fails {
+				emitNDJSON(stderr, "error", "file_failed", "输入不可用", map[string]any{
+					"source_path": absPath(cwd, f.Input),
+					"reason":      f.Reason,
+				}, suggestionForFailure(f.Reason))
+			}
+
+			diags := make([]job.Diagnostic, 0)
+			for _, src := range sources {
+				diags = append(diags, lint.CheckFile(src.SourcePath)...)
+			}
+			emitDiagnostics(stdout, "lint_diagnostic", diags, func(p string) string { return absPath(cwd, p) })
+
+			errorCount := job.CountErrors(diags)
+			level := "info"
+			status := "success"
+			suggestion := ""
+			if errorCount > 0 || len(fails) > 0 {
+				level = "error"
+				status = "failed"
+				suggestion = "按 lint_diagnostic 事件中的行号逐项修复后重新执行 lint"
+			}
+			emitNDJSON(stdout, level, "lint_summary", "Markdown 检查完成", map[string]any{
+				"status":        status,
+				"file_count":    len(sources),
+				"error_count":   errorCount,
+				"warning_count": len(diags) - errorCount,
+				"failure_count": len(fails),
+				"duration_ms":   time.Since(start).Milliseconds(),
+			}, suggestion)
+			if status != "success" {
+				return errBuildFailed
+			}
+			return nil
+		},
+	}
+}
+
+func emitDiagnostics(w io.Writer, event string, diags []job.Diagnostic, displayPath func(string) string) {
+	for _, d := range diags {
+		level := d.Severity
+		if level != job.SeverityError {
+			level = job.SeverityWarn
+		}
+		details := map[string]any{
+			"source_path": displayPath(d.Source),
+			"code":        d.Code,
+			"severity":    d.Severity,
+		}
+		if d.Line > 0 {
+			details["line"] = d.Line
+		}
+		emitNDJSON(w, level, event, d.Message, details, suggestionForDiagnostic(d.Code))
+	}
+}
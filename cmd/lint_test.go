@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCommandReportsDiagnostics(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# a\n\n![x](lost.png)\n"), 0o644))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{"lint", src})
+	err := cmd.Execute()
+	require.ErrorIs(t, err, errBuildFailed)
+	out := stdout.String()
+	require.Contains(t, out, "\"event\":\"lint_diagnostic\"")
+	require.Contains(t, out, "\"code\":\"missing-image\"")
+	require.Contains(t, out, "\"line\":3")
+	require.Contains(t, out, "\"event\":\"lint_summary\"")
+	require.Contains(t, out, "\"error_count\":1")
+}
+
+func TestLintCommandCleanInput(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# a\n\n## b\n"), 0o644))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{"lint", src})
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, stdout.String(), "\"status\":\"success\"")
+	require.NotContains(t, stdout.String(), "lint_diagnostic")
+}
+
+func TestBuildWithLintBlock(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("```\nunclosed\n"), 0o644))
+	pandoc := filepath.Join(tmp, "fake-pandoc.sh")
+	script := "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 'pandoc 3.1.11'; exit 0; fi\nexit 0\n"
+	require.NoError(t, os.WriteFile(pandoc, []byte(script), 0o755))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{src, "--pandoc-path", pandoc, "--output", filepath.Join(tmp, "out"), "--lint-block"})
+	err := cmd.Execute()
+	require.ErrorIs(t, err, errBuildFailed)
+	require.Contains(t, stderr.String(), "\"code\":\"unclosed-fence\"")
+	require.Contains(t, stderr.String(), "lint 检查未通过")
+}
+
+func TestSubcommandsRejectConversionFlags(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# a\n"), 0o644))
+
+	for _, args := range [][]string{
+		{"lint", src, "--math"},
+		{"lint", src, "--watermark", "DRAFT"},
+		{"validate", filepath.Join(tmp, "a.docx"), "--notes", "endnotes"},
+	} {
+		cmd := NewRootCmd(bytes.NewBuffer(nil), bytes.NewBuffer(nil))
+		cmd.SetArgs(args)
+		require.ErrorContains(t, cmd.Execute(), "unknown flag", args)
+	}
+
+	serve, _, err := NewRootCmd(bytes.NewBuffer(nil), bytes.NewBuffer(nil)).Find([]string{"serve"})
+	require.NoError(t, err)
+	require.NotNil(t, serve.Flags().Lookup("watermark"))
+	require.Nil(t, serve.Flags().Lookup("lint"))
+}
@@ -77,10 +77,21 @@ func suggestionForFailure(reason string) string {
 		return "检查输入路径是否存在且可读；建议使用绝对路径重新执行"
 	case strings.Contains(reason, "创建输出目录失败"):
 		return "检查输出目录权限，或切换到有写权限的目录后重试"
+	case strings.Contains(reason, "循环包含"):
+		return "移除形成环的包含指令；公共片段之间不要互相包含"
+	case strings.Contains(reason, "包含层级超过"):
+		return "减少包含指令的嵌套层数，或把深层片段直接合并到上层片段"
+	case strings.Contains(reason, "读取包含文件失败"):
+		return "检查包含指令中的路径（相对包含它的 Markdown 文件所在目录）"
+	case strings.Contains(reason, "模板处理失败"):
+		return "按 convert_diagnostic 事件中的行号补充未定义的变量或修正 {{ if }} / {{ end }} 配对"
 	case strings.Contains(reason, "pandoc 转换失败"):
 		if strings.Contains(lower, "could not fetch resource") || strings.Contains(lower, "image not found") {
 			return "补齐 Markdown 引用的本地资源文件，或改为可访问路径；然后重试"
 		}
+		if strings.Contains(lower, "out of memory") || strings.Contains(lower, "too many open files") || strings.Contains(lower, "no space left") {
+			return "疑似资源不足导致的临时性失败；可降低 --jobs 或通过 --retries 自动重试"
+		}
 		return "建议先手工执行 pandoc 命令定位具体语法/资源问题，再修复 Markdown 后重试"
 	default:
 		return "检查错误详情与输入文件内容；确认路径、权限和依赖环境后重试"
@@ -110,6 +121,53 @@ func suggestionForTopError(errText string) string {
 	}
 }
 
+func suggestionForDiagnostic(code string) string {
+	switch code {
+	case "unclosed-fence":
+		return "补齐代码块的闭合围栏（与开始围栏相同字符且长度不小于开始围栏）"
+	case "missing-image":
+		return "补齐图片文件或修正图片相对路径（相对 Markdown 文件所在目录）"
+	case "broken-link":
+		return "修正链接的相对路径，或确认目标文件已纳入仓库"
+	case "heading-jump":
+		return "逐级使用标题层级，避免 Word 导航窗格与目录出现断层"
+	case "unresolved-citation":
+		return "检查引用键拼写，或把对应条目补充到 --bibliography / front matter bibliography 指定的参考文献文件"
+	case "invalid-math":
+		return "检查公式的花括号、\\begin/\\end 与 \\left/\\right 是否配对；金额等字面 $ 可写成 \\$"
+	case "diagram-renderer-missing":
+		return "安装对应的渲染器（如 npm i -g @mermaid-js/mermaid-cli、plantuml），或用 --diagram-renderer 指定命令"
+	case "diagram-render-failed":
+		return "检查图表源码语法，或单独运行 --diagram-renderer 指定的命令排查渲染器错误"
+	case "undefined-variable":
+		return "通过 --var、--vars-file 或 front matter 定义该变量；需要输出字面的 {{ 时写成 \\{{"
+	case "template-syntax":
+		return "检查 {{ if }} / {{ else }} / {{ end }} 是否配对，条件写法如 {{ if edition == \"pro\" }}"
+	case "unresolved-reference":
+		return "检查引用的标签拼写，并确认目标图片、表格或标题带有对应的 {#fig:id} / {#tbl:id} / {#sec:id} 标签"
+	case "duplicate-label":
+		return "为每个图片、表格与标题使用唯一的标签；重复的标签只有第一个可被引用"
+	case "link-outside-inputs":
+		return "把被链接的 Markdown 文件加入本次输入一并转换，或改为指向已发布文档的绝对地址"
+	case "table-widths":
+		return "列宽写成与表格列数相同个数的相对值，如 Table: 说明 {#tbl:id widths=\"20,30,50\"}，标题行紧挨表格"
+	case "table-columns":
+		return "保持表头、分隔行与每一行的列数一致；单元格内的竖线需写成 \\|"
+	case "docx-zip":
+		return "docx 文件已损坏或不完整；确认写出过程未被中断后重新生成"
+	case "docx-content-types":
+		return "检查参考模板或后处理新增的部件是否在 [Content_Types].xml 中声明了 Default 或 Override"
+	case "docx-relationships":
+		return "检查 .rels 中的关系目标是否存在、Id 是否唯一；raw openxml 片段中的 r:id 必须在文档关系中定义"
+	case "docx-xml":
+		return "检查 raw openxml 片段与参考模板的 XML 是否闭合、转义正确，所用命名空间前缀是否已声明"
+	case "docx-schema":
+		return "检查 raw openxml 片段的元素嵌套（w:p 不能嵌套、w:t 必须位于 w:r 中、表格单元格以段落结尾）"
+	default:
+		return "根据 message 与行号检查对应 Markdown 内容"
+	}
+}
+
 func EmitUnhandledError(w io.Writer, err error) {
 	if err == nil {
 		return
@@ -1,22 +1,55 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/hooziwang/syl-md2doc/internal/app"
+	"github.com/hooziwang/syl-md2doc/internal/convert"
 	"github.com/spf13/cobra"
-	"syl-md2doc/internal/app"
 )
 
 type buildFlags struct {
-	outputArg     string
-	jobs          int
-	referenceDocx string
-	pandocPath    string
-	verbose       bool
+	outputArg        string
+	jobs             int
+	referenceDocx    string
+	pandocPath       string
+	verbose          bool
+	retries          int
+	retryBackoff     time.Duration
+	failFast         bool
+	maxFailures      int
+	lint             bool
+	lintBlock        bool
+	properties       []string
+	propsFile        string
+	headerFooter     convert.HeaderFooterOptions
+	cover            string
+	vars             []string
+	varsFile         string
+	template         bool
+	includeRoot      string
+	watermark        convert.WatermarkOptions
+	classification   string
+	bibliography     []string
+	csl              string
+	math             bool
+	highlight        convert.HighlightOptions
+	admonitionMode   string
+	admonitionStyles []string
+	diagramRenderers []string
+	diagrams         convert.DiagramOptions
+	page             convert.PageOptions
+	blankLines       string
+	tables           convert.TableOptions
+	notes            convert.NoteOptions
+	fonts            convert.FontOptions
+	validate         bool
 }
 
 const rootLongHelp = `将一个或多个 Markdown 文件批量转换为 Word(.docx)。
@@ -25,6 +58,7 @@ const rootLongHelp = `将一个或多个 Markdown 文件批量转换为 Word(.do
 1. 支持多个文件、多个目录、文件与目录混合输入。
 2. 目录会递归扫描；仅处理 .md 文件，其他文件自动忽略。
 3. 一个 .md 文件对应一个 .docx 文件。
+4. 与子命令同名的文件或目录（如 lint、serve、validate、version）请写作 ./lint，或放在 -- 之后：syl-md2doc -- lint。
 
 输出规则：
 1. 默认输出到当前目录。
@@ -74,22 +108,87 @@ func NewRootCmd(stdout io.Writer, stderr io.Writer) *cobra.Command {
 		Example:       rootExamples,
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		Args:          cobra.ArbitraryArgs,
 		RunE:          runBuild(stdout, stderr, flags, &showVersion),
 	}
 	root.SetOut(stdout)
 	root.SetErr(stderr)
 	root.CompletionOptions.HiddenDefaultCmd = true
+	root.SuggestionsMinimumDistance = 2
 	bindBuildFlags(root, flags)
+	root.Flags().BoolVar(&flags.lint, "lint", false, "转换前对源文件执行 lint 检查并输出诊断")
+	root.Flags().BoolVar(&flags.lintBlock, "lint-block", false, "lint 存在 error 级诊断的文件不再转换（隐含 --lint）")
 	root.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "显示版本信息")
+	root.AddCommand(newLintCmd(stdout, stderr))
+	root.AddCommand(newValidateCmd(stdout, stderr))
+	root.AddCommand(newServeCmd(stdout, stderr, flags))
 	return root
 }
 
+// bindBuildFlags 注册转换参数；只挂在直跑（根命令）与 serve 上，lint、validate 等子命令不接受这些参数。
 func bindBuildFlags(cmd *cobra.Command, flags *buildFlags) {
-	cmd.PersistentFlags().StringVarP(&flags.outputArg, "output", "o", "", "输出目录或输出文件")
-	cmd.PersistentFlags().IntVarP(&flags.jobs, "jobs", "j", runtime.NumCPU(), "并发任务数")
-	cmd.PersistentFlags().StringVar(&flags.referenceDocx, "reference-docx", "", "pandoc 参考 docx 模板")
-	cmd.PersistentFlags().StringVar(&flags.pandocPath, "pandoc-path", "", "pandoc 可执行文件路径")
-	cmd.PersistentFlags().BoolVar(&flags.verbose, "verbose", false, "输出详细日志")
+	cmd.Flags().StringVarP(&flags.outputArg, "output", "o", "", "输出目录或输出文件")
+	cmd.Flags().IntVarP(&flags.jobs, "jobs", "j", runtime.NumCPU(), "并发任务数")
+	cmd.Flags().StringVar(&flags.referenceDocx, "reference-docx", "", "pandoc 参考 docx 模板")
+	cmd.Flags().StringVar(&flags.pandocPath, "pandoc-path", "", "pandoc 可执行文件路径")
+	cmd.Flags().BoolVar(&flags.verbose, "verbose", false, "输出详细日志")
+	cmd.Flags().IntVar(&flags.retries, "retries", 0, "临时性失败（进程被终止、资源耗尽、临时文件冲突）的最大重试次数")
+	cmd.Flags().DurationVar(&flags.retryBackoff, "retry-backoff", 500*time.Millisecond, "首次重试前的等待时长，之后按指数退避")
+	cmd.Flags().BoolVar(&flags.failFast, "fail-fast", false, "出现第一个失败即停止派发新任务并取消进行中的任务")
+	cmd.Flags().IntVar(&flags.maxFailures, "max-failures", 0, "失败数达到该值后停止转换（0 表示不限制）")
+	cmd.Flags().StringArrayVar(&flags.properties, "set-property", nil, "设置 docx 文档属性 key=value（可重复），如 title、author、company 或自定义属性")
+	cmd.Flags().StringVar(&flags.propsFile, "properties-file", "", "YAML 文档属性文件（作为默认值，front matter 与 --set-property 可覆盖）")
+	cmd.Flags().StringVar(&flags.includeRoot, "include-root", "", "包含指令允许读取的目录（默认为主文档所在目录），如仓库根目录，以便包含 ../shared 中的片段")
+	cmd.Flags().StringVar(&flags.cover, "cover", "", "封面模板：Markdown 模板或 .docx 片段，占位符如 {title}、{revision_table}")
+	cmd.Flags().StringArrayVar(&flags.vars, "var", nil, "模板变量 key=value（可重复），优先级高于 --vars-file 与 front matter")
+	cmd.Flags().StringVar(&flags.varsFile, "vars-file", "", "YAML 变量文件，为 Markdown 中的 {{ name }} 与 {{ if }} 提供变量")
+	cmd.Flags().BoolVar(&flags.template, "template", false, "处理正文中的 {{ name }} 变量与 {{ if }} 条件块（指定 --var 或 --vars-file 时自动启用）")
+	cmd.Flags().StringVar(&flags.watermark.Text, "watermark", "", "文字水印，如 DRAFT、CONFIDENTIAL（为空时读取 front matter 的 watermark）")
+	cmd.Flags().Float64Var(&flags.watermark.Opacity, "watermark-opacity", 0.5, "水印不透明度（0-1）")
+	cmd.Flags().Float64Var(&flags.watermark.Angle, "watermark-angle", 45, "水印逆时针旋转角度（度），0 为水平")
+	cmd.Flags().StringVar(&flags.watermark.Color, "watermark-color", "#C0C0C0", "水印颜色：#RRGGBB 或颜色名")
+	cmd.Flags().StringVar(&flags.classification, "classification", "", "密级标识，写入每页页眉顶部与页脚底部（为空时读取 front matter 的 classification）")
+	cmd.Flags().StringArrayVar(&flags.bibliography, "bibliography", nil, "参考文献文件（BibTeX .bib、CSL-JSON .json 或 CSL YAML，可重复），启用 [@key] 引用")
+	cmd.Flags().StringVar(&flags.csl, "csl", "", "CSL 引用样式文件，如 gb-t-7714-2015-numeric.csl（默认 Chicago 作者-年份）")
+	cmd.Flags().BoolVar(&flags.math, "math", false, "解析 $...$ 与 $$...$$ 公式并转为 Word 原生公式，同时校验公式语法")
+	cmd.Flags().StringVar(&flags.highlight.Style, "highlight-style", "", "代码高亮主题："+strings.Join(convert.BuiltinHighlightStyles(), "、")+"，或 .theme 主题文件（默认 pygments）")
+	cmd.Flags().BoolVar(&flags.highlight.Disabled, "no-highlight", false, "关闭代码块语法高亮")
+	cmd.Flags().BoolVar(&flags.highlight.LineNumbers, "code-line-numbers", false, "为代码块的每一行加上行号")
+	cmd.Flags().StringVar(&flags.admonitionMode, "admonition-mode", convert.AdmonitionModeBox, "提示块（> [!NOTE]、::: warning）渲染方式：box（带底色的方框）或 style（仅套用段落样式）")
+	cmd.Flags().StringArrayVar(&flags.admonitionStyles, "admonition-style", nil, "提示块段落样式名 kind=样式名（可重复），kind 为 note/tip/important/warning/caution/title")
+	cmd.Flags().StringArrayVar(&flags.diagramRenderers, "diagram-renderer", nil, "图表渲染命令 kind=命令（可重复），kind 为 mermaid/plantuml；命令中可用 {input}、{output}、{format} 占位符")
+	cmd.Flags().StringVar(&flags.diagrams.Format, "diagram-format", "png", "图表图片格式：png 或 svg")
+	cmd.Flags().StringVar(&flags.diagrams.CacheDir, "diagram-cache", "", "图表渲染缓存目录（默认为用户缓存目录下的 syl-md2doc/diagrams）")
+	cmd.Flags().StringVar(&flags.page.Paper, "paper", "", "纸张大小：A3、A4、A5、B5、Letter、Legal（默认沿用参考模板）")
+	cmd.Flags().StringVar(&flags.page.Margins, "margins", "", "页边距：1、2 或 4 个长度（上 右 下 左），单位 mm/cm/in/pt，如 2.5cm 或 \"25mm,20mm\"")
+	cmd.Flags().StringVar(&flags.page.Orientation, "orientation", "", "页面方向：portrait（纵向）或 landscape（横向）；::: landscape 块始终为横向")
+	cmd.Flags().StringVar(&flags.blankLines, "blank-lines", string(convert.BlankLinesPreserve), "空行处理：preserve（每个空行保留为空段落）、collapse（按标准 Markdown 只作分隔）或 extra-only（仅连续多个空行生成空段落）")
+	cmd.Flags().StringVar(&flags.tables.Style, "table-style", "", "表格样式名（参考模板中的表格样式，如 \"Grid Table 4 Accent 1\"），默认使用 pandoc 的 Table 样式")
+	cmd.Flags().BoolVar(&flags.tables.RepeatHeader, "table-header-repeat", false, "表格跨页时重复表头行")
+	cmd.Flags().BoolVar(&flags.tables.Banded, "table-banded", false, "表格隔行条带显示（样式未定义条带时使用浅灰底纹）")
+	cmd.Flags().StringVar(&flags.notes.Kind, "notes", convert.NotesFootnotes, "Markdown 脚注的形式：footnotes（页脚注）或 endnotes（文末尾注）")
+	cmd.Flags().StringVar(&flags.notes.Format, "note-format", "", "脚注/尾注编号格式：decimal、lower-roman、upper-roman、lower-letter、upper-letter、symbol（*、†、‡）或 chinese")
+	cmd.Flags().StringVar(&flags.notes.Restart, "note-restart", "", "脚注/尾注重新编号的位置：continuous、section 或 page（仅脚注）")
+	cmd.Flags().BoolVar(&flags.fonts.Embed, "embed-fonts", false, "把文档用到且能在 --font-dir 中找到的字体嵌入 docx")
+	cmd.Flags().StringArrayVar(&flags.fonts.Dirs, "font-dir", nil, "字体目录（可重复），用于嵌入字体与字体报告，查找时优先于系统字体目录")
+	cmd.Flags().StringVar(&flags.headerFooter.Header, "header", "", "页眉模板，如 \"{title} — {version}\"；| 分隔左/中/右")
+	cmd.Flags().StringVar(&flags.headerFooter.Footer, "footer", "", "页脚模板，如 \"第 {page} 页，共 {pages} 页\"")
+	cmd.Flags().StringVar(&flags.headerFooter.FirstHeader, "first-page-header", "", "首页页眉模板（未指定时沿用 --header）")
+	cmd.Flags().StringVar(&flags.headerFooter.FirstFooter, "first-page-footer", "", "首页页脚模板（未指定时沿用 --footer）")
+	cmd.Flags().BoolVar(&flags.headerFooter.DifferentFirstPage, "different-first-page", false, "首页只使用 --first-page-header/--first-page-footer，未指定则首页留白")
+	cmd.Flags().StringVar(&flags.headerFooter.EvenHeader, "even-header", "", "偶数页页眉模板（指定后启用奇偶页不同）")
+	cmd.Flags().StringVar(&flags.headerFooter.EvenFooter, "even-footer", "", "偶数页页脚模板（指定后启用奇偶页不同）")
+	cmd.Flags().BoolVar(&flags.validate, "validate", false, "校验生成的 docx 结构（zip、内容类型、关系、XML 与关键 OOXML 约束），校验失败的任务记为失败")
+}
+
+func (f *buildFlags) failureThreshold() int {
+	if f.failFast {
+		return 1
+	}
+	if f.maxFailures < 0 {
+		return 0
+	}
+	return f.maxFailures
 }
 
 func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion *bool) func(*cobra.Command, []string) error {
@@ -105,6 +204,13 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 			}, suggestionForTopError("至少提供一个输入"))
 			return errBuildFailed
 		}
+		if input, names := subcommandLike(cmd, args); len(names) > 0 {
+			emitNDJSON(stderr, "error", "invalid_input", "输入不存在，且与子命令名相近", map[string]any{
+				"input":       input,
+				"suggestions": names,
+			}, "运行子命令请把子命令写在最前面并检查拼写；如确为同名文件或目录，请写作 ./"+input+" 或放在 -- 之后")
+			return errBuildFailed
+		}
 
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -113,6 +219,15 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 			}, "检查运行目录是否可访问，或在可访问目录中重试")
 			return errBuildFailed
 		}
+		opts, err := flags.appOptions(cwd)
+		if err != nil {
+			emitNDJSON(stderr, "error", "invalid_input", "参数无效", map[string]any{
+				"error": err.Error(),
+			}, "检查 key=value 形式的参数，例如 --set-property company=ACME")
+			return errBuildFailed
+		}
+		opts.Inputs = args
+
 		start := time.Now()
 		if flags.verbose {
 			emitNDJSON(stdout, "info", "build_start", "开始执行 Markdown 转 docx", map[string]any{
@@ -123,6 +238,9 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 				"reference_docx": absPath(cwd, flags.referenceDocx),
 				"pandoc_path":    absPath(cwd, flags.pandocPath),
 				"verbose":        flags.verbose,
+				"retries":        flags.retries,
+				"retry_backoff":  flags.retryBackoff.String(),
+				"max_failures":   flags.failureThreshold(),
 			}, "")
 		}
 
@@ -133,15 +251,7 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 			}, "")
 		}
 
-		res, err := app.Run(app.Options{
-			Inputs:        args,
-			OutputArg:     flags.outputArg,
-			Jobs:          flags.jobs,
-			ReferenceDocx: flags.referenceDocx,
-			PandocPath:    flags.pandocPath,
-			CWD:           cwd,
-			Verbose:       flags.verbose,
-		})
+		res, err := app.Run(opts)
 		if err != nil {
 			emitNDJSON(stderr, "error", "build_aborted", "转换任务启动失败", map[string]any{
 				"error":  err.Error(),
@@ -149,6 +259,9 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 			}, suggestionForTopError(err.Error()))
 			return errBuildFailed
 		}
+		emitDiagnostics(stderr, "lint_diagnostic", res.Diagnostics, func(p string) string { return absPath(cwd, p) })
+		emitDiagnostics(stderr, "convert_diagnostic", res.ConvertDiagnostics, func(p string) string { return absPath(cwd, p) })
+
 		if flags.verbose {
 			emitNDJSON(stdout, "info", "pandoc_environment", "pandoc 环境检测结果", map[string]any{
 				"pandoc_path":    absPath(cwd, res.PandocPath),
@@ -156,6 +269,10 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 			}, "")
 		}
 
+		if flags.verbose {
+			emitFontReports(stdout, cwd, res.Tasks)
+		}
+
 		// 成功场景默认精简输出；失败或 --verbose 时输出逐条告警。
 		if flags.verbose || res.FailureCount > 0 {
 			for idx, w := range res.Warnings {
@@ -166,11 +283,25 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 			}
 		}
 		for idx, f := range res.Failures {
-			emitNDJSON(stderr, "error", "file_failed", "文件转换失败", map[string]any{
+			details := map[string]any{
 				"index":       idx + 1,
 				"source_path": absPath(cwd, f.Source),
 				"reason":      f.Reason,
-			}, suggestionForFailure(f.Reason))
+			}
+			if f.Attempts > 0 {
+				details["attempts"] = f.Attempts
+			}
+			emitNDJSON(stderr, "error", "file_failed", "文件转换失败", details, suggestionForFailure(f.Reason))
+		}
+
+		if res.Stopped {
+			notRun := absPaths(cwd, res.NotRun)
+			emitNDJSON(stderr, "error", "build_stopped", "失败数达到阈值，已停止转换", map[string]any{
+				"max_failures":  flags.failureThreshold(),
+				"failure_count": res.FailureCount,
+				"not_run_count": res.NotRunCount,
+				"not_run_paths": notRun,
+			}, "修复失败项后重试；如需继续转换其余文件，可调大 --max-failures 或去掉 --fail-fast")
 		}
 
 		level := "info"
@@ -179,10 +310,14 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 			level = "error"
 			status = "partial_failed"
 		}
+		if res.Stopped {
+			status = "stopped"
+		}
 		summaryDetails := map[string]any{
 			"status":        status,
 			"success_count": res.SuccessCount,
 			"failure_count": res.FailureCount,
+			"not_run_count": res.NotRunCount,
 			"warning_count": res.WarningCount,
 			"duration_ms":   time.Since(start).Milliseconds(),
 			"output_paths":  res.OutputPaths,
@@ -190,6 +325,13 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 		if len(res.OutputPaths) == 1 {
 			summaryDetails["output_path"] = res.OutputPaths[0]
 		}
+		if deps := includedFiles(cwd, res.Tasks); len(deps) > 0 {
+			summaryDetails["includes"] = deps
+		}
+		if res.RetryCount > 0 {
+			summaryDetails["retry_count"] = res.RetryCount
+			summaryDetails["retried_tasks"] = retriedTasks(cwd, res.Tasks)
+		}
 		// 失败时给完整诊断上下文；成功默认只保留结果导向字段。
 		if res.FailureCount > 0 || flags.verbose {
 			summaryDetails["pandoc_path"] = absPath(cwd, res.PandocPath)
@@ -211,6 +353,159 @@ func runBuild(stdout io.Writer, stderr io.Writer, flags *buildFlags, showVersion
 	}
 }
 
+// appOptions 把命令行参数转换为 app.Options（不含 Inputs）。
+func (f *buildFlags) appOptions(cwd string) (app.Options, error) {
+	props, err := parseKeyValues(f.properties)
+	if err != nil {
+		return app.Options{}, fmt.Errorf("--set-property：%w", err)
+	}
+	vars, err := parseKeyValues(f.vars)
+	if err != nil {
+		return app.Options{}, fmt.Errorf("--var：%w", err)
+	}
+	admonitionStyles, err := parseKeyValues(f.admonitionStyles)
+	if err != nil {
+		return app.Options{}, fmt.Errorf("--admonition-style：%w", err)
+	}
+	diagramRenderers, err := parseKeyValues(f.diagramRenderers)
+	if err != nil {
+		return app.Options{}, fmt.Errorf("--diagram-renderer：%w", err)
+	}
+	diagrams := f.diagrams
+	diagrams.Renderers = diagramRenderers
+	return app.Options{
+		OutputArg:      f.outputArg,
+		Jobs:           f.jobs,
+		ReferenceDocx:  f.referenceDocx,
+		PandocPath:     f.pandocPath,
+		CWD:            cwd,
+		Verbose:        f.verbose,
+		Retries:        f.retries,
+		RetryBackoff:   f.retryBackoff,
+		MaxFailures:    f.failureThreshold(),
+		Lint:           f.lint || f.lintBlock,
+		LintBlock:      f.lintBlock,
+		Properties:     props,
+		PropertiesFile: f.propsFile,
+		HeaderFooter:   f.headerFooter,
+		Cover:          f.cover,
+		Vars:           vars,
+		VarsFile:       f.varsFile,
+		Template:       f.template,
+		IncludeRoot:    f.includeRoot,
+		Watermark:      f.watermark,
+		Classification: f.classification,
+		Bibliography:   f.bibliography,
+		CSL:            f.csl,
+		Math:           f.math,
+		Highlight:      f.highlight,
+		Admonitions:    convert.AdmonitionOptions{Mode: f.admonitionMode, Styles: admonitionStyles},
+		Diagrams:       diagrams,
+		Page:           f.page,
+		BlankLines:     convert.BlankLineMode(f.blankLines),
+		Tables:         f.tables,
+		Notes:          f.notes,
+		Fonts:          f.fonts,
+		Validate:       f.validate,
+	}, nil
+}
+
+// parseKeyValues 解析可重复的 key=value 参数；同名键以后出现的为准。
+func parseKeyValues(items []string) (map[string]string, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(items))
+	for _, item := range items {
+		key, value, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("缺少 key=value 中的键或等号：%q", item)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+func retriedTasks(cwd string, tasks []app.TaskResult) []map[string]any {
+	out := make([]map[string]any, 0)
+	for _, t := range tasks {
+		if t.Attempts <= 1 {
+			continue
+		}
+		out = append(out, map[string]any{
+			"source_path": absPath(cwd, t.Source),
+			"status":      t.Status,
+			"attempts":    t.Attempts,
+		})
+	}
+	return out
+}
+
+// includedFiles 列出通过包含指令依赖了其他文件的源文件及其依赖。
+func includedFiles(cwd string, tasks []app.TaskResult) []map[string]any {
+	out := make([]map[string]any, 0)
+	for _, t := range tasks {
+		if len(t.Includes) == 0 {
+			continue
+		}
+		out = append(out, map[string]any{
+			"source_path": absPath(cwd, t.Source),
+			"includes":    absPaths(cwd, t.Includes),
+		})
+	}
+	return out
+}
+
+// emitFontReports 为每个输出文件输出一条 font_report 事件，列出引用的字体及是否找到或已嵌入。
+func emitFontReports(w io.Writer, cwd string, tasks []app.TaskResult) {
+	for _, t := range tasks {
+		if len(t.Fonts) == 0 {
+			continue
+		}
+		fonts := make([]map[string]any, 0, len(t.Fonts))
+		missing := make([]string, 0)
+		for _, f := range t.Fonts {
+			item := map[string]any{"name": f.Name, "found": f.Path != "", "embedded": f.Embedded}
+			if f.Path != "" {
+				item["path"] = absPath(cwd, f.Path)
+			} else {
+				missing = append(missing, f.Name)
+			}
+			fonts = append(fonts, item)
+		}
+		level, suggestion := "info", ""
+		if len(missing) > 0 {
+			level = "warn"
+			suggestion = "缺失的字体在打开文档时会被替换；把字体文件放入 --font-dir 目录并加 --embed-fonts，或在参考模板中改用已安装的字体"
+		}
+		emitNDJSON(w, level, "font_report", "文档引用的字体", map[string]any{
+			"source_path": absPath(cwd, t.Source),
+			"output_path": absPath(cwd, t.Target),
+			"fonts":       fonts,
+			"missing":     missing,
+		}, suggestion)
+	}
+}
+
+// subcommandLike 返回第一个不存在同名文件、且与子命令名相同或相近（如 lnt）的输入及建议的子命令，
+// 避免拼错的子命令被当作输入路径报“文件不存在”。-- 之后的参数一律按输入处理。
+func subcommandLike(cmd *cobra.Command, args []string) (string, []string) {
+	n := len(args)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		n = dash
+	}
+	for _, arg := range args[:n] {
+		if _, err := os.Stat(arg); err == nil {
+			continue
+		}
+		if names := cmd.SuggestionsFor(arg); len(names) > 0 {
+			return arg, names
+		}
+	}
+	return "", nil
+}
+
 func normalizeArgs(args []string) []string {
 	if len(args) == 1 && args[0] == "version" {
 		return []string{"--version"}
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hooziwang/syl-md2doc/internal/app"
+	"github.com/hooziwang/syl-md2doc/internal/job"
 	"github.com/stretchr/testify/require"
 )
 
@@ -71,7 +73,7 @@ func TestBuildVerbosePrintPandocInfo(t *testing.T) {
 	stdout := bytes.NewBuffer(nil)
 	stderr := bytes.NewBuffer(nil)
 	cmd := NewRootCmd(stdout, stderr)
-	cmd.SetArgs([]string{src, "--pandoc-path", pandoc, "--verbose"})
+	cmd.SetArgs([]string{src, "--pandoc-path", pandoc, "--output", filepath.Join(tmp, "out"), "--verbose"})
 
 	err := cmd.Execute()
 	require.NoError(t, err)
@@ -147,3 +149,63 @@ func TestHelpContainsDetailedGuidance(t *testing.T) {
 	require.Contains(t, out, "syl-md2doc /abs/docs/a.md")
 	require.Contains(t, out, "syl-md2doc --version")
 }
+
+func TestBuildRetriesTransientFailure(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# hi"), 0o644))
+	marker := filepath.Join(tmp, "attempted")
+
+	pandoc := filepath.Join(tmp, "fake-pandoc-flaky.sh")
+	script := "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 'pandoc 3.1.11'; exit 0; fi\nif [ ! -f \"" + marker + "\" ]; then touch \"" + marker + "\"; echo 'pandoc: out of memory' 1>&2; exit 251; fi\nout=\"\"\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"-o\" ]; then out=\"$2\"; shift 2; continue; fi\n  shift\ndone\nmkdir -p \"$(dirname \"$out\")\"\nprintf 'ok' > \"$out\"\nexit 0\n"
+	require.NoError(t, os.WriteFile(pandoc, []byte(script), 0o755))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{src, "--pandoc-path", pandoc, "--output", filepath.Join(tmp, "out"), "--retries", "2", "--retry-backoff", "1ms"})
+	err := cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, stdout.String(), "\"success_count\":1")
+	require.Contains(t, stdout.String(), "\"retry_count\":1")
+	require.Contains(t, stdout.String(), "\"attempts\":2")
+}
+
+func TestBuildFailFastStopsAndReportsNotRun(t *testing.T) {
+	tmp := t.TempDir()
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, name), []byte("# hi"), 0o644))
+	}
+	pandoc := filepath.Join(tmp, "fake-pandoc-fail.sh")
+	script := "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 'pandoc 3.1.11'; exit 0; fi\necho 'fatal: failed' 1>&2\nexit 1\n"
+	require.NoError(t, os.WriteFile(pandoc, []byte(script), 0o755))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{tmp, "--pandoc-path", pandoc, "--output", filepath.Join(tmp, "out"), "--jobs", "1", "--fail-fast"})
+	err := cmd.Execute()
+	require.ErrorIs(t, err, errBuildFailed)
+	require.Contains(t, stdout.String(), "\"status\":\"stopped\"")
+	require.Contains(t, stdout.String(), "\"failure_count\":1")
+	require.Contains(t, stdout.String(), "\"not_run_count\":2")
+	require.Contains(t, stderr.String(), "\"event\":\"build_stopped\"")
+}
+
+func TestEmitFontReports(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	emitFontReports(out, "/work", []app.TaskResult{
+		{Source: "a.md", Target: "out/a.docx", Fonts: []job.FontUsage{
+			{Name: "等线", Path: "/fonts/dengxian.ttf", Embedded: true},
+			{Name: "等线 Light"},
+		}},
+		{Source: "b.md", Target: "out/b.docx"},
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"event":"font_report"`)
+	require.Contains(t, lines[0], `"level":"warn"`)
+	require.Contains(t, lines[0], `"missing":["等线 Light"]`)
+	require.Contains(t, lines[0], `{"embedded":true,"found":true,"name":"等线","path":"/fonts/dengxian.ttf"}`)
+	require.Contains(t, lines[0], `"source_path":"/work/a.md"`)
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -28,3 +30,39 @@ func TestBuildRequiresInput(t *testing.T) {
 	require.Contains(t, stderr.String(), "\"event\":\"invalid_input\"")
 	require.Contains(t, stderr.String(), "至少一个 .md 文件或目录")
 }
+
+func TestBuildRejectsMistypedSubcommand(t *testing.T) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{"lnt", "a.md"})
+
+	err := cmd.Execute()
+	require.ErrorIs(t, err, errBuildFailed)
+	require.Contains(t, stderr.String(), "输入不存在，且与子命令名相近")
+	require.Contains(t, stderr.String(), "\"suggestions\":[\"lint\"]")
+	require.Contains(t, stderr.String(), "./lnt")
+}
+
+func TestBuildAcceptsSubcommandNamedInputsAfterDash(t *testing.T) {
+	tmp := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tmp))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	// 不存在的同名输入在 -- 之后按输入处理，报告的是文件不存在而不是子命令建议。
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(bytes.NewBuffer(nil), stderr)
+	cmd.SetArgs([]string{"--pandoc-path", filepath.Join(tmp, "missing-pandoc"), "--", "lint"})
+	require.ErrorIs(t, cmd.Execute(), errBuildFailed)
+	require.NotContains(t, stderr.String(), "与子命令名相近")
+
+	// 存在的同名目录（非首个参数）直接按输入处理。
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "serve"), 0o755))
+	stderr.Reset()
+	cmd = NewRootCmd(bytes.NewBuffer(nil), stderr)
+	cmd.SetArgs([]string{"--pandoc-path", filepath.Join(tmp, "missing-pandoc"), "a.md", "serve"})
+	require.ErrorIs(t, cmd.Execute(), errBuildFailed)
+	require.NotContains(t, stderr.String(), "与子命令名相近")
+}
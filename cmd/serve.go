@@ -0,0 +1,558 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hooziwang/syl-md2doc/internal/app"
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/spf13/cobra"
+)
+
+const (
+	docxContentType   = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+const serveLongHelp = `以 HTTP 服务方式提供 Markdown -> docx 转换。
+
+接口：
+1. POST /convert：请求体为 Markdown 文本，或 Markdown + 资源文件的 zip 包。
+   - 单个 Markdown 返回 docx；zip 中有多个 Markdown 时返回包含全部 docx 的 zip。
+   - 查询参数：filename（Markdown 文件名）、entry（zip 内只转换该文件）、lint、lint_block、retries、max_failures。
+   - 其他转换选项（纸张、水印、页眉页脚等）只取服务启动参数，请求不能覆盖；未知查询参数返回 400。
+   - 失败时返回 NDJSON（与命令行事件格式一致）。
+2. GET /healthz：健康检查（含 pandoc 信息）。
+3. GET /version：版本信息。
+
+模板与 pandoc 路径只取服务启动参数（--reference-docx、--pandoc-path），不接受请求覆盖。
+包含文件、图片与 front matter 中的参考文献、引用样式只能引用请求内的文件；图表只用 --diagram-renderer 显式配置的渲染器。
+并发转换数受 --jobs 限制，超出的请求排队等待。`
+
+type serveFlags struct {
+	listen         string
+	maxBodyBytes   int64
+	requestTimeout time.Duration
+}
+
+type convertServer struct {
+	build     buildFlags
+	converter *convert.PandocConverter
+	pandoc    convert.PandocInfo
+	slots     chan struct{}
+	maxBody   int64
+	timeout   time.Duration
+}
+
+func newServeCmd(stdout io.Writer, stderr io.Writer, flags *buildFlags) *cobra.Command {
+	sf := &serveFlags{}
+	cmd := &cobra.Command{
+		Use:           "serve",
+		Short:         "启动 HTTP 转换服务",
+		Long:          serveLongHelp,
+		Example:       "  syl-md2doc serve --listen :8080 --reference-docx /abs/template/ref.docx\n  curl --data-binary @a.md 'http://127.0.0.1:8080/convert?filename=a.md' -o a.docx",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				emitNDJSON(stderr, "error", "cwd_read_failed", "读取当前目录失败", map[string]any{
+					"error": err.Error(),
+				}, "检查运行目录是否可访问，或在可访问目录中重试")
+				return errBuildFailed
+			}
+			srv, err := newConvertServer(*flags, sf, cwd)
+			if err != nil {
+				emitNDJSON(stderr, "error", "serve_aborted", "转换服务启动失败", map[string]any{
+					"error": err.Error(),
+				}, suggestionForTopError(err.Error()))
+				return errBuildFailed
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			httpServer := &http.Server{
+				Addr:              sf.listen,
+				Handler:           srv.routes(),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- httpServer.ListenAndServe()
+			}()
+			emitNDJSON(stdout, "info", "serve_start", "转换服务已启动", map[string]any{
+				"listen":         sf.listen,
+				"jobs":           cap(srv.slots),
+				"pandoc_path":    srv.pandoc.BinaryPath,
+				"pandoc_version": srv.pandoc.Version,
+				"reference_docx": absPath(cwd, flags.referenceDocx),
+			}, "")
+
+			select {
+			case err := <-errCh:
+				emitNDJSON(stderr, "error", "serve_aborted", "转换服务异常退出", map[string]any{
+					"listen": sf.listen,
+					"error":  err.Error(),
+				}, "检查监听地址是否被占用，或更换 --listen 后重试")
+				return errBuildFailed
+			case <-ctx.Done():
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), sf.requestTimeout)
+			defer cancel()
+			if err := httpServer.Shutdown(shutdownCtx); err != nil {
+				emitNDJSON(stderr, "warn", "serve_shutdown", "等待进行中的请求超时，已强制退出", map[string]any{
+					"error": err.Error(),
+				}, "")
+				return nil
+			}
+			emitNDJSON(stdout, "info", "serve_shutdown", "转换服务已停止", nil, "")
+			return nil
+		},
+	}
+	bindBuildFlags(cmd, flags)
+	cmd.Flags().StringVar(&sf.listen, "listen", ":8080", "监听地址")
+	cmd.Flags().Int64Var(&sf.maxBodyBytes, "max-body", 32<<20, "请求体大小上限（字节）")
+	cmd.Flags().DurationVar(&sf.requestTimeout, "request-timeout", 5*time.Minute, "单个请求的转换超时")
+	return cmd
+}
+
+func newConvertServer(build buildFlags, sf *serveFlags, cwd string) (*convertServer, error) {
+	opts, err := build.appOptions(cwd)
+	if err != nil {
+		return nil, err
+	}
+	opts.ReferenceDocx = absPath(cwd, build.referenceDocx)
+	opts.Verbose = false
+	// 请求内容不可信：只运行运维显式配置（--diagram-renderer）的图表渲染器。
+	opts.Diagrams.ConfiguredOnly = true
+	conv, info, err := app.NewConverter(opts, cwd)
+	if err != nil {
+		return nil, err
+	}
+	jobs := build.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	return &convertServer{
+		build:     build,
+		converter: conv,
+		pandoc:    info,
+		slots:     make(chan struct{}, jobs),
+		maxBody:   sf.maxBodyBytes,
+		timeout:   sf.requestTimeout,
+	}, nil
+}
+
+func (s *convertServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/convert", s.handleConvert)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/version", s.handleVersion)
+	return mux
+}
+
+func (s *convertServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeEvent(w, http.StatusOK, "info", "health", "服务可用", map[string]any{
+		"status":         "ok",
+		"pandoc_path":    s.pandoc.BinaryPath,
+		"pandoc_version": s.pandoc.Version,
+		"busy_workers":   len(s.slots),
+		"jobs":           cap(s.slots),
+	}, "")
+}
+
+func (s *convertServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeEvent(w, http.StatusOK, "info", "version", versionText(), map[string]any{
+		"version":    Version,
+		"commit":     Commit,
+		"build_time": BuildTime,
+	}, "")
+}
+
+func (s *convertServer) handleConvert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeEvent(w, http.StatusMethodNotAllowed, "error", "invalid_request", "仅支持 POST", map[string]any{
+			"method": r.Method,
+		}, "使用 POST /convert 提交 Markdown 或 zip")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeEvent(w, http.StatusRequestEntityTooLarge, "error", "invalid_request", "请求体过大", map[string]any{
+				"max_body": s.maxBody,
+			}, "拆分文档后分批提交，或调大服务端 --max-body")
+			return
+		}
+		writeEvent(w, http.StatusBadRequest, "error", "invalid_request", "读取请求体失败", map[string]any{
+			"error": err.Error(),
+		}, "检查客户端上传是否完整后重试")
+		return
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		writeEvent(w, http.StatusBadRequest, "error", "invalid_input", "请求体为空", nil, "请求体应为 Markdown 文本或 zip 包")
+		return
+	}
+
+	opts, err := s.requestOptions(r)
+	if err != nil {
+		writeEvent(w, http.StatusBadRequest, "error", "invalid_request", "请求参数无效", map[string]any{
+			"error": err.Error(),
+		}, "检查查询参数名与取值类型；文档格式等选项需在服务启动时设置")
+		return
+	}
+
+	workDir, err := os.MkdirTemp("", "syl-md2doc-serve-*")
+	if err != nil {
+		writeEvent(w, http.StatusInternalServerError, "error", "build_aborted", "创建临时目录失败", map[string]any{
+			"error": err.Error(),
+		}, "检查服务端临时目录空间与权限")
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(workDir)
+	}()
+	srcDir := filepath.Join(workDir, "src")
+	outDir := filepath.Join(workDir, "out")
+
+	query := r.URL.Query()
+	inputs, err := stageRequestSources(body, srcDir, query.Get("filename"), query.Get("entry"), s.maxBody*8)
+	if err != nil {
+		writeEvent(w, http.StatusBadRequest, "error", "invalid_input", "解析请求内容失败", map[string]any{
+			"error": err.Error(),
+		}, "zip 包内应包含 .md 文件；entry 需为 zip 内的相对路径")
+		return
+	}
+	opts.Inputs = inputs
+	opts.CWD = srcDir
+	opts.OutputArg = outDir
+	opts.Converter = s.requestConverter(srcDir)
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		writeEvent(w, http.StatusServiceUnavailable, "error", "busy", "等待空闲转换槽位超时", map[string]any{
+			"jobs": cap(s.slots),
+		}, "稍后重试，或调大服务端 --jobs")
+		return
+	}
+	start := time.Now()
+	res, err := app.RunContext(ctx, opts)
+	<-s.slots
+	if err != nil {
+		writeEvent(w, http.StatusInternalServerError, "error", "build_aborted", "转换任务启动失败", map[string]any{
+			"error": err.Error(),
+		}, suggestionForTopError(err.Error()))
+		return
+	}
+
+	if res.FailureCount > 0 || len(res.OutputPaths) == 0 {
+		s.writeFailure(w, srcDir, res, time.Since(start))
+		return
+	}
+	w.Header().Set("X-Syl-Warning-Count", strconv.Itoa(res.WarningCount))
+	if len(res.OutputPaths) == 1 {
+		s.writeDocx(w, res.OutputPaths[0], docxName(query.Get("filename"), res, srcDir))
+		return
+	}
+	s.writeZip(w, srcDir, res)
+}
+
+// requestConverter 为每个请求复制转换器：相对图片按请求目录解析，包含的片段、图片与
+// front matter 中的参考文献、引用样式都只能来自请求自身上传的文件。
+func (s *convertServer) requestConverter(srcDir string) *convert.PandocConverter {
+	conv := *s.converter
+	conv.ResourcePath = srcDir
+	if rp := strings.TrimSpace(s.converter.ResourcePath); rp != "" {
+		conv.ResourcePath += string(os.PathListSeparator) + rp
+	}
+	conv.IncludeRoot = srcDir
+	conv.SandboxDir = srcDir
+	return &conv
+}
+
+// requestParams 是 /convert 接受的查询参数；其余转换选项只取服务启动参数。
+var requestParams = map[string]bool{"filename": true, "entry": true, "lint": true, "lint_block": true, "retries": true, "max_failures": true}
+
+// requestOptions 解析请求级参数；未知参数返回错误，避免调用方误以为 paper、watermark 等选项已生效。
+func (s *convertServer) requestOptions(r *http.Request) (app.Options, error) {
+	q := r.URL.Query()
+	unknown := make([]string, 0)
+	for k := range q {
+		if !requestParams[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return app.Options{}, fmt.Errorf("不支持的查询参数：%s（仅支持 filename、entry、lint、lint_block、retries、max_failures，其他选项只取服务启动参数）", strings.Join(unknown, ", "))
+	}
+	opts := app.Options{
+		Jobs:         1,
+		Retries:      s.build.retries,
+		RetryBackoff: s.build.retryBackoff,
+		MaxFailures:  s.build.failureThreshold(),
+		Lint:         s.build.lint || s.build.lintBlock,
+		LintBlock:    s.build.lintBlock,
+	}
+	if v := q.Get("retries"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("retries 需为非负整数：%s", v)
+		}
+		opts.Retries = n
+	}
+	if v := q.Get("max_failures"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("max_failures 需为非负整数：%s", v)
+		}
+		opts.MaxFailures = n
+	}
+	if v := q.Get("lint"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("lint 需为布尔值：%s", v)
+		}
+		opts.Lint = b
+	}
+	if v := q.Get("lint_block"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("lint_block 需为布尔值：%s", v)
+		}
+		opts.LintBlock = b
+		opts.Lint = opts.Lint || b
+	}
+	return opts, nil
+}
+
+func (s *convertServer) writeFailure(w http.ResponseWriter, srcDir string, res app.Result, elapsed time.Duration) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	emitDiagnostics(w, "lint_diagnostic", res.Diagnostics, func(p string) string { return relPath(srcDir, p) })
+	emitDiagnostics(w, "convert_diagnostic", res.ConvertDiagnostics, func(p string) string { return relPath(srcDir, p) })
+	for idx, f := range res.Failures {
+		emitNDJSON(w, "error", "file_failed", "文件转换失败", map[string]any{
+			"index":       idx + 1,
+			"source_path": relPath(srcDir, f.Source),
+			"reason":      relReason(srcDir, f.Reason),
+		}, suggestionForFailure(f.Reason))
+	}
+	suggestion := "修复失败项后重试；建议先按 file_failed 事件逐项处理"
+	if res.FailureCount == 0 {
+		suggestion = "请求中未发现可转换的 Markdown 文件"
+	}
+	status := "partial_failed"
+	if res.Stopped {
+		status = "stopped"
+	}
+	emitNDJSON(w, "error", "summary", "批量转换完成", map[string]any{
+		"status":        status,
+		"success_count": res.SuccessCount,
+		"failure_count": res.FailureCount,
+		"not_run_count": res.NotRunCount,
+		"warning_count": res.WarningCount,
+		"duration_ms":   elapsed.Milliseconds(),
+	}, suggestion)
+}
+
+func (s *convertServer) writeDocx(w http.ResponseWriter, path, name string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		writeEvent(w, http.StatusInternalServerError, "error", "build_aborted", "读取转换结果失败", map[string]any{
+			"error": err.Error(),
+		}, "检查服务端临时目录空间与权限")
+		return
+	}
+	w.Header().Set("Content-Type", docxContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (s *convertServer) writeZip(w http.ResponseWriter, srcDir string, res app.Result) {
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	for _, t := range res.Tasks {
+		if t.Status != app.TaskStatusSuccess {
+			continue
+		}
+		data, err := os.ReadFile(t.Target)
+		if err == nil {
+			var fw io.Writer
+			fw, err = zw.Create(filepath.ToSlash(replaceExt(relPath(srcDir, t.Source), ".docx")))
+			if err == nil {
+				_, err = fw.Write(data)
+			}
+		}
+		if err != nil {
+			writeEvent(w, http.StatusInternalServerError, "error", "build_aborted", "打包转换结果失败", map[string]any{
+				"error": err.Error(),
+			}, "检查服务端临时目录空间与权限")
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		writeEvent(w, http.StatusInternalServerError, "error", "build_aborted", "打包转换结果失败", map[string]any{
+			"error": err.Error(),
+		}, "检查服务端临时目录空间与权限")
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="documents.zip"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func writeEvent(w http.ResponseWriter, status int, level, event, message string, details map[string]any, suggestion string) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(status)
+	emitNDJSON(w, level, event, message, details, suggestion)
+}
+
+// stageRequestSources 把请求体落盘为待转换的源文件，返回 app.Run 的输入列表。
+func stageRequestSources(body []byte, srcDir, filename, entry string, maxUnpacked int64) ([]string, error) {
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建临时目录失败：%w", err)
+	}
+	if !bytes.HasPrefix(body, []byte("PK\x03\x04")) {
+		name := filepath.Base(strings.TrimSpace(filename))
+		if name == "" || name == "." || name == string(filepath.Separator) {
+			name = "document.md"
+		}
+		if !strings.EqualFold(filepath.Ext(name), ".md") {
+			name += ".md"
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, name), body, 0o644); err != nil {
+			return nil, fmt.Errorf("写入 Markdown 失败：%w", err)
+		}
+		return []string{name}, nil
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("zip 解析失败：%w", err)
+	}
+	var unpacked int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		target, err := safeJoin(srcDir, f.Name)
+		if err != nil {
+			return nil, err
+		}
+		unpacked += int64(f.UncompressedSize64)
+		if unpacked > maxUnpacked {
+			return nil, fmt.Errorf("zip 解压后超过 %d 字节上限", maxUnpacked)
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(entry) != "" {
+		target, err := safeJoin(srcDir, entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(target); err != nil {
+			return nil, fmt.Errorf("zip 中不存在 entry：%s", entry)
+		}
+		return []string{target}, nil
+	}
+	return []string{srcDir}, nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败：%w", err)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("读取 zip 条目失败（%s）：%w", f.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	out, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("写入 zip 条目失败（%s）：%w", f.Name, err)
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	if _, err := io.Copy(out, io.LimitReader(rc, int64(f.UncompressedSize64))); err != nil {
+		return fmt.Errorf("写入 zip 条目失败（%s）：%w", f.Name, err)
+	}
+	return nil
+}
+
+// safeJoin 拒绝绝对路径与 ../ 逃逸，防止 zip slip。
+func safeJoin(root, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("非法路径：%s", name)
+	}
+	return filepath.Join(root, clean), nil
+}
+
+func docxName(filename string, res app.Result, srcDir string) string {
+	name := strings.TrimSpace(filename)
+	if name == "" && len(res.Tasks) > 0 {
+		for _, t := range res.Tasks {
+			if t.Status == app.TaskStatusSuccess {
+				name = relPath(srcDir, t.Source)
+				break
+			}
+		}
+	}
+	if name == "" {
+		name = "document.md"
+	}
+	return replaceExt(filepath.Base(name), ".docx")
+}
+
+// relPath 把服务端临时目录下的路径转成请求内的相对路径，避免泄露服务端目录结构。
+func relPath(root, p string) string {
+	if rel, err := filepath.Rel(root, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(p)
+}
+
+func relReason(root, reason string) string {
+	return strings.ReplaceAll(reason, root+string(filepath.Separator), "")
+}
+
+func replaceExt(name, ext string) string {
+	baseExt := filepath.Ext(name)
+	if baseExt == "" {
+		return name + ext
+	}
+	return strings.TrimSuffix(name, baseExt) + ext
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const fakeServePandoc = "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 'pandoc 3.1.11'; exit 0; fi\nsrc=\"$1\"\nout=\"\"\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"-o\" ]; then out=\"$2\"; shift 2; continue; fi\n  shift\ndone\nif grep -q BROKEN \"$src\"; then echo 'fatal: broken' 1>&2; exit 1; fi\nmkdir -p \"$(dirname \"$out\")\"\nprintf 'docx' > \"$out\"\nexit 0\n"
+
+func newTestConvertServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return startTestConvertServer(t, fakeServePandoc, buildFlags{jobs: 2})
+}
+
+func startTestConvertServer(t *testing.T, script string, build buildFlags) *httptest.Server {
+	t.Helper()
+	tmp := t.TempDir()
+	build.pandocPath = filepath.Join(tmp, "fake-pandoc.sh")
+	require.NoError(t, os.WriteFile(build.pandocPath, []byte(script), 0o755))
+	srv, err := newConvertServer(build, &serveFlags{maxBodyBytes: 1 << 20, requestTimeout: time.Minute}, tmp)
+	require.NoError(t, err)
+	ts := httptest.NewServer(srv.routes())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postZip(t *testing.T, url string, files map[string]string) (*http.Response, []byte) {
+	t.Helper()
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(content))
+	}
+	require.NoError(t, zw.Close())
+	resp, err := http.Post(url, "application/zip", bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, body
+}
+
+func TestServeConvertMarkdown(t *testing.T) {
+	ts := newTestConvertServer(t)
+	resp, err := http.Post(ts.URL+"/convert?filename=guide.md", "text/markdown", strings.NewReader("# hi\n"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	require.Equal(t, docxContentType, resp.Header.Get("Content-Type"))
+	require.Contains(t, resp.Header.Get("Content-Disposition"), "guide.docx")
+	require.Equal(t, "docx", string(body))
+}
+
+func TestServeConvertZipReturnsZip(t *testing.T) {
+	ts := newTestConvertServer(t)
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	for _, name := range []string{"a.md", "sub/b.md", "img/x.png"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, _ = w.Write([]byte("# x"))
+	}
+	require.NoError(t, zw.Close())
+
+	resp, err := http.Post(ts.URL+"/convert", "application/zip", bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	require.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	names := make([]string, 0)
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	require.ElementsMatch(t, []string{"a.docx", "sub/b.docx"}, names)
+}
+
+func TestServeConvertFailureReturnsNDJSON(t *testing.T) {
+	ts := newTestConvertServer(t)
+	resp, err := http.Post(ts.URL+"/convert", "text/markdown", strings.NewReader("BROKEN"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	require.Equal(t, ndjsonContentType, resp.Header.Get("Content-Type"))
+	require.Contains(t, string(body), "\"event\":\"file_failed\"")
+	require.Contains(t, string(body), "\"source_path\":\"document.md\"")
+	require.Contains(t, string(body), "\"event\":\"summary\"")
+	require.NotContains(t, string(body), os.TempDir()+string(filepath.Separator)+"syl-md2doc-serve")
+}
+
+func TestServeRejectsZipSlip(t *testing.T) {
+	ts := newTestConvertServer(t)
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("../evil.md")
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("# x"))
+	require.NoError(t, zw.Close())
+
+	resp, err := http.Post(ts.URL+"/convert", "application/zip", bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServeConfinesIncludesToRequestFiles(t *testing.T) {
+	ts := startTestConvertServer(t, fakeServePandoc, buildFlags{jobs: 1, includeRoot: "/"})
+
+	for _, body := range []string{"!include /etc/hostname\n", "!include ../../../../etc/hostname\n"} {
+		resp, err := http.Post(ts.URL+"/convert", "text/markdown", strings.NewReader(body))
+		require.NoError(t, err)
+		out, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, string(out))
+		require.Contains(t, string(out), "包含")
+	}
+}
+
+func TestServeRejectsFilesOutsideRequest(t *testing.T) {
+	ts := newTestConvertServer(t)
+
+	for body, want := range map[string]string{
+		"![](/etc/hostname)\n":                       "图片超出请求内的文件：/etc/hostname",
+		"![x](../../../../etc/hostname)\n":           "图片超出请求内的文件",
+		"![x](https://example.com/a.png)\n":          "图片超出请求内的文件",
+		"---\nbibliography: /etc/passwd\n---\n# x\n": "参考文献文件超出请求内的文件：/etc/passwd",
+		"---\ncsl: /etc/passwd\n---\n# x\n":          "引用样式文件超出请求内的文件：/etc/passwd",
+	} {
+		resp, err := http.Post(ts.URL+"/convert", "text/markdown", strings.NewReader(body))
+		require.NoError(t, err)
+		out, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, string(out))
+		require.Contains(t, string(out), want)
+	}
+}
+
+func TestServeResolvesResourcesAgainstRequestFiles(t *testing.T) {
+	script := "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 'pandoc 3.1.11'; exit 0; fi\nout=\"\"\nrp=\"\"\nwhile [ $# -gt 0 ]; do\n  case \"$1\" in\n    -o) out=\"$2\"; shift 2; continue;;\n    --resource-path=*) rp=\"${1#--resource-path=}\";;\n  esac\n  shift\ndone\nif [ ! -f \"${rp%%:*}/img/x.png\" ]; then echo \"missing image under resource path: $rp\" 1>&2; exit 1; fi\nmkdir -p \"$(dirname \"$out\")\"\nprintf 'docx' > \"$out\"\n"
+	ts := startTestConvertServer(t, script, buildFlags{jobs: 1})
+
+	resp, body := postZip(t, ts.URL+"/convert", map[string]string{"a.md": "![x](img/x.png)\n", "img/x.png": "png"})
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	require.Equal(t, "docx", string(body))
+}
+
+func TestServeRejectsUnknownQueryParameters(t *testing.T) {
+	ts := newTestConvertServer(t)
+	resp, err := http.Post(ts.URL+"/convert?filename=a.md&paper=A3&watermark=x", "text/markdown", strings.NewReader("# hi\n"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, string(body))
+	require.Contains(t, string(body), "\"event\":\"invalid_request\"")
+	require.Contains(t, string(body), "不支持的查询参数：paper, watermark")
+}
+
+func TestServeReportsStoppedStatus(t *testing.T) {
+	ts := newTestConvertServer(t)
+	resp, body := postZip(t, ts.URL+"/convert?max_failures=1", map[string]string{"a.md": "BROKEN", "b.md": "BROKEN", "c.md": "BROKEN"})
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, string(body))
+	require.Contains(t, string(body), "\"status\":\"stopped\"")
+	require.NotContains(t, string(body), "partial_failed")
+}
+
+func TestServeHealthAndVersion(t *testing.T) {
+	ts := newTestConvertServer(t)
+	resp, err := http.Get(ts.URL + "/healthz")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "\"pandoc_version\":\"3.1.11\"")
+
+	resp, err = http.Get(ts.URL + "/version")
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Contains(t, string(body), "\"event\":\"version\"")
+
+	resp, err = http.Get(ts.URL + "/convert")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+const validateLongHelp = `检查已生成的 docx 文件结构，输出 NDJSON 诊断，提前发现 Word 打开时提示“无法读取的内容”的问题。
+
+检查项：
+1. docx-zip（error）：不是有效的 zip、条目损坏（CRC 校验失败）或部件重复。
+2. docx-content-types：缺少 [Content_Types].xml、部件没有内容类型或主文档内容类型不正确（error），Override 指向不存在的部件（warn）。
+3. docx-relationships（error）：缺少 officeDocument 关系、关系 Id 重复、内部关系目标不存在，或 r:id 引用了未定义的关系。
+4. docx-xml（error）：XML 格式错误或使用了未声明的命名空间前缀。
+5. docx-schema：段落、文本块与文字的嵌套位置错误，表格没有行或单元格不以段落结尾，w:sectPr 不在正文末尾，脚注/尾注引用不存在（error）；书签 id 重复（warn）。
+
+目录按递归查找其中的 .docx 文件。存在 error 级诊断时返回非 0。`
+
+func newValidateCmd(stdout io.Writer, stderr io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:           "validate [docx...]",
+		Short:         "校验 docx 文件结构",
+		Long:          validateLongHelp,
+		Example:       "  syl-md2doc validate /abs/out/a.docx /abs/out",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				emitNDJSON(stderr, "error", "invalid_input", "缺少输入参数", map[string]any{
+					"required": "至少一个 .docx 文件或目录",
+					"args":     args,
+				}, "至少传入一个 .docx 文件或目录，例如：syl-md2doc validate /abs/out/a.docx")
+				return errBuildFailed
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				emitNDJSON(stderr, "error", "cwd_read_failed", "读取当前目录失败", map[string]any{
+					"error": err.Error(),
+				}, "检查运行目录是否可访问，或在可访问目录中重试")
+				return errBuildFailed
+			}
+
+			start := time.Now()
+			files, fails := discoverDocx(args, cwd)
+			for _, f := range fails {
+				emitNDJSON(stderr, "error", "file_failed", "输入不可用", map[string]any{
+					"source_path": absPath(cwd, f.path),
+					"reason":      f.reason,
+				}, suggestionForFailure(f.reason))
+			}
+
+			diags := make([]job.Diagnostic, 0)
+			for _, path := range files {
+				diags = append(diags, validate.CheckFile(path)...)
+			}
+			emitDiagnostics(stdout, "validate_diagnostic", diags, func(p string) string { return absPath(cwd, p) })
+
+			errorCount := job.CountErrors(diags)
+			level := "info"
+			status := "success"
+			suggestion := ""
+			if errorCount > 0 || len(fails) > 0 {
+				level = "error"
+				status = "failed"
+				suggestion = "按 validate_diagnostic 事件中的部件与原因排查；若由 raw openxml 片段或模板引起，修正后重新生成"
+			}
+			emitNDJSON(stdout, level, "validate_summary", "docx 校验完成", map[string]any{
+				"status":        status,
+				"file_count":    len(files),
+				"error_count":   errorCount,
+				"warning_count": len(diags) - errorCount,
+				"failure_count": len(fails),
+				"duration_ms":   time.Since(start).Milliseconds(),
+			}, suggestion)
+			if status != "success" {
+				return errBuildFailed
+			}
+			return nil
+		},
+	}
+}
+
+type docxFailure struct {
+	path, reason string
+}
+
+// discoverDocx 展开输入：文件原样校验，目录递归查找 .docx（跳过 Word 的 ~$ 锁文件）；结果按路径去重排序。
+func discoverDocx(args []string, cwd string) ([]string, []docxFailure) {
+	seen := map[string]bool{}
+	var fails []docxFailure
+	for _, arg := range args {
+		path := absPath(cwd, arg)
+		info, err := os.Stat(path)
+		if err != nil {
+			fails = append(fails, docxFailure{path: path, reason: "输入不存在或不可访问"})
+			continue
+		}
+		if !info.IsDir() {
+			seen[path] = true
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			name := d.Name()
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(name), ".docx") && !strings.HasPrefix(name, "~$") {
+				seen[p] = true
+			}
+			return nil
+		})
+		if err != nil {
+			fails = append(fails, docxFailure{path: path, reason: "读取目录失败：" + err.Error()})
+		}
+	}
+	files := make([]string, 0, len(seen))
+	for p := range seen {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+	return files, fails
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCommandScansDirectories(t *testing.T) {
+	tmp := t.TempDir()
+	ref, err := os.ReadFile(filepath.Join("..", "internal", "convert", "templates", "default-reference.docx"))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.docx"), ref, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "sub", "b.DOCX"), ref, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "~$a.docx"), []byte("lock"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("# a\n"), 0o644))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{"validate", tmp, filepath.Join(tmp, "a.docx")})
+	require.NoError(t, cmd.Execute())
+	out := stdout.String()
+	require.Contains(t, out, "\"event\":\"validate_summary\"")
+	require.Contains(t, out, "\"status\":\"success\"")
+	require.Contains(t, out, "\"file_count\":2")
+	require.NotContains(t, out, "validate_diagnostic")
+	require.Empty(t, stderr.String())
+}
+
+func TestValidateCommandReportsDiagnostics(t *testing.T) {
+	tmp := t.TempDir()
+	broken := filepath.Join(tmp, "broken.docx")
+	require.NoError(t, os.WriteFile(broken, []byte("not a zip"), 0o644))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{"validate", broken, filepath.Join(tmp, "missing.docx")})
+	err := cmd.Execute()
+	require.ErrorIs(t, err, errBuildFailed)
+	out := stdout.String()
+	require.Contains(t, out, "\"event\":\"validate_diagnostic\"")
+	require.Contains(t, out, "\"code\":\"docx-zip\"")
+	require.Contains(t, out, "\"source_path\":\""+broken+"\"")
+	require.Contains(t, out, "\"error_count\":1")
+	require.Contains(t, out, "\"failure_count\":1")
+	require.Contains(t, stderr.String(), "\"event\":\"file_failed\"")
+}
+
+func TestValidateCommandRequiresInput(t *testing.T) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := NewRootCmd(stdout, stderr)
+	cmd.SetArgs([]string{"validate"})
+	require.ErrorIs(t, cmd.Execute(), errBuildFailed)
+	require.Contains(t, stderr.String(), "\"event\":\"invalid_input\"")
+}
@@ -1,4 +1,4 @@
-module syl-md2doc
+module github.com/hooziwang/syl-md2doc
 
 go 1.22
 
@@ -6,6 +6,7 @@ require (
 	github.com/hooziwang/daddylovesyl v0.1.0
 	github.com/spf13/cobra v1.10.2
 	github.com/stretchr/testify v1.10.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -13,5 +14,4 @@ require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/hooziwang/syl-md2doc/internal/frontmatter"
+	"gopkg.in/yaml.v3"
+)
+
+// loadPropertiesFile 读取 YAML 属性文件：顶层键值对即属性；若存在 properties 映射则一并读取。
+func loadPropertiesFile(path, cwd string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取属性文件失败：%w", err)
+	}
+	raw := map[string]any{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析属性文件失败（需为 YAML 键值对）：%w", err)
+	}
+	out := map[string]string{}
+	for k, v := range raw {
+		if k == "properties" {
+			continue
+		}
+		if s, ok := frontmatter.String(v, ", "); ok {
+			out[k] = s
+		}
+	}
+	for k, v := range frontmatter.StringMap(raw, "properties") {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// loadVarsFile 读取 YAML 变量文件（支持嵌套映射，按 a.b 访问），并用 overrides（--var）覆盖同名变量。
+func loadVarsFile(path, cwd string, overrides map[string]string) (map[string]string, error) {
+	if path == "" {
+		return overrides, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取变量文件失败：%w", err)
+	}
+	raw := map[string]any{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析变量文件失败（需为 YAML 键值对）：%w", err)
+	}
+	out := map[string]string{}
+	flattenVars(out, "", raw)
+	for k, v := range overrides {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func flattenVars(out map[string]string, prefix string, raw map[string]any) {
+	for k, v := range raw {
+		if nested, ok := v.(map[string]any); ok {
+			flattenVars(out, prefix+k+".", nested)
+			continue
+		}
+		if s, ok := frontmatter.String(v, ", "); ok {
+			out[prefix+k] = s
+		}
+	}
+}
+
+// resolveCover 把封面模板路径解析为绝对路径并确认可读，避免每个文件转换时才报错。
+func resolveCover(path, cwd string) (string, error) {
+	return resolveFile(path, cwd, "封面模板")
+}
+
+// resolveBibliography 解析 --bibliography 与 --csl 指定的文件。
+func resolveBibliography(paths []string, csl, cwd string) ([]string, string, error) {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		resolved, err := resolveFile(p, cwd, "参考文献")
+		if err != nil {
+			return nil, "", err
+		}
+		if resolved != "" {
+			out = append(out, resolved)
+		}
+	}
+	resolvedCSL, err := resolveFile(csl, cwd, "引用样式")
+	if err != nil {
+		return nil, "", err
+	}
+	return out, resolvedCSL, nil
+}
+
+// resolveHighlight 校验高亮设置；非内置主题名按主题文件解析。
+func resolveHighlight(opts convert.HighlightOptions, cwd string) (convert.HighlightOptions, error) {
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	if opts.Style == "" || convert.IsBuiltinHighlightStyle(opts.Style) {
+		return opts, nil
+	}
+	path, err := resolveFile(opts.Style, cwd, "高亮主题")
+	if err != nil {
+		return opts, fmt.Errorf("%w（内置主题：%s）", err, strings.Join(convert.BuiltinHighlightStyles(), ", "))
+	}
+	opts.Style = path
+	return opts, nil
+}
+
+// resolveDiagrams 校验图表设置，并把相对缓存目录解析为相对 CWD 的绝对路径。
+func resolveDiagrams(opts convert.DiagramOptions, cwd string) (convert.DiagramOptions, error) {
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	if opts.CacheDir != "" && !filepath.IsAbs(opts.CacheDir) {
+		opts.CacheDir = filepath.Join(cwd, opts.CacheDir)
+	}
+	return opts, nil
+}
+
+// resolveFonts 校验字体选项，把字体目录解析为绝对路径并确认其存在。
+func resolveFonts(opts convert.FontOptions, cwd string) (convert.FontOptions, error) {
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	dirs := make([]string, 0, len(opts.Dirs))
+	for _, dir := range opts.Dirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(cwd, dir)
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			return opts, fmt.Errorf("读取字体目录失败：%w", err)
+		}
+		if !info.IsDir() {
+			return opts, fmt.Errorf("字体目录不是目录：%s", dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	opts.Dirs = dirs
+	return opts, nil
+}
+
+func resolveDir(path, cwd, kind string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("读取%s失败：%w", kind, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s不是目录：%s", kind, path)
+	}
+	return filepath.Clean(path), nil
+}
+
+func resolveFile(path, cwd, kind string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("读取%s失败：%w", kind, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s不能是目录：%s", kind, path)
+	}
+	return path, nil
+}
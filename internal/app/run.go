@@ -7,13 +7,103 @@ import (
 	"runtime"
 	"strings"
 
-	"syl-md2doc/internal/convert"
-	"syl-md2doc/internal/input"
-	"syl-md2doc/internal/plan"
-	"syl-md2doc/internal/runner"
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/hooziwang/syl-md2doc/internal/input"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/lint"
+	"github.com/hooziwang/syl-md2doc/internal/plan"
+	"github.com/hooziwang/syl-md2doc/internal/runner"
 )
 
+// NewConverter 检查 pandoc 环境并按 opts 构造 pandoc 转换器；需要复用转换器的调用方（如 HTTP 服务）可单独调用。
+func NewConverter(opts Options, cwd string) (*convert.PandocConverter, convert.PandocInfo, error) {
+	info, err := convert.EnsurePandocAvailable(opts.PandocPath)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	defaults, err := loadPropertiesFile(opts.PropertiesFile, cwd)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	vars, err := loadVarsFile(opts.VarsFile, cwd, opts.Vars)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	if err := opts.Watermark.Validate(); err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	if err := opts.Admonitions.Validate(); err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	if err := opts.Page.Validate(); err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	if err := opts.BlankLines.Validate(); err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	if err := opts.Notes.Validate(); err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	cover, err := resolveCover(opts.Cover, cwd)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	bibliography, csl, err := resolveBibliography(opts.Bibliography, opts.CSL, cwd)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	highlight, err := resolveHighlight(opts.Highlight, cwd)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	diagrams, err := resolveDiagrams(opts.Diagrams, cwd)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	fontOpts, err := resolveFonts(opts.Fonts, cwd)
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	includeRoot, err := resolveDir(opts.IncludeRoot, cwd, "包含目录")
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	includeBase, err := resolveDir(opts.IncludeBase, cwd, "包含解析目录")
+	if err != nil {
+		return nil, convert.PandocInfo{}, err
+	}
+	pc := convert.NewPandocConverter(opts.PandocPath, opts.ReferenceDocx, opts.Verbose)
+	pc.ResourcePath = opts.ResourcePath
+	pc.IncludeRoot = includeRoot
+	pc.IncludeBase = includeBase
+	pc.Properties = convert.PropertyOptions{Defaults: defaults, Overrides: opts.Properties}
+	pc.HeaderFooter = opts.HeaderFooter
+	pc.Cover = cover
+	pc.Vars = vars
+	pc.Template = opts.Template || len(opts.Vars) > 0 || opts.VarsFile != ""
+	pc.Watermark = opts.Watermark
+	pc.Classification = opts.Classification
+	pc.Bibliography = bibliography
+	pc.CSL = csl
+	pc.Math = opts.Math
+	pc.Highlight = highlight
+	pc.Admonitions = opts.Admonitions
+	pc.Diagrams = diagrams
+	pc.Page = opts.Page
+	pc.BlankLines = opts.BlankLines
+	pc.Tables = opts.Tables
+	pc.Notes = opts.Notes
+	pc.Fonts = fontOpts
+	pc.Validate = opts.Validate
+	return pc, info, nil
+}
+
 func Run(opts Options) (Result, error) {
+	return RunContext(context.Background(), opts)
+}
+
+// RunContext 与 Run 相同，但 ctx 取消时会取消进行中的转换，未开始的任务记为未执行。
+func RunContext(ctx context.Context, opts Options) (Result, error) {
 	if len(opts.Inputs) == 0 {
 		return Result{}, fmt.Errorf("至少提供一个输入")
 	}
@@ -38,12 +128,12 @@ func Run(opts Options) (Result, error) {
 	conv := opts.Converter
 	pandocInfo := convert.PandocInfo{}
 	if conv == nil {
-		info, err := convert.EnsurePandocAvailable(opts.PandocPath)
+		pc, info, err := NewConverter(opts, cwd)
 		if err != nil {
 			return Result{}, err
 		}
 		pandocInfo = info
-		conv = convert.NewPandocConverter(opts.PandocPath, opts.ReferenceDocx, opts.Verbose)
+		conv = pc
 	}
 
 	sources, discoverWarns, discoverFails, err := input.Discover(opts.Inputs, cwd)
@@ -56,15 +146,58 @@ func Run(opts Options) (Result, error) {
 		return Result{}, err
 	}
 
-	summary := runner.Run(context.Background(), jobs, tasks, conv)
+	diagnostics := make([]job.Diagnostic, 0)
+	lintFails := make([]Failure, 0)
+	if opts.Lint {
+		kept := make([]job.Task, 0, len(tasks))
+		for _, t := range tasks {
+			diags := lint.CheckFile(t.SourcePath)
+			diagnostics = append(diagnostics, diags...)
+			if n := job.CountErrors(diags); opts.LintBlock && n > 0 {
+				lintFails = append(lintFails, Failure{Source: t.SourcePath, Reason: fmt.Sprintf("lint 检查未通过：%d 个错误", n)})
+				continue
+			}
+			kept = append(kept, t)
+		}
+		tasks = kept
+	}
+
+	// 批次映射随任务传给转换器，转换器本身不保存批次状态，可被并发的多次运行（如 serve）共用。
+	targets := convert.LinkTargets(tasks)
+	for i := range tasks {
+		tasks[i].Targets = targets
+	}
+
+	policy := runner.Policy{
+		Retries: opts.Retries,
+		Backoff: opts.RetryBackoff,
+	}
+	var summary runner.Summary
+	if opts.MaxFailures > 0 {
+		// 输入阶段与 lint 阻断的失败同样计入阈值；阈值已满时所有任务都不再执行。
+		policy.MaxFailures = opts.MaxFailures - len(discoverFails) - len(lintFails)
+		if policy.MaxFailures <= 0 {
+			summary = notRunSummary(tasks)
+		}
+	}
+	if !summary.Stopped {
+		summary = runner.RunWithPolicy(ctx, jobs, tasks, conv, policy)
+	}
 
 	result := Result{
-		SuccessCount: summary.SuccessCount,
-		Warnings:     make([]string, 0),
-		Failures:     make([]Failure, 0),
-		OutputPaths:  make([]string, 0),
-		PandocPath:   pandocInfo.BinaryPath,
-		PandocVer:    pandocInfo.Version,
+		SuccessCount:       summary.SuccessCount,
+		NotRunCount:        summary.NotRunCount,
+		RetryCount:         summary.RetryCount,
+		Stopped:            summary.Stopped,
+		Warnings:           make([]string, 0),
+		Failures:           make([]Failure, 0),
+		Diagnostics:        diagnostics,
+		ConvertDiagnostics: make([]job.Diagnostic, 0),
+		NotRun:             make([]string, 0),
+		Tasks:              make([]TaskResult, 0, len(summary.Results)),
+		OutputPaths:        make([]string, 0),
+		PandocPath:         pandocInfo.BinaryPath,
+		PandocVer:          pandocInfo.Version,
 	}
 	result.Warnings = append(result.Warnings, discoverWarns...)
 	result.Warnings = append(result.Warnings, planWarns...)
@@ -72,20 +205,51 @@ func Run(opts Options) (Result, error) {
 	for _, f := range discoverFails {
 		result.Failures = append(result.Failures, Failure{Source: f.Input, Reason: f.Reason})
 	}
+	for _, f := range lintFails {
+		result.Failures = append(result.Failures, f)
+		result.Tasks = append(result.Tasks, TaskResult{Source: f.Source, Status: TaskStatusFailed})
+	}
 	for _, item := range summary.Results {
 		result.Warnings = append(result.Warnings, item.Warnings...)
+		result.ConvertDiagnostics = append(result.ConvertDiagnostics, item.Diagnostics...)
+		taskResult := TaskResult{
+			Source:   item.Task.SourcePath,
+			Target:   item.Task.TargetPath,
+			Status:   TaskStatusSuccess,
+			Attempts: item.Attempts,
+			Includes: item.Includes,
+			Fonts:    item.Fonts,
+		}
+		if item.NotRun {
+			taskResult.Status = TaskStatusNotRun
+			result.Tasks = append(result.Tasks, taskResult)
+			result.NotRun = append(result.NotRun, item.Task.SourcePath)
+			continue
+		}
 		if item.Error != nil {
-			result.Failures = append(result.Failures, Failure{Source: item.Task.SourcePath, Reason: item.Error.Error()})
+			taskResult.Status = TaskStatusFailed
+			result.Tasks = append(result.Tasks, taskResult)
+			result.Failures = append(result.Failures, Failure{Source: item.Task.SourcePath, Reason: item.Error.Error(), Attempts: item.Attempts})
 			continue
 		}
+		result.Tasks = append(result.Tasks, taskResult)
 		result.OutputPaths = append(result.OutputPaths, item.Task.TargetPath)
 	}
 
-	if len(tasks) == 0 && len(discoverFails) == 0 {
+	if len(tasks) == 0 && len(discoverFails) == 0 && len(lintFails) == 0 {
 		result.Warnings = append(result.Warnings, "未发现可转换的 Markdown 文件")
 	}
 
 	result.FailureCount = len(result.Failures)
-	result.WarningCount = len(result.Warnings)
+	// 转换阶段的结构化告警原本也是 pandoc 告警，一并计数。
+	result.WarningCount = len(result.Warnings) + len(result.ConvertDiagnostics)
 	return result, nil
 }
+
+func notRunSummary(tasks []job.Task) runner.Summary {
+	summary := runner.Summary{Total: len(tasks), NotRunCount: len(tasks), Stopped: true, Results: make([]job.Result, 0, len(tasks))}
+	for _, t := range tasks {
+		summary.Results = append(summary.Results, job.Result{Task: t, NotRun: true})
+	}
+	return summary
+}
@@ -1,10 +1,14 @@
 package app
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/hooziwang/syl-md2doc/internal/job"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,3 +39,188 @@ func TestRunNoMarkdownWarn(t *testing.T) {
 	require.Equal(t, 0, res.FailureCount)
 	require.NotEmpty(t, res.Warnings)
 }
+
+func TestRunMaxFailuresCountsDiscoverFailures(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("# a"), 0o644))
+
+	res, err := Run(Options{
+		Inputs:      []string{"a.md", "missing.md"},
+		CWD:         tmp,
+		Converter:   &stubConverter{},
+		MaxFailures: 1,
+	})
+	require.NoError(t, err)
+	require.True(t, res.Stopped)
+	require.Equal(t, 0, res.SuccessCount)
+	require.Equal(t, 1, res.FailureCount)
+	require.Equal(t, 1, res.NotRunCount)
+	require.Equal(t, []string{filepath.Join(tmp, "a.md")}, res.NotRun)
+	require.Equal(t, TaskStatusNotRun, res.Tasks[0].Status)
+}
+
+func TestRunLintBlockSkipsFilesWithErrors(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("# a\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "b.md"), []byte("```\nunclosed\n"), 0o644))
+
+	res, err := Run(Options{
+		Inputs:    []string{"a.md", "b.md"},
+		CWD:       tmp,
+		Converter: &stubConverter{},
+		Lint:      true,
+		LintBlock: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.SuccessCount)
+	require.Equal(t, 1, res.FailureCount)
+	require.Contains(t, res.Failures[0].Reason, "lint")
+	require.Len(t, res.Diagnostics, 1)
+	require.Equal(t, 1, res.Diagnostics[0].Line)
+}
+
+type diagnosticConverter struct{}
+
+func (diagnosticConverter) Convert(ctx context.Context, task job.Task) job.Result {
+	return job.Result{Task: task, Diagnostics: []job.Diagnostic{{Source: task.SourcePath, Line: 3, Severity: job.SeverityWarn, Code: "unresolved-citation", Message: "引用键未在参考文献中找到：@x"}}}
+}
+
+func TestRunCollectsConvertDiagnostics(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("# a\n"), 0o644))
+
+	res, err := Run(Options{Inputs: []string{"a.md"}, CWD: tmp, Converter: diagnosticConverter{}})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.SuccessCount)
+	require.Empty(t, res.Diagnostics)
+	require.Len(t, res.ConvertDiagnostics, 1)
+	require.Equal(t, "unresolved-citation", res.ConvertDiagnostics[0].Code)
+	require.Equal(t, 1, res.WarningCount)
+}
+
+type includeConverter struct{}
+
+func (includeConverter) Convert(ctx context.Context, task job.Task) job.Result {
+	return job.Result{Task: task, Includes: []string{"/abs/shared/legal.md"}}
+}
+
+func TestRunRecordsIncludesPerTask(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("!include shared/legal.md\n"), 0o644))
+
+	res, err := Run(Options{Inputs: []string{"a.md"}, CWD: tmp, Converter: includeConverter{}})
+	require.NoError(t, err)
+	require.Len(t, res.Tasks, 1)
+	require.Equal(t, []string{"/abs/shared/legal.md"}, res.Tasks[0].Includes)
+}
+
+func TestResolveBibliography(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "refs.bib"), []byte(""), 0o644))
+
+	bibs, csl, err := resolveBibliography([]string{"refs.bib"}, "", tmp)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(tmp, "refs.bib")}, bibs)
+	require.Empty(t, csl)
+
+	_, _, err = resolveBibliography(nil, "missing.csl", tmp)
+	require.ErrorContains(t, err, "读取引用样式失败")
+}
+
+func TestResolveHighlight(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "corp.theme"), []byte("{}"), 0o644))
+
+	opts, err := resolveHighlight(convert.HighlightOptions{Style: "corp.theme"}, tmp)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(tmp, "corp.theme"), opts.Style)
+
+	opts, err = resolveHighlight(convert.HighlightOptions{Style: "Tango"}, tmp)
+	require.NoError(t, err)
+	require.Equal(t, "Tango", opts.Style)
+
+	_, err = resolveHighlight(convert.HighlightOptions{Style: "solarized"}, tmp)
+	require.ErrorContains(t, err, "读取高亮主题失败")
+	require.ErrorContains(t, err, "内置主题：pygments")
+
+	_, err = resolveHighlight(convert.HighlightOptions{Style: "tango", Disabled: true}, tmp)
+	require.Error(t, err)
+}
+
+func TestResolveFonts(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "fonts"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.ttf"), nil, 0o644))
+
+	opts, err := resolveFonts(convert.FontOptions{Embed: true, Dirs: []string{"fonts"}}, tmp)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(tmp, "fonts")}, opts.Dirs)
+
+	_, err = resolveFonts(convert.FontOptions{Embed: true}, tmp)
+	require.ErrorContains(t, err, "--embed-fonts 需要同时用 --font-dir 指定字体目录")
+	_, err = resolveFonts(convert.FontOptions{Dirs: []string{"missing"}}, tmp)
+	require.ErrorContains(t, err, "读取字体目录失败")
+	_, err = resolveFonts(convert.FontOptions{Dirs: []string{"a.ttf"}}, tmp)
+	require.ErrorContains(t, err, "字体目录不是目录")
+}
+
+func TestLoadVarsFileFlattensAndAppliesOverrides(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "pro.yaml"), []byte("edition: pro\nseats: 50\ncustomer:\n  name: ACME\n"), 0o644))
+
+	vars, err := loadVarsFile("pro.yaml", tmp, map[string]string{"seats": "10"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"edition": "pro", "seats": "10", "customer.name": "ACME"}, vars)
+
+	_, err = loadVarsFile("missing.yaml", tmp, nil)
+	require.ErrorContains(t, err, "读取变量文件失败")
+}
+
+func TestNewConverterEnablesTemplateWithVariables(t *testing.T) {
+	tmp := t.TempDir()
+	pandoc := filepath.Join(tmp, "fake-pandoc.sh")
+	require.NoError(t, os.WriteFile(pandoc, []byte("#!/bin/sh\necho 'pandoc 3.1.11'\n"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "vars.yaml"), []byte("edition: pro\n"), 0o644))
+
+	for _, tc := range []struct {
+		opts Options
+		want bool
+	}{
+		{Options{}, false},
+		{Options{Template: true}, true},
+		{Options{Vars: map[string]string{"edition": "pro"}}, true},
+		{Options{VarsFile: "vars.yaml"}, true},
+	} {
+		tc.opts.PandocPath = pandoc
+		pc, _, err := NewConverter(tc.opts, tmp)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, pc.Template, tc.opts)
+	}
+}
+
+type targetsConverter struct {
+	mu      sync.Mutex
+	targets []map[string]string
+}
+
+func (c *targetsConverter) Convert(ctx context.Context, task job.Task) job.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.targets = append(c.targets, task.Targets)
+	return job.Result{Task: task}
+}
+
+func TestRunPassesPlannedTargetsWithEachTask(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("[b](b.md)\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "b.md"), []byte("# b\n"), 0o644))
+
+	conv := &targetsConverter{}
+	res, err := Run(Options{Inputs: []string{"a.md", "b.md"}, CWD: tmp, Converter: conv})
+	require.NoError(t, err)
+	require.Len(t, conv.targets, 2)
+	for _, targets := range conv.targets {
+		require.Len(t, targets, 2)
+		require.Equal(t, res.Tasks[1].Target, targets[filepath.Join(tmp, "b.md")])
+	}
+}
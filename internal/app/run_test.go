@@ -7,8 +7,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/hooziwang/syl-md2doc/internal/job"
 	"github.com/stretchr/testify/require"
-	"syl-md2doc/internal/job"
 )
 
 type stubConverter struct{}
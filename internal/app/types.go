@@ -1,7 +1,10 @@
 package app
 
 import (
-	"syl-md2doc/internal/convert"
+	"time"
+
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/hooziwang/syl-md2doc/internal/job"
 )
 
 type Options struct {
@@ -10,23 +13,96 @@ type Options struct {
 	Jobs          int
 	ReferenceDocx string
 	PandocPath    string
+	ResourcePath  string
 	CWD           string
 	Verbose       bool
-	Converter     convert.Converter
+	Retries       int
+	RetryBackoff  time.Duration
+	MaxFailures   int
+	Lint          bool
+	LintBlock     bool
+	// Properties 来自 --set-property，优先级最高；PropertiesFile 为默认属性，优先级低于 front matter。
+	Properties     map[string]string
+	PropertiesFile string
+	HeaderFooter   convert.HeaderFooterOptions
+	// Cover 为封面模板（Markdown 或 .docx 片段）；Vars 来自 --var，供封面与页眉页脚模板使用。
+	Cover string
+	Vars  map[string]string
+	// VarsFile 为 YAML 变量文件（相对 CWD），其中的变量优先级低于 Vars、高于 front matter。
+	VarsFile string
+	// Template 启用正文模板（{{ name }} 与 {{ if }}）；设置了 Vars 或 VarsFile 时自动启用。
+	Template bool
+	// IncludeRoot 为包含指令允许读取的目录（相对 CWD），为空时为 IncludeBase。
+	IncludeRoot string
+	// IncludeBase 为主文档中包含路径的解析目录（相对 CWD），为空时为各主文档所在目录；库接口从流转换时为资源目录。
+	IncludeBase string
+	// Watermark 与 Classification 为空时可由 front matter 的 watermark / classification 字段按文件指定。
+	Watermark      convert.WatermarkOptions
+	Classification string
+	// Bibliography 与 CSL 启用 citeproc；也可由 front matter 的 bibliography / csl 字段按文件指定。
+	Bibliography []string
+	CSL          string
+	// Math 启用 $...$ / $$...$$ 公式并校验公式语法。
+	Math bool
+	// Highlight 为代码高亮设置；Style 可以是内置主题名或相对 CWD 的主题文件。
+	Highlight convert.HighlightOptions
+	// Admonitions 控制 GitHub alerts 与 ::: 提示块的渲染方式与样式名。
+	Admonitions convert.AdmonitionOptions
+	// Diagrams 控制 mermaid / plantuml 代码块的渲染；CacheDir 相对 CWD。
+	Diagrams convert.DiagramOptions
+	// Page 为纸张、页边距与方向，应用到文档的每一节。
+	Page convert.PageOptions
+	// BlankLines 为空行处理方式：preserve（默认）、collapse 或 extra-only。
+	BlankLines convert.BlankLineMode
+	// Tables 为表格样式、表头重复与条带行设置。
+	Tables convert.TableOptions
+	// Notes 为脚注/尾注形式与编号设置。
+	Notes convert.NoteOptions
+	// Fonts 为字体嵌入设置；Dirs 相对 CWD。
+	Fonts convert.FontOptions
+	// Validate 校验每个输出 docx 的结构，校验失败的任务记为失败。
+	Validate  bool
+	Converter convert.Converter
 }
 
+const (
+	TaskStatusSuccess = "success"
+	TaskStatusFailed  = "failed"
+	TaskStatusNotRun  = "not_run"
+)
+
 type Failure struct {
-	Source string
-	Reason string
+	Source   string
+	Reason   string
+	Attempts int
+}
+
+type TaskResult struct {
+	Source   string
+	Target   string
+	Status   string
+	Attempts int
+	// Includes 是该文件通过包含指令依赖的其他文件。
+	Includes []string
+	// Fonts 是输出文档引用的字体及查找结果（verbose 或嵌入字体时）。
+	Fonts []job.FontUsage
 }
 
 type Result struct {
 	SuccessCount int
 	FailureCount int
 	WarningCount int
+	NotRunCount  int
+	RetryCount   int
+	Stopped      bool
 	Warnings     []string
 	Failures     []Failure
-	OutputPaths  []string
-	PandocPath   string
-	PandocVer    string
+	Diagnostics  []job.Diagnostic
+	// ConvertDiagnostics 是转换阶段的结构化告警（如未解析的引用键），与 lint 诊断分开输出。
+	ConvertDiagnostics []job.Diagnostic
+	NotRun             []string
+	Tasks              []TaskResult
+	OutputPaths        []string
+	PandocPath         string
+	PandocVer          string
 }
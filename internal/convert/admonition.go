@@ -0,0 +1,250 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	AdmonitionModeBox   = "box"
+	AdmonitionModeStyle = "style"
+)
+
+// admonitionTitleKey 是 --admonition-style 中标题行样式的键。
+const admonitionTitleKey = "title"
+
+// admonitionKinds 是支持的提示块类型（与 GitHub alerts 一致），依次为默认样式名、标题、图标、强调色与底色。
+var admonitionKinds = []struct {
+	name   string
+	style  string
+	title  string
+	icon   string
+	border string
+	fill   string
+}{
+	{"note", "Admonition Note", "说明", "ℹ", "0969DA", "DDF4FF"},
+	{"tip", "Admonition Tip", "提示", "💡", "1A7F37", "DAFBE1"},
+	{"important", "Admonition Important", "重要", "❗", "8250DF", "FBEFFF"},
+	{"warning", "Admonition Warning", "警告", "⚠", "9A6700", "FFF8C5"},
+	{"caution", "Admonition Caution", "危险", "⛔", "CF222E", "FFEBE9"},
+}
+
+// admonitionAliases 把 ::: 块中常见的其他类名映射到上述类型。
+var admonitionAliases = map[string]string{
+	"info":      "note",
+	"hint":      "tip",
+	"attention": "warning",
+	"danger":    "caution",
+	"error":     "caution",
+}
+
+const defaultAdmonitionTitleStyle = "Admonition Title"
+
+// fencedDivRe 匹配 ::: 围栏块的开始或结束行。
+var fencedDivRe = regexp.MustCompile(`^ {0,3}:::`)
+
+// AdmonitionOptions 控制提示块（GitHub alerts 与 ::: 围栏块）的渲染方式。
+type AdmonitionOptions struct {
+	// Mode 为 box（默认，带底色与左侧色条的单元格表格）或 style（仅套用段落样式）。
+	Mode string
+	// Styles 覆盖段落样式名，键为 note/tip/important/warning/caution 或 title（标题行）。
+	Styles map[string]string
+}
+
+func (o AdmonitionOptions) Validate() error {
+	switch o.Mode {
+	case "", AdmonitionModeBox, AdmonitionModeStyle:
+	default:
+		return fmt.Errorf("--admonition-mode 仅支持 %s 或 %s：%s", AdmonitionModeBox, AdmonitionModeStyle, o.Mode)
+	}
+	for key, name := range o.Styles {
+		if !isAdmonitionStyleKey(key) {
+			return fmt.Errorf("--admonition-style 不支持的类型：%s（可用：%s）", key, strings.Join(admonitionStyleKeys(), ", "))
+		}
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("--admonition-style 的样式名不能为空：%s", key)
+		}
+	}
+	return nil
+}
+
+func isAdmonitionStyleKey(key string) bool {
+	for _, k := range admonitionStyleKeys() {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func admonitionStyleKeys() []string {
+	keys := []string{admonitionTitleKey}
+	for _, k := range admonitionKinds {
+		keys = append(keys, k.name)
+	}
+	return keys
+}
+
+func (o AdmonitionOptions) style(key, fallback string) string {
+	for k, v := range o.Styles {
+		if strings.EqualFold(k, key) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return fallback
+}
+
+// hasFencedDivs 判断正文（代码块之外）是否使用了 ::: 围栏块；只有用到时才开启 fenced_divs 扩展。
+func hasFencedDivs(body string) bool {
+	inFence := false
+	fenceChar := byte(0)
+	fenceLen := 0
+	for _, line := range strings.Split(body, "\n") {
+		if ch, n, ok := fenceMarker(strings.TrimSpace(line)); ok {
+			if !inFence {
+				inFence, fenceChar, fenceLen = true, ch, n
+				continue
+			}
+			if ch == fenceChar && n >= fenceLen {
+				inFence = false
+			}
+			continue
+		}
+		if !inFence && fencedDivRe.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildAdmonitionLuaFilter 生成识别提示块的 Lua 过滤器：
+// GitHub alerts（> [!NOTE]，或 pandoc alerts 扩展解析出的 Div）与 ::: note 等围栏块。
+// box 模式用原始 OOXML 包出带底色的单元格表格，块内内容仍由 pandoc 正常转换；style 模式仅套用段落样式。
+func buildAdmonitionLuaFilter(o AdmonitionOptions) string {
+	mode := o.Mode
+	if mode == "" {
+		mode = AdmonitionModeBox
+	}
+	var b strings.Builder
+	b.WriteString("local admonition_mode = " + strconv.Quote(mode) + "\n")
+	b.WriteString("local admonition_title_style = " + strconv.Quote(o.style(admonitionTitleKey, defaultAdmonitionTitleStyle)) + "\n")
+	b.WriteString("local admonition_close = " + strconv.Quote(`</w:tc></w:tr></w:tbl><w:p><w:pPr><w:spacing w:before="0" w:after="0" w:line="120" w:lineRule="exact"/></w:pPr></w:p>`) + "\n")
+	b.WriteString("local admonition_kinds = {\n")
+	for _, k := range admonitionKinds {
+		open := `<w:tbl><w:tblPr><w:tblW w:w="5000" w:type="pct"/><w:tblBorders><w:left w:val="single" w:sz="24" w:space="0" w:color="` + k.border + `"/></w:tblBorders>` +
+			`<w:tblCellMar><w:top w:w="80" w:type="dxa"/><w:left w:w="160" w:type="dxa"/><w:bottom w:w="80" w:type="dxa"/><w:right w:w="160" w:type="dxa"/></w:tblCellMar><w:tblLook w:val="0000"/></w:tblPr>` +
+			`<w:tblGrid><w:gridCol/></w:tblGrid><w:tr><w:tc><w:tcPr><w:tcW w:w="5000" w:type="pct"/><w:shd w:val="clear" w:color="auto" w:fill="` + k.fill + `"/></w:tcPr>`
+		fmt.Fprintf(&b, "  %s = { style = %s, title = %s, icon = %s, open = %s },\n",
+			k.name, strconv.Quote(o.style(k.name, k.style)), strconv.Quote(k.title), strconv.Quote(k.icon), strconv.Quote(open))
+	}
+	b.WriteString("}\n")
+	b.WriteString("local admonition_aliases = {")
+	aliases := make([]string, 0, len(admonitionAliases))
+	for alias := range admonitionAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for i, alias := range aliases {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, " %s = %s", alias, strconv.Quote(admonitionAliases[alias]))
+	}
+	b.WriteString(" }\n")
+	b.WriteString(admonitionLuaFunctions)
+	return b.String()
+}
+
+const admonitionLuaFunctions = `
+local function admonition_kind(name)
+  name = string.lower(name or "")
+  name = admonition_aliases[name] or name
+  if admonition_kinds[name] then
+    return name
+  end
+  return nil
+end
+
+local function admonition(kind, title, blocks)
+  local k = admonition_kinds[kind]
+  local inlines = pandoc.List({pandoc.Str(k.icon), pandoc.Space()})
+  inlines:extend(title)
+  local heading = pandoc.Div({pandoc.Para({pandoc.Strong(inlines)})}, {["custom-style"] = admonition_title_style})
+  local content = pandoc.List({heading})
+  content:extend(blocks)
+  if admonition_mode == "style" then
+    return pandoc.Div(content, {["custom-style"] = k.style})
+  end
+  local out = pandoc.List({pandoc.RawBlock("openxml", k.open)})
+  out:extend(content)
+  out:insert(pandoc.RawBlock("openxml", admonition_close))
+  return out
+end
+
+function Div(el)
+  local kind = nil
+  for _, class in ipairs(el.classes) do
+    kind = admonition_kind(class)
+    if kind then
+      break
+    end
+  end
+  if not kind then
+    return nil
+  end
+  local blocks = pandoc.List(el.content)
+  -- pandoc alerts 扩展会插入英文标题，统一替换为本地化标题。
+  if #blocks > 0 and blocks[1].t == "Div" and blocks[1].classes:includes("title") then
+    blocks:remove(1)
+  end
+  local title = {pandoc.Str(admonition_kinds[kind].title)}
+  if el.attributes["title"] and el.attributes["title"] ~= "" then
+    title = {pandoc.Str(el.attributes["title"])}
+  end
+  return admonition(kind, title, blocks)
+end
+
+function BlockQuote(el)
+  local first = el.content[1]
+  if not first or (first.t ~= "Para" and first.t ~= "Plain") then
+    return nil
+  end
+  local inlines = first.content
+  if #inlines == 0 or inlines[1].t ~= "Str" then
+    return nil
+  end
+  local name = inlines[1].text:match("^%[!(%a+)%]$")
+  local kind = name and admonition_kind(name)
+  if not kind then
+    return nil
+  end
+  -- [!NOTE] 同一行后面的文字作为自定义标题，换行之后为正文。
+  local title = pandoc.List()
+  local i = 2
+  while i <= #inlines and inlines[i].t ~= "LineBreak" and inlines[i].t ~= "SoftBreak" do
+    title:insert(inlines[i])
+    i = i + 1
+  end
+  while #title > 0 and title[1].t == "Space" do
+    title:remove(1)
+  end
+  if #title == 0 then
+    title = {pandoc.Str(admonition_kinds[kind].title)}
+  end
+  local blocks = pandoc.List()
+  local rest = pandoc.List()
+  for j = i + 1, #inlines do
+    rest:insert(inlines[j])
+  end
+  if #rest > 0 then
+    blocks:insert(pandoc.Para(rest))
+  end
+  for j = 2, #el.content do
+    blocks:insert(el.content[j])
+  end
+  return admonition(kind, title, blocks)
+end
+`
@@ -0,0 +1,74 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmonitionOptionsValidate(t *testing.T) {
+	require.NoError(t, AdmonitionOptions{}.Validate())
+	require.NoError(t, AdmonitionOptions{Mode: AdmonitionModeStyle, Styles: map[string]string{"Warning": "警告框", "title": "提示标题"}}.Validate())
+	require.ErrorContains(t, AdmonitionOptions{Mode: "panel"}.Validate(), "--admonition-mode")
+	require.ErrorContains(t, AdmonitionOptions{Styles: map[string]string{"danger": "x"}}.Validate(), "不支持的类型：danger")
+	require.ErrorContains(t, AdmonitionOptions{Styles: map[string]string{"note": " "}}.Validate(), "样式名不能为空")
+}
+
+func TestBuildAdmonitionLuaFilter(t *testing.T) {
+	script := buildAdmonitionLuaFilter(AdmonitionOptions{Styles: map[string]string{"WARNING": "Corp Warning", "title": "Corp Title"}})
+	require.Contains(t, script, `local admonition_mode = "box"`)
+	require.Contains(t, script, `local admonition_title_style = "Corp Title"`)
+	require.Contains(t, script, `warning = { style = "Corp Warning", title = "警告"`)
+	require.Contains(t, script, `note = { style = "Admonition Note", title = "说明"`)
+	require.Contains(t, script, `w:fill=\"FFF8C5\"`)
+	require.Contains(t, script, `danger = "caution"`)
+	require.Contains(t, script, "function BlockQuote(el)")
+	require.Contains(t, script, "function Div(el)")
+
+	script = buildAdmonitionLuaFilter(AdmonitionOptions{Mode: AdmonitionModeStyle})
+	require.Contains(t, script, `local admonition_mode = "style"`)
+	require.Contains(t, script, `local admonition_title_style = "Admonition Title"`)
+}
+
+func TestHasFencedDivs(t *testing.T) {
+	require.True(t, hasFencedDivs("# t\n\n::: warning\n小心\n:::\n"))
+	require.True(t, hasFencedDivs("::::: {.note title=\"自定义\"}\nx\n:::::\n"))
+	require.False(t, hasFencedDivs("```\n::: warning\n```\n"))
+	require.False(t, hasFencedDivs("> [!NOTE]\n> x\n"))
+	require.False(t, hasFencedDivs("    ::: 缩进代码\n"))
+}
+
+func TestConvertEnablesFencedDivsAndAdmonitionFilter(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("::: tip\n先备份\n:::\n"), 0o644))
+	var got []string
+	var filter string
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		got = args
+		for _, a := range args {
+			if strings.HasPrefix(a, "--lua-filter=") {
+				data, err := os.ReadFile(strings.TrimPrefix(a, "--lua-filter="))
+				require.NoError(t, err)
+				filter = string(data)
+			}
+		}
+		return exec.CommandContext(ctx, "true")
+	}
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Admonitions = AdmonitionOptions{Mode: AdmonitionModeStyle}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Contains(t, got, "gfm+raw_attribute+hard_line_breaks+fenced_divs")
+	require.Contains(t, filter, "KeywordHighlight")
+	require.Contains(t, filter, `local admonition_mode = "style"`)
+}
@@ -0,0 +1,141 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BlankLineMode 控制正文空行是否转换为 Word 空段落。
+type BlankLineMode string
+
+const (
+	// BlankLinesPreserve 把每个空行都保留为空段落（默认）。
+	BlankLinesPreserve BlankLineMode = "preserve"
+	// BlankLinesCollapse 按标准 Markdown 处理，空行只分隔块，不生成空段落。
+	BlankLinesCollapse BlankLineMode = "collapse"
+	// BlankLinesExtraOnly 只为连续两个及以上的空行生成空段落，第一个空行仍作为分隔。
+	BlankLinesExtraOnly BlankLineMode = "extra-only"
+)
+
+const emptyParagraphBlock = "```{=openxml}\n<w:p/>\n```"
+
+// listItemRe 匹配列表项的开始行。
+var listItemRe = regexp.MustCompile(`^ {0,3}(?:[-*+]|\d{1,9}[.)])(?:\s|$)`)
+
+func (m BlankLineMode) Validate() error {
+	switch m {
+	case "", BlankLinesPreserve, BlankLinesCollapse, BlankLinesExtraOnly:
+		return nil
+	}
+	return fmt.Errorf("--blank-lines 仅支持 %s、%s 或 %s：%s", BlankLinesPreserve, BlankLinesCollapse, BlankLinesExtraOnly, m)
+}
+
+func preserveMarkdownBlankLines(input string) (string, bool) {
+	return formatBlankLines(input, BlankLinesPreserve)
+}
+
+// formatBlankLines 按 mode 把围栏代码块之外的空行替换为 raw openxml 空段落。
+// 列表内部（下一行仍是列表项或缩进的续行）、脚注定义内部（下一行为缩进 4 列的续段或另一条脚注定义）
+// 与缩进代码块内部的空行属于 Markdown 结构，始终原样保留。
+func formatBlankLines(input string, mode BlankLineMode) (string, bool) {
+	if mode == BlankLinesCollapse {
+		return input, false
+	}
+	normalized := strings.ReplaceAll(input, "\r\n", "\n")
+	lines := strings.Split(normalized, "\n")
+	hasTrailingNewline := strings.HasSuffix(normalized, "\n")
+	if hasTrailingNewline && len(lines) > 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	out := make([]string, 0, len(lines))
+	inFence := false
+	fenceChar := byte(0)
+	fenceLen := 0
+	changed := false
+	// inList 表示当前处于列表中；inNote 表示处于脚注定义中；inIndented 表示处于缩进代码块中；
+	// text 表示上一行是段落等文字行（缩进行视为懒续行）。
+	inList, inNote, inIndented, text := false, false, false, false
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if inFence || trimmed != "" {
+			if ch, n, ok := fenceMarker(trimmed); ok && !inIndented {
+				if !inFence {
+					inFence, fenceChar, fenceLen = true, ch, n
+				} else if ch == fenceChar && n >= fenceLen {
+					inFence, fenceChar, fenceLen = false, 0, 0
+				}
+				text = false
+			} else if !inFence {
+				indent := indentWidth(line)
+				switch {
+				case listItemRe.MatchString(line):
+					inList, inIndented = true, false
+				case footnoteDefRe.MatchString(line):
+					inNote, inIndented = true, false
+				case indent >= 4 && !inList && !inNote && !text:
+					inIndented = true
+				case indent < 4:
+					inIndented = false
+				}
+				text = !inIndented
+			}
+			out = append(out, line)
+			i++
+			continue
+		}
+
+		// 一段连续的空行：根据下一行判断是否仍在列表或缩进代码块内部。
+		j := i
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		inner := false
+		if j < len(lines) {
+			next := lines[j]
+			inner = inList && (listItemRe.MatchString(next) || indentWidth(next) >= 2) ||
+				inNote && (footnoteDefRe.MatchString(next) || indentWidth(next) >= 4) ||
+				inIndented && indentWidth(next) >= 4
+		}
+		for k := i; k < j; k++ {
+			if inner || mode == BlankLinesExtraOnly && k == i {
+				out = append(out, lines[k])
+				continue
+			}
+			out = append(out, emptyParagraphBlock)
+			changed = true
+		}
+		if !inner {
+			inList, inNote, inIndented = false, false, false
+		}
+		text = false
+		i = j
+	}
+	if !changed {
+		return input, false
+	}
+	result := strings.Join(out, "\n")
+	if hasTrailingNewline {
+		result += "\n"
+	}
+	return result, true
+}
+
+// indentWidth 返回行首缩进的列数，制表符按 4 列对齐。
+func indentWidth(line string) int {
+	width := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
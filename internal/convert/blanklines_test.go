@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBlankLinesModes(t *testing.T) {
+	in := "第一段\n\n第二段\n\n\n\n第三段\n"
+	p := emptyParagraphBlock
+
+	out, changed := formatBlankLines(in, BlankLinesPreserve)
+	require.True(t, changed)
+	require.Equal(t, "第一段\n"+p+"\n第二段\n"+p+"\n"+p+"\n"+p+"\n第三段\n", out)
+
+	out, changed = formatBlankLines(in, "")
+	require.True(t, changed)
+	require.Equal(t, "第一段\n"+p+"\n第二段\n"+p+"\n"+p+"\n"+p+"\n第三段\n", out)
+
+	out, changed = formatBlankLines(in, BlankLinesExtraOnly)
+	require.True(t, changed)
+	require.Equal(t, "第一段\n\n第二段\n\n"+p+"\n"+p+"\n第三段\n", out)
+
+	out, changed = formatBlankLines("a\n\nb\n", BlankLinesExtraOnly)
+	require.False(t, changed)
+	require.Equal(t, "a\n\nb\n", out)
+
+	out, changed = formatBlankLines(in, BlankLinesCollapse)
+	require.False(t, changed)
+	require.Equal(t, in, out)
+}
+
+func TestFormatBlankLinesKeepsListsAndIndentedCode(t *testing.T) {
+	p := emptyParagraphBlock
+	in := "- 第一项\n\n  续行\n\n- 第二项\n\n\n1. 有序\n\n   说明\n\n之后\n\n    code 1\n\n    code 2\n\n正文\n    懒续行\n\n结尾"
+	out, changed := formatBlankLines(in, BlankLinesPreserve)
+	require.True(t, changed)
+	require.Equal(t, "- 第一项\n\n  续行\n\n- 第二项\n\n\n1. 有序\n\n   说明\n"+p+"\n之后\n"+p+"\n    code 1\n\n    code 2\n"+p+"\n正文\n    懒续行\n"+p+"\n结尾", out)
+}
+
+func TestBlankLineModeValidate(t *testing.T) {
+	require.NoError(t, BlankLineMode("").Validate())
+	require.NoError(t, BlankLinesExtraOnly.Validate())
+	require.ErrorContains(t, BlankLineMode("keep").Validate(), "--blank-lines 仅支持 preserve、collapse 或 extra-only：keep")
+}
+
+func TestFormatBlankLinesKeepsFootnoteDefinitions(t *testing.T) {
+	p := emptyParagraphBlock
+	in := "正文[^a][^b]\n\n[^a]: 第一段\n\n    第二段\n\n        code\n\n[^b]: 另一条\n\n之后"
+	out, changed := formatBlankLines(in, BlankLinesPreserve)
+	require.True(t, changed)
+	require.Equal(t, "正文[^a][^b]\n"+p+"\n[^a]: 第一段\n\n    第二段\n\n        code\n\n[^b]: 另一条\n"+p+"\n之后", out)
+}
@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+)
+
+var (
+	// pageBreakRe 匹配独占一行的分页标记：\newpage、\pagebreak、<!-- pagebreak --> 或 ---pagebreak---。
+	pageBreakRe = regexp.MustCompile(`(?i)^ {0,3}(?:\\newpage|\\pagebreak|<!--\s*pagebreak\s*-->|-{3,}\s*pagebreak\s*-{3,})\s*$`)
+	// sectionBreakRe 匹配独占一行的分节标记：\newsection、<!-- sectionbreak --> 或 ---sectionbreak---。
+	sectionBreakRe = regexp.MustCompile(`(?i)^ {0,3}(?:\\newsection|<!--\s*sectionbreak\s*-->|-{3,}\s*sectionbreak\s*-{3,})\s*$`)
+)
+
+// explicitBreaks 把代码块之外的分页、分节标记替换为 raw openxml 分页符与分节符。
+// 标记前后紧邻的各一个空行视为分隔，不再生成空段落。
+// changed 表示正文有改动，sections 表示插入了分节符（页面设置由后处理补齐）。
+func explicitBreaks(body string) (out string, changed, sections bool) {
+	lines := strings.Split(body, "\n")
+	code := codeLines(lines)
+	kept := make([]string, 0, len(lines))
+	skipBlank := false
+	for i, line := range lines {
+		if skipBlank && i < len(lines)-1 && strings.TrimSpace(line) == "" {
+			skipBlank = false
+			continue
+		}
+		skipBlank = false
+		if code[i] {
+			kept = append(kept, line)
+			continue
+		}
+		paragraph := ""
+		switch {
+		case pageBreakRe.MatchString(line):
+			paragraph = docx.PageBreakParagraph
+		case sectionBreakRe.MatchString(line):
+			paragraph = docx.SectionBreakParagraph
+			sections = true
+		default:
+			kept = append(kept, line)
+			continue
+		}
+		if n := len(kept); n > 0 && strings.TrimSpace(kept[n-1]) == "" {
+			kept = kept[:n-1]
+		}
+		kept = append(kept, "```{=openxml}", paragraph, "```")
+		skipBlank = true
+		changed = true
+	}
+	if !changed {
+		return body, false, false
+	}
+	return strings.Join(kept, "\n"), true, sections
+}
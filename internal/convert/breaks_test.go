@@ -0,0 +1,50 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplicitBreaks(t *testing.T) {
+	pageBreak := "```{=openxml}\n" + docx.PageBreakParagraph + "\n```"
+	sectionBreak := "```{=openxml}\n" + docx.SectionBreakParagraph + "\n```"
+
+	body := "第一章\n\n\\newpage\n\n第二章\n<!-- PageBreak -->\n第三章\n---pagebreak---\n\n\n第四章\n  <!--sectionbreak-->\n附录\n"
+	out, changed, sections := explicitBreaks(body)
+	require.True(t, changed)
+	require.True(t, sections)
+	// 标记两侧各一个空行视为分隔，多余的空行仍保留为空段落。
+	require.Equal(t, "第一章\n"+pageBreak+"\n第二章\n"+pageBreak+"\n第三章\n"+pageBreak+"\n\n第四章\n"+sectionBreak+"\n附录\n", out)
+
+	out, _, _ = explicitBreaks("正文\n\\pagebreak\n")
+	require.Equal(t, "正文\n"+pageBreak+"\n", out)
+}
+
+func TestExplicitBreaksSkipsCodeAndInlineText(t *testing.T) {
+	body := "```\n\\newpage\n```\n~~~~\n<!-- sectionbreak -->\n~~~~\n    \\newpage\n见 \\newpage 命令\n---\n"
+	out, changed, sections := explicitBreaks(body)
+	require.False(t, changed)
+	require.False(t, sections)
+	require.Equal(t, body, out)
+}
+
+func TestPrepareSourceMarksSectionBreaks(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# 正文\n\n---sectionbreak---\n\n# 附录\n"), 0o644))
+
+	prepared, _, err := (&PandocConverter{}).prepareSource(context.Background(), job.Task{SourcePath: src})
+	require.NoError(t, err)
+	defer os.Remove(prepared.path)
+	require.True(t, prepared.sections)
+	require.False(t, prepared.lastLandscape)
+	bs, err := os.ReadFile(prepared.path)
+	require.NoError(t, err)
+	require.Equal(t, "# 正文\n```{=openxml}\n"+docx.SectionBreakParagraph+"\n```\n# 附录\n", string(bs))
+}
@@ -0,0 +1,163 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const DiagnosticUnresolvedCitation = "unresolved-citation"
+
+// citeprocMetaKeys 是透传给 pandoc citeproc 的 front matter 键；front matter 在预处理时已从正文剥离。
+var citeprocMetaKeys = []string{"references", "nocite", "link-citations", "link-bibliography", "reference-section-title", "suppress-bibliography", "lang"}
+
+// unresolvedCitationRe 匹配 pandoc（Citeproc: citation KEY not found）与旧版 pandoc-citeproc 的未解析引用告警。
+var unresolvedCitationRe = regexp.MustCompile(`(?i)(?:citeproc: citation|pandoc-citeproc: reference) (\S+) not found`)
+
+// citeproc 汇总单个文件的参考文献设置：命令行 --bibliography/--csl 与 front matter 的 bibliography/csl。
+type citeproc struct {
+	bibliography []string
+	csl          string
+	meta         map[string]any
+}
+
+// citeprocFor 合并命令行与 front matter 的参考文献设置；front matter 中的相对路径相对源文件所在目录。
+func (p *PandocConverter) citeprocFor(task job.Task, meta map[string]any) (citeproc, error) {
+	c := citeproc{bibliography: append([]string(nil), p.Bibliography...), csl: p.CSL}
+	dir := filepath.Dir(task.SourcePath)
+	resolve := func(kind, raw string) (string, error) {
+		path := raw
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if p.SandboxDir != "" && !insideDir(p.SandboxDir, filepath.Clean(path)) {
+			return "", fmt.Errorf("front matter 中的%s文件超出请求内的文件：%s", kind, raw)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("front matter 中的%s文件不存在：%s", kind, raw)
+		}
+		return path, nil
+	}
+	var bibs []any
+	switch v := meta["bibliography"].(type) {
+	case string:
+		bibs = []any{v}
+	case []any:
+		bibs = v
+	}
+	for _, item := range bibs {
+		raw, ok := item.(string)
+		if !ok || strings.TrimSpace(raw) == "" {
+			continue
+		}
+		path, err := resolve("参考文献", strings.TrimSpace(raw))
+		if err != nil {
+			return citeproc{}, err
+		}
+		c.bibliography = append(c.bibliography, path)
+	}
+	if raw, ok := meta["csl"].(string); ok && strings.TrimSpace(raw) != "" {
+		path, err := resolve("引用样式", strings.TrimSpace(raw))
+		if err != nil {
+			return citeproc{}, err
+		}
+		c.csl = path
+	}
+	for _, key := range citeprocMetaKeys {
+		if v, ok := meta[key]; ok {
+			if c.meta == nil {
+				c.meta = map[string]any{}
+			}
+			c.meta[key] = v
+		}
+	}
+	return c, nil
+}
+
+// enabled 在有参考文献文件或 front matter 内联 references 时开启 citeproc。
+func (c citeproc) enabled() bool {
+	_, inline := c.meta["references"]
+	return len(c.bibliography) > 0 || inline
+}
+
+// args 返回 citeproc 的 pandoc 参数；透传的元数据写入临时文件，调用方负责在转换后删除。
+func (c citeproc) args() ([]string, string, error) {
+	if !c.enabled() {
+		return nil, "", nil
+	}
+	args := []string{"--citeproc"}
+	for _, bib := range c.bibliography {
+		args = append(args, "--bibliography="+bib)
+	}
+	if c.csl != "" {
+		args = append(args, "--csl="+c.csl)
+	}
+	if len(c.meta) == 0 {
+		return args, "", nil
+	}
+	data, err := yaml.Marshal(c.meta)
+	if err != nil {
+		return nil, "", fmt.Errorf("生成引用元数据失败：%w", err)
+	}
+	f, err := os.CreateTemp("", "syl-md2doc-meta-*.yaml")
+	if err != nil {
+		return nil, "", job.Transient(fmt.Errorf("创建临时元数据文件失败：%w", err))
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.Write(data); err != nil {
+		_ = os.Remove(f.Name())
+		return nil, "", job.Transient(fmt.Errorf("写入临时元数据文件失败：%w", err))
+	}
+	return append(args, "--metadata-file="+f.Name()), f.Name(), nil
+}
+
+// citationDiagnostics 从 pandoc stderr 中提取未解析的引用键，在交给 pandoc 的展开后正文中查找，
+// 再定位到实际所在的源文件（主文档或包含的片段）与行号；返回诊断与剩余的 stderr 行。
+func citationDiagnostics(stderrText string, task job.Task, body includedSource) ([]job.Diagnostic, string) {
+	if !unresolvedCitationRe.MatchString(stderrText) {
+		return nil, stderrText
+	}
+	lines := strings.Split(body.body, "\n")
+	diags := make([]job.Diagnostic, 0)
+	seen := map[string]bool{}
+	rest := make([]string, 0)
+	for _, line := range strings.Split(stderrText, "\n") {
+		m := unresolvedCitationRe.FindStringSubmatch(line)
+		if m == nil {
+			rest = append(rest, line)
+			continue
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(m[1], "@"), ".")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		diags = append(diags, job.Diagnostic{
+			Source:   task.SourcePath,
+			Line:     citationLine(lines, key),
+			Severity: job.SeverityWarn,
+			Code:     DiagnosticUnresolvedCitation,
+			Message:  fmt.Sprintf("引用键未在参考文献中找到：@%s", key),
+		})
+	}
+	return body.locate(diags), strings.Join(rest, "\n")
+}
+
+// citationLine 返回 @key 在正文中首次出现的行号（从 1 开始），找不到时返回 0。
+func citationLine(lines []string, key string) int {
+	re := regexp.MustCompile(`(?:^|[^\w@])@\{?` + regexp.QuoteMeta(key) + `\}?(?:[^\w:./-]|[:./-](?:\W|$)|$)`)
+	for i := range lines {
+		if re.MatchString(lines[i]) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,130 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertEnablesCiteprocAndReportsUnresolvedCitations(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "refs.bib"), []byte("@book{knuth84, title={TeX}}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ieee.csl"), []byte("<style/>"), 0o644))
+	src := filepath.Join(dir, "paper.md")
+	content := "---\nbibliography: refs.bib\ncsl: ieee.csl\nlink-citations: true\n---\n# 引言\n\n见 [@knuth84]。\n\n另见 [@missing2020; @knuth84]。\n"
+	require.NoError(t, os.WriteFile(src, []byte(content), 0o644))
+	argsFile := filepath.Join(dir, "args.txt")
+
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	script := `printf '%s\n' "$@" > "$ARGS_FILE"
+for a in "$@"; do case "$a" in --metadata-file=*) cat "${a#--metadata-file=}" >> "$ARGS_FILE" ;; esac; done
+echo "[WARNING] Citeproc: citation missing2020 not found" >&2
+echo "[WARNING] Could not fetch resource logo.png" >&2`
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, "sh", append([]string{"-c", script, "pandoc"}, args...)...)
+		cmd.Env = append(os.Environ(), "ARGS_FILE="+argsFile)
+		return cmd
+	}
+
+	conv := NewPandocConverter("pandoc", "", false)
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "out", "paper.docx")})
+	require.NoError(t, res.Error)
+
+	data, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	args := string(data)
+	require.Contains(t, args, "gfm+raw_attribute+hard_line_breaks+citations\n")
+	require.Contains(t, args, "--citeproc\n")
+	require.Contains(t, args, "--bibliography="+filepath.Join(dir, "refs.bib")+"\n")
+	require.Contains(t, args, "--csl="+filepath.Join(dir, "ieee.csl")+"\n")
+	require.Contains(t, args, "link-citations: true")
+
+	require.Equal(t, []job.Diagnostic{{
+		Source:   src,
+		Line:     10,
+		Severity: job.SeverityWarn,
+		Code:     DiagnosticUnresolvedCitation,
+		Message:  "引用键未在参考文献中找到：@missing2020",
+	}}, res.Diagnostics)
+	require.Equal(t, []string{"[WARNING] Could not fetch resource logo.png"}, res.Warnings)
+}
+
+func TestConvertLocatesUnresolvedCitationInIncludedFragment(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"refs.bib":         "@book{knuth84, title={TeX}}\n",
+		"paper.md":         "---\nbibliography: refs.bib\n---\n# 引言\n\n!include parts/related.md\n\n见 [@knuth84]。\n",
+		"parts/related.md": "## 相关工作\n\n早期研究 [@missing2020]。\n",
+	})
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", `echo "[WARNING] Citeproc: citation missing2020 not found" >&2`)
+	}
+
+	src := filepath.Join(dir, "paper.md")
+	res := NewPandocConverter("pandoc", "", false).Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "paper.docx")})
+	require.NoError(t, res.Error)
+	require.Equal(t, []job.Diagnostic{{
+		Source:   filepath.Join(dir, "parts", "related.md"),
+		Line:     3,
+		Severity: job.SeverityWarn,
+		Code:     DiagnosticUnresolvedCitation,
+		Message:  "引用键未在参考文献中找到：@missing2020",
+	}}, res.Diagnostics)
+}
+
+func TestConvertWithoutBibliographyKeepsDefaultReader(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("见 [@knuth84]。\n"), 0o644))
+	var got []string
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		got = args
+		return exec.CommandContext(ctx, "true")
+	}
+
+	res := NewPandocConverter("pandoc", "", false).Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Contains(t, got, "gfm+raw_attribute+hard_line_breaks")
+	for _, a := range got {
+		require.False(t, strings.HasPrefix(a, "--citeproc") || strings.HasPrefix(a, "--bibliography"), a)
+	}
+}
+
+func TestCiteprocForFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte("[]"), 0o644))
+	task := job.Task{SourcePath: filepath.Join(dir, "doc.md")}
+	p := &PandocConverter{Bibliography: []string{"/abs/global.bib"}, CSL: "/abs/global.csl"}
+
+	c, err := p.citeprocFor(task, map[string]any{"bibliography": []any{"a.json"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"/abs/global.bib", filepath.Join(dir, "a.json")}, c.bibliography)
+	require.Equal(t, "/abs/global.csl", c.csl)
+	require.True(t, c.enabled())
+
+	_, err = p.citeprocFor(task, map[string]any{"csl": "missing.csl"})
+	require.ErrorContains(t, err, "front matter 中的引用样式文件不存在：missing.csl")
+
+	c, err = (&PandocConverter{}).citeprocFor(task, map[string]any{"references": []any{map[string]any{"id": "x"}}})
+	require.NoError(t, err)
+	require.True(t, c.enabled(), "内联 references 也应启用 citeproc")
+	require.False(t, citeproc{}.enabled())
+}
+
+func TestCitationLine(t *testing.T) {
+	lines := strings.Split("标题\n邮箱 a@smith.com\n见 @smith:2020 与 [@smith]\n", "\n")
+	require.Equal(t, 3, citationLine(lines, "smith"))
+	require.Equal(t, 3, citationLine(lines, "smith:2020"))
+	require.Equal(t, 0, citationLine(lines, "jones"))
+}
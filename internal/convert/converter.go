@@ -3,7 +3,7 @@ package convert
 import (
 	"context"
 
-	"syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/job"
 )
 
 type Converter interface {
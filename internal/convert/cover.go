@@ -0,0 +1,143 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/frontmatter"
+)
+
+// markdownCoverBreak 以 raw openxml 分节符把 Markdown 封面与正文分开，页面设置由后处理补齐。
+const markdownCoverBreak = "\n\n```{=openxml}\n" + docx.SectionBreakParagraph + "\n```\n\n"
+
+const revisionTableVar = "revision_table"
+
+// revisionColumns 是修订记录表的列：front matter revisions 条目的键（含别名）与表头。
+var revisionColumns = []struct {
+	keys   []string
+	header string
+}{
+	{[]string{"version"}, "版本"},
+	{[]string{"date"}, "日期"},
+	{[]string{"author"}, "作者"},
+	{[]string{"changes", "description", "summary"}, "修订说明"},
+}
+
+func isDocxCover(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".docx")
+}
+
+// renderMarkdownCover 读取 Markdown 封面模板并替换 {name} 占位符；{revision_table} 展开为修订记录表。
+func renderMarkdownCover(path string, vars *templateVars) (string, []string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("读取封面模板失败：%w", err)
+	}
+	tpl := frontmatter.Split(string(content)).Body
+	lookup := func(name string) (string, bool) {
+		if strings.EqualFold(name, revisionTableVar) {
+			headers, rows := revisionRows(vars.meta)
+			return markdownTable(headers, rows), true
+		}
+		return vars.lookup(name)
+	}
+	out, undefined := docx.FillText(tpl, lookup)
+	return strings.TrimRight(out, "\n"), appendUndefined(nil, map[string]bool{}, "封面", undefined), nil
+}
+
+// applyCover 把 docx 封面片段插入正文开头，并补齐封面节的页面设置。Markdown 封面已在预处理中插入。
+func (p *PandocConverter) applyCover(pkg *docx.Package, vars *templateVars) ([]string, error) {
+	var warnings []string
+	if isDocxCover(p.Cover) {
+		fragment, err := docx.Open(p.Cover)
+		if err != nil {
+			return nil, fmt.Errorf("读取封面模板失败：%w", err)
+		}
+		tableStyle := pkg.StyleID("Table")
+		err = pkg.PrependFragment(fragment, func(body string) string {
+			out, undefined := docx.FillPlaceholders(body, vars.lookup, func(name string) (string, bool) {
+				if !strings.EqualFold(name, revisionTableVar) {
+					return "", false
+				}
+				headers, rows := revisionRows(vars.meta)
+				return docx.Table(headers, rows, tableStyle), true
+			})
+			warnings = appendUndefined(warnings, map[string]bool{}, "封面", undefined)
+			return out
+		})
+		if err != nil {
+			return warnings, err
+		}
+	}
+	return warnings, pkg.FinishCoverSection()
+}
+
+// revisionRows 从 front matter 的 revisions 列表生成修订记录表；只保留至少有一行填写了的列。
+func revisionRows(meta map[string]any) ([]string, [][]string) {
+	items, _ := meta["revisions"].([]any)
+	used := make([]bool, len(revisionColumns))
+	cells := make([][]string, 0, len(items))
+	for _, item := range items {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		row := make([]string, len(revisionColumns))
+		for i, col := range revisionColumns {
+			for _, key := range col.keys {
+				if s, ok := frontmatter.String(entry[key], "; "); ok {
+					row[i] = s
+					used[i] = true
+					break
+				}
+			}
+		}
+		cells = append(cells, row)
+	}
+
+	headers := make([]string, 0, len(revisionColumns))
+	keep := make([]int, 0, len(revisionColumns))
+	for i, col := range revisionColumns {
+		if used[i] || len(cells) == 0 {
+			headers = append(headers, col.header)
+			keep = append(keep, i)
+		}
+	}
+	rows := make([][]string, 0, len(cells))
+	for _, row := range cells {
+		out := make([]string, 0, len(keep))
+		for _, i := range keep {
+			out = append(out, row[i])
+		}
+		rows = append(rows, out)
+	}
+	return headers, rows
+}
+
+func markdownTable(headers []string, rows [][]string) string {
+	cell := func(s string) string {
+		s = strings.ReplaceAll(s, "|", `\|`)
+		return strings.Join(strings.Fields(s), " ")
+	}
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" " + cell(c) + " |")
+		}
+		b.WriteString("\n")
+	}
+	line(headers)
+	sep := make([]string, len(headers))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	line(sep)
+	for _, row := range rows {
+		line(row)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,295 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const (
+	DiagnosticUnresolvedReference = "unresolved-reference"
+	DiagnosticDuplicateLabel      = "duplicate-label"
+)
+
+// crossrefBookmarkBase 是交叉引用书签的起始 id，避开 pandoc 为标题生成的书签 id。
+const crossrefBookmarkBase = 100000
+
+var (
+	// figureLabelRe 匹配独占一行的带标签图片：![说明](a.png){#fig:id}。
+	figureLabelRe = regexp.MustCompile(`^\s{0,3}!\[([^\]]*)\]\(([^)]*)\)\s*\{#fig:([A-Za-z0-9_-]+)\}\s*$`)
+	// headingLabelRe 匹配带标签的 ATX 标题：## 标题 {#sec:id}。
+	headingLabelRe = regexp.MustCompile(`^(\s{0,3}#{1,6}\s+)(.*?)\s*\{#sec:([A-Za-z0-9_-]+)\}\s*$`)
+	// atxHeadingRe 匹配 ATX 标题，用于计算标题编号。
+	atxHeadingRe = regexp.MustCompile(`^\s{0,3}(#{1,6})(?:\s|$)`)
+	// crossrefRe 匹配 @fig:id、@tbl:id、@sec:id、@secnum:id 引用；前面紧跟字母数字或反斜杠时不视为引用（如邮箱地址）。
+	crossrefRe = regexp.MustCompile(`(^|[^A-Za-z0-9_\\])@(fig|tbl|secnum|sec):([A-Za-z0-9_-]+)`)
+)
+
+// crossrefBookmarkMaxLen 是 Word 书签名的最大长度。
+const crossrefBookmarkMaxLen = 40
+
+// crossrefKinds 是各类标签的编号前缀与题注段落样式（pandoc 参考模板中的样式 id）。
+var crossrefKinds = map[string]struct{ prefix, style string }{
+	"fig": {"图", "ImageCaption"},
+	"tbl": {"表", "TableCaption"},
+}
+
+// crossrefLabel 是一个已定义的标签。
+type crossrefLabel struct {
+	line     int
+	bookmark string
+	// text 为引用处显示的文字：图表为“图 3”，标题为标题文字。
+	text string
+	// number 为标题按 ATX 层级计算的编号（如 2.1），作为 @secnum 引用更新域前的显示值。
+	number string
+}
+
+// resolveCrossrefs 为带标签的图片、表格与标题生成编号题注与书签，并把 @fig:id 等引用替换为 REF 域，
+// Word 中更新域后编号与标题文字会随文档变化。未找到标签的引用原样保留并返回告警。
+// 返回的正文保留行号映射。
+func resolveCrossrefs(task job.Task, src includedSource) (includedSource, []job.Diagnostic) {
+	lines := strings.Split(src.body, "\n")
+	code := codeLines(lines)
+	diags := make([]job.Diagnostic, 0)
+	report := func(line int, code, format string, args ...any) {
+		diags = append(diags, job.Diagnostic{
+			Source:   task.SourcePath,
+			Line:     line,
+			Severity: job.SeverityWarn,
+			Code:     code,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// 第一遍收集标签并编号，引用可以出现在标签之前。
+	labels := make(map[string]crossrefLabel)
+	counts := make(map[string]int)
+	usedBookmarks := make(map[string]bool)
+	define := func(i int, key, text, number string) string {
+		if first, ok := labels[key]; ok {
+			report(i+1, DiagnosticDuplicateLabel, "交叉引用标签重复：#%s（首次定义于第 %d 行）", key, src.lines[first.line-1].line)
+			return ""
+		}
+		name := crossrefBookmarkName(key, usedBookmarks)
+		labels[key] = crossrefLabel{line: i + 1, bookmark: name, text: text, number: number}
+		return name
+	}
+	bookmarks := make(map[int]string)
+	var headingCounts [6]int
+	for i, line := range lines {
+		if code[i] {
+			continue
+		}
+		number := ""
+		if m := atxHeadingRe.FindStringSubmatch(line); m != nil {
+			number = nextHeadingNumber(&headingCounts, len(m[1]))
+		}
+		if m := figureLabelRe.FindStringSubmatch(line); m != nil {
+			counts["fig"]++
+			bookmarks[i] = define(i, "fig:"+m[3], fmt.Sprintf("%s %d", crossrefKinds["fig"].prefix, counts["fig"]), "")
+		} else if c, ok := parseTableCaption(line); ok && c.numbered() {
+			counts["tbl"]++
+			if c.label != "" {
+				bookmarks[i] = define(i, "tbl:"+c.label, fmt.Sprintf("%s %d", crossrefKinds["tbl"].prefix, counts["tbl"]), "")
+			}
+		} else if m := headingLabelRe.FindStringSubmatch(line); m != nil {
+			bookmarks[i] = define(i, "sec:"+m[3], plainHeadingText(m[2]), number)
+		}
+	}
+
+	out := includedSource{files: src.files, lines: make([]sourceLine, 0, len(src.lines))}
+	kept := make([]string, 0, len(lines))
+	emit := func(i int, text ...string) {
+		for _, t := range text {
+			kept = append(kept, t)
+			out.lines = append(out.lines, src.lines[i])
+		}
+	}
+	counts = make(map[string]int)
+	bookmarkID := crossrefBookmarkBase
+	for i, line := range lines {
+		unresolved := func(ref string) {
+			report(i+1, DiagnosticUnresolvedReference, "交叉引用未找到对应标签：@%s", ref)
+		}
+		if code[i] {
+			emit(i, line)
+			continue
+		}
+		if m := figureLabelRe.FindStringSubmatch(line); m != nil {
+			counts["fig"]++
+			bookmarkID++
+			caption := captionParagraph("fig", counts["fig"], replaceCrossrefs(m[1], labels, unresolved), bookmarks[i], bookmarkID)
+			// 保留说明作为图片的替代文字；行尾的不换行空格使 pandoc 不再把它当作带题注的图（题注已单独生成）。
+			emit(i, "!["+plainCrossrefs(m[1], labels)+"]("+m[2]+")\\ ", "```{=openxml}", caption, "```")
+			continue
+		}
+		if c, ok := parseTableCaption(line); ok {
+			if !c.numbered() {
+				// 只有列宽提示的标题行不生成题注。
+				continue
+			}
+			counts["tbl"]++
+			bookmarkID++
+			caption := captionParagraph("tbl", counts["tbl"], replaceCrossrefs(c.text, labels, unresolved), bookmarks[i], bookmarkID)
+			emit(i, "```{=openxml}", caption, "```")
+			continue
+		}
+		if m := headingLabelRe.FindStringSubmatch(line); m != nil {
+			line = m[1] + m[2]
+			if name := bookmarks[i]; name != "" {
+				bookmarkID++
+				line = fmt.Sprintf("%s`<w:bookmarkStart w:id=\"%d\" w:name=\"%s\"/>`{=openxml}%s`<w:bookmarkEnd w:id=\"%d\"/>`{=openxml}", m[1], bookmarkID, name, m[2], bookmarkID)
+			}
+		}
+		emit(i, replaceCrossrefs(line, labels, unresolved))
+	}
+	out.body = strings.Join(kept, "\n")
+	sort.SliceStable(diags, func(a, b int) bool { return diags[a].Line < diags[b].Line })
+	return out, src.locate(diags)
+}
+
+// replaceCrossrefs 把行内代码之外的 @fig:id 等引用替换为指向书签的 REF 域；未找到的引用转义后原样输出。
+// @secnum:id 引用标题的编号（REF \w），需要参考模板的标题样式带多级列表编号，否则更新域后为空。
+func replaceCrossrefs(line string, labels map[string]crossrefLabel, unresolved func(string)) string {
+	if !strings.Contains(line, "@") {
+		return line
+	}
+	return mapOutsideCode(line, func(s string) string {
+		return crossrefRe.ReplaceAllStringFunc(s, func(ref string) string {
+			m := crossrefRe.FindStringSubmatch(ref)
+			key := m[2] + ":" + m[3]
+			numbered := m[2] == "secnum"
+			if numbered {
+				key = "sec:" + m[3]
+			}
+			label, ok := labels[key]
+			if !ok {
+				unresolved(m[2] + ":" + m[3])
+				return m[1] + `\@` + m[2] + ":" + m[3]
+			}
+			if numbered {
+				return m[1] + "`" + docx.FieldRuns("REF "+label.bookmark+` \w \h`, label.number) + "`{=openxml}"
+			}
+			return m[1] + "`" + docx.FieldRuns("REF "+label.bookmark+` \h`, label.text) + "`{=openxml}"
+		})
+	})
+}
+
+// plainCrossrefs 把说明中的引用替换为纯文字（如“图 3”），用于图片的替代文字；未找到的引用转义后原样输出。
+func plainCrossrefs(s string, labels map[string]crossrefLabel) string {
+	return crossrefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		m := crossrefRe.FindStringSubmatch(ref)
+		label, ok := labels[strings.TrimSuffix(m[2], "num")+":"+m[3]]
+		switch {
+		case !ok:
+			return m[1] + `\@` + m[2] + ":" + m[3]
+		case m[2] == "secnum":
+			return m[1] + label.number
+		default:
+			return m[1] + label.text
+		}
+	})
+}
+
+// captionParagraph 生成“图 3 说明”形式的题注段落；编号为 SEQ 域，书签包住“图 3”供 REF 引用。
+func captionParagraph(kind string, n int, caption, bookmark string, id int) string {
+	k := crossrefKinds[kind]
+	number := docx.TextRun(k.prefix+" ", "") + docx.FieldRuns("SEQ "+k.prefix+` \* ARABIC`, fmt.Sprint(n))
+	if bookmark != "" {
+		number = fmt.Sprintf(`<w:bookmarkStart w:id="%d" w:name="%s"/>%s<w:bookmarkEnd w:id="%d"/>`, id, bookmark, number, id)
+	}
+	text := ""
+	if caption = strings.TrimSpace(caption); caption != "" {
+		// 说明中的引用已替换为 REF 域，其余部分按纯文本输出。
+		text = captionRuns(" " + caption)
+	}
+	return `<w:p><w:pPr><w:pStyle w:val="` + k.style + `"/></w:pPr>` + number + text + `</w:p>`
+}
+
+// captionRuns 把含 `...`{=openxml} 片段的说明文字转换为 run 序列。
+func captionRuns(s string) string {
+	s = strings.ReplaceAll(s, `\@`, "@")
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "`<w:")
+		if start < 0 {
+			b.WriteString(docx.TextRun(s, ""))
+			return b.String()
+		}
+		end := strings.Index(s[start:], "`{=openxml}")
+		if end < 0 {
+			b.WriteString(docx.TextRun(s, ""))
+			return b.String()
+		}
+		b.WriteString(docx.TextRun(s[:start], ""))
+		b.WriteString(s[start+1 : start+end])
+		s = s[start+end+len("`{=openxml}"):]
+	}
+}
+
+// crossrefBookmarkName 把标签转换为合法的 Word 书签名（字母开头，仅含字母数字与下划线，最长 40 字符）。
+// 与 used 中已有的书签重名（如截断后相同，或 a-b 与 a_b）时追加 _2、_3 等序号，并记录到 used。
+func crossrefBookmarkName(key string, used map[string]bool) string {
+	base := strings.NewReplacer(":", "_", "-", "_").Replace(key)
+	name := truncateBookmark(base, crossrefBookmarkMaxLen)
+	for n := 2; used[name]; n++ {
+		suffix := fmt.Sprintf("_%d", n)
+		name = truncateBookmark(base, crossrefBookmarkMaxLen-len(suffix)) + suffix
+	}
+	used[name] = true
+	return name
+}
+
+func truncateBookmark(name string, n int) string {
+	if len(name) > n {
+		return name[:n]
+	}
+	return name
+}
+
+// nextHeadingNumber 按标题层级递增计数并返回“2.1”形式的编号，下级计数随之清零。
+func nextHeadingNumber(counts *[6]int, level int) string {
+	counts[level-1]++
+	for i := level; i < len(counts); i++ {
+		counts[i] = 0
+	}
+	parts := make([]string, level)
+	for i := range parts {
+		parts[i] = fmt.Sprint(counts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// plainHeadingText 去掉标题中常见的行内标记，作为引用处显示的文字。
+func plainHeadingText(s string) string {
+	s = strings.NewReplacer("**", "", "__", "", "`", "").Replace(s)
+	return strings.TrimSpace(strings.Trim(s, "*_"))
+}
+
+// codeLines 标记围栏代码块（含围栏行）所在的行。
+func codeLines(lines []string) []bool {
+	code := make([]bool, len(lines))
+	inFence := false
+	fenceChar := byte(0)
+	fenceLen := 0
+	for i, line := range lines {
+		if ch, n, ok := fenceMarker(strings.TrimSpace(line)); ok {
+			if !inFence {
+				inFence, fenceChar, fenceLen = true, ch, n
+				code[i] = true
+				continue
+			}
+			if ch == fenceChar && n >= fenceLen {
+				inFence = false
+				code[i] = true
+				continue
+			}
+		}
+		code[i] = inFence
+	}
+	return code
+}
@@ -0,0 +1,118 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func resolveCrossrefBody(t *testing.T, body string) (string, []job.Diagnostic) {
+	t.Helper()
+	inc, err := expandIncludes("/abs/a.md", body, 1, "", "")
+	require.NoError(t, err)
+	out, diags := resolveCrossrefs(job.Task{SourcePath: "/abs/a.md"}, inc)
+	require.Len(t, out.lines, strings.Count(out.body, "\n")+1)
+	return out.body, diags
+}
+
+func TestResolveCrossrefsNumbersCaptionsAndReferences(t *testing.T) {
+	body := "# 概述 {#sec:intro}\n" +
+		"如 @fig:arch 与 @tbl:perf 所示，详见 @sec:intro。\n" +
+		"![系统架构](img/arch.png){#fig:arch}\n" +
+		"![](img/flow.png){#fig:flow}\n" +
+		"Table: 性能对比 {#tbl:perf}\n" +
+		"| a | b |\n|---|---|\n| 1 | 2 |\n" +
+		"`@fig:arch` 与 a@fig:arch 不是引用"
+	out, diags := resolveCrossrefBody(t, body)
+	require.Empty(t, diags)
+
+	require.Contains(t, out, "# `<w:bookmarkStart w:id=\"100001\" w:name=\"sec_intro\"/>`{=openxml}概述`<w:bookmarkEnd w:id=\"100001\"/>`{=openxml}\n")
+	require.Contains(t, out, "如 `"+`<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText xml:space="preserve"> REF fig_arch \h </w:instrText></w:r>`)
+	require.Contains(t, out, `<w:t xml:space="preserve">图 1</w:t>`)
+	require.Contains(t, out, `<w:t xml:space="preserve">表 1</w:t>`)
+	require.Contains(t, out, `REF sec_intro \h`)
+	require.Contains(t, out, `<w:t xml:space="preserve">概述</w:t>`)
+
+	require.Contains(t, out, "![系统架构](img/arch.png)\\ \n```{=openxml}\n<w:p><w:pPr><w:pStyle w:val=\"ImageCaption\"/></w:pPr><w:bookmarkStart w:id=\"100002\" w:name=\"fig_arch\"/>")
+	require.Contains(t, out, `SEQ 图 \* ARABIC`)
+	require.Contains(t, out, `<w:t xml:space="preserve"> 系统架构</w:t>`)
+	require.Contains(t, out, "![](img/flow.png)\\ \n")
+	require.Contains(t, out, `<w:bookmarkStart w:id="100003" w:name="fig_flow"/>`)
+	require.Contains(t, out, `<w:t xml:space="preserve">2</w:t>`)
+	require.Contains(t, out, "<w:pStyle w:val=\"TableCaption\"/>")
+	require.Contains(t, out, " 性能对比</w:t>")
+	require.Contains(t, out, "\n| a | b |\n")
+	require.True(t, strings.HasSuffix(out, "`@fig:arch` 与 a@fig:arch 不是引用"))
+}
+
+func TestResolveCrossrefsReportsUnresolvedAndDuplicates(t *testing.T) {
+	body := "见 @fig:missing。\n" +
+		"```\n@fig:ignored\n```\n" +
+		"![a](a.png){#fig:x}\n" +
+		"![b](b.png){#fig:x}"
+	out, diags := resolveCrossrefBody(t, body)
+	require.True(t, strings.HasPrefix(out, "见 \\@fig:missing。\n```\n@fig:ignored\n```\n"))
+	require.Equal(t, []job.Diagnostic{
+		{Source: "/abs/a.md", Line: 1, Severity: job.SeverityWarn, Code: DiagnosticUnresolvedReference, Message: "交叉引用未找到对应标签：@fig:missing"},
+		{Source: "/abs/a.md", Line: 6, Severity: job.SeverityWarn, Code: DiagnosticDuplicateLabel, Message: "交叉引用标签重复：#fig:x（首次定义于第 5 行）"},
+	}, diags)
+	// 重复的标签仍编号，但不再生成书签。
+	require.Equal(t, 1, strings.Count(out, `w:name="fig_x"`))
+	require.Contains(t, out, `<w:t xml:space="preserve">2</w:t>`)
+}
+
+func TestResolveCrossrefsNumberedSectionReference(t *testing.T) {
+	body := "# 概述\n" +
+		"# 设计\n" +
+		"## 架构 {#sec:arch}\n" +
+		"```\n# 代码中的注释\n```\n" +
+		"### 模块 {#sec:mod}\n" +
+		"见第 @secnum:arch 节「@sec:arch」与 @secnum:mod，@secnum:missing 不存在。"
+	out, diags := resolveCrossrefBody(t, body)
+	require.Contains(t, out, `REF sec_arch \w \h`)
+	require.Contains(t, out, `<w:t xml:space="preserve">2.1</w:t>`)
+	require.Contains(t, out, `REF sec_arch \h`)
+	require.Contains(t, out, `<w:t xml:space="preserve">架构</w:t>`)
+	require.Contains(t, out, `REF sec_mod \w \h`)
+	require.Contains(t, out, `<w:t xml:space="preserve">2.1.1</w:t>`)
+	require.Contains(t, out, `\@secnum:missing`)
+	require.Equal(t, []job.Diagnostic{
+		{Source: "/abs/a.md", Line: 8, Severity: job.SeverityWarn, Code: DiagnosticUnresolvedReference, Message: "交叉引用未找到对应标签：@secnum:missing"},
+	}, diags)
+}
+
+func TestCrossrefBookmarkName(t *testing.T) {
+	used := make(map[string]bool)
+	require.Equal(t, "fig_system_arch", crossrefBookmarkName("fig:system-arch", used))
+	require.Equal(t, "fig_system_arch_2", crossrefBookmarkName("fig:system_arch", used))
+
+	long := crossrefBookmarkName("sec:"+strings.Repeat("a", 60)+"-1", used)
+	require.Len(t, long, 40)
+	other := crossrefBookmarkName("sec:"+strings.Repeat("a", 60)+"-2", used)
+	require.Len(t, other, 40)
+	require.NotEqual(t, long, other)
+	require.True(t, strings.HasSuffix(other, "_2"))
+}
+
+func TestResolveCrossrefsLongLabelsGetDistinctBookmarks(t *testing.T) {
+	prefix := strings.Repeat("deployment-overview-", 3)
+	body := "见 @fig:" + prefix + "a 与 @fig:" + prefix + "b。\n" +
+		"![a](a.png){#fig:" + prefix + "a}\n" +
+		"![b](b.png){#fig:" + prefix + "b}"
+	out, diags := resolveCrossrefBody(t, body)
+	require.Empty(t, diags)
+	first := crossrefBookmarkName("fig:"+prefix+"a", map[string]bool{})
+	require.Contains(t, out, "REF "+first+` \h`)
+	require.Contains(t, out, "REF "+first[:38]+`_2 \h`)
+	require.Equal(t, 1, strings.Count(out, `w:name="`+first+`"`))
+}
+
+func TestResolveCrossrefsKeepsFigureAltText(t *testing.T) {
+	body := "# 部署 {#sec:deploy}\n" +
+		"![架构，详见 @tbl:perf 与第 @secnum:deploy 节，@fig:missing](a.png){#fig:a}\n" +
+		"Table: 性能 {#tbl:perf}"
+	out, _ := resolveCrossrefBody(t, body)
+	require.Contains(t, out, "![架构，详见 表 1 与第 1 节，\\@fig:missing](a.png)\\ \n")
+}
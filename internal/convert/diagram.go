@@ -0,0 +1,327 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const (
+	DiagnosticDiagramRendererMissing = "diagram-renderer-missing"
+	DiagnosticDiagramRenderFailed    = "diagram-render-failed"
+)
+
+// defaultDiagramRenderers 是各图表类型的默认渲染命令。{input}/{output} 为临时输入/输出文件，
+// 命令中不含 {input} 时从 stdin 读源码，不含 {output} 时从 stdout 读取图片；{format} 为 png 或 svg。
+var defaultDiagramRenderers = map[string]string{
+	"mermaid":  "mmdc -i {input} -o {output}",
+	"plantuml": "plantuml -t{format} -pipe",
+}
+
+// diagramLanguages 把代码块语言映射到图表类型，以及写入临时输入文件时使用的扩展名。
+var diagramLanguages = map[string]struct{ kind, ext string }{
+	"mermaid":  {"mermaid", ".mmd"},
+	"plantuml": {"plantuml", ".puml"},
+	"puml":     {"plantuml", ".puml"},
+}
+
+// DiagramOptions 控制 mermaid / plantuml 代码块的渲染。
+type DiagramOptions struct {
+	// Renderers 覆盖默认渲染命令，键为 mermaid 或 plantuml。
+	Renderers map[string]string
+	// Format 为 png（默认）或 svg。
+	Format string
+	// CacheDir 为渲染结果缓存目录；为空时使用用户缓存目录下的 syl-md2doc/diagrams。
+	CacheDir string
+	// ConfiguredOnly 只渲染 Renderers 中显式配置的图表类型，其余按普通代码块输出（HTTP 服务不运行默认渲染器）。
+	ConfiguredOnly bool
+}
+
+func (o DiagramOptions) Validate() error {
+	switch o.Format {
+	case "", "png", "svg":
+	default:
+		return fmt.Errorf("--diagram-format 仅支持 png 或 svg：%s", o.Format)
+	}
+	for kind, command := range o.Renderers {
+		if _, ok := defaultDiagramRenderers[strings.ToLower(kind)]; !ok {
+			return fmt.Errorf("--diagram-renderer 不支持的图表类型：%s（可用：%s）", kind, strings.Join(diagramKinds(), ", "))
+		}
+		if len(splitCommand(command)) == 0 {
+			return fmt.Errorf("--diagram-renderer 的命令不能为空：%s", kind)
+		}
+	}
+	return nil
+}
+
+func diagramKinds() []string {
+	kinds := make([]string, 0, len(defaultDiagramRenderers))
+	for k := range defaultDiagramRenderers {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (o DiagramOptions) format() string {
+	if o.Format == "" {
+		return "png"
+	}
+	return o.Format
+}
+
+// enabled 判断是否渲染该类型的图表。
+func (o DiagramOptions) enabled(kind string) bool {
+	if !o.ConfiguredOnly {
+		return true
+	}
+	for k := range o.Renderers {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o DiagramOptions) renderer(kind string) string {
+	for k, v := range o.Renderers {
+		if strings.EqualFold(k, kind) {
+			return v
+		}
+	}
+	return defaultDiagramRenderers[kind]
+}
+
+func (o DiagramOptions) cacheDir() string {
+	if o.CacheDir != "" {
+		return o.CacheDir
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "syl-md2doc", "diagrams")
+}
+
+// renderDiagrams 把正文中的 mermaid / plantuml 代码块渲染为图片并替换为图片引用；
+// 渲染器不存在或渲染失败时保留代码块，并返回定位到源文件行号的诊断。bodyLine 为正文首行在源文件中的行号。
+func (p *PandocConverter) renderDiagrams(ctx context.Context, task job.Task, body string, bodyLine int) (string, bool, []job.Diagnostic) {
+	lines := strings.Split(body, "\n")
+	diags := make([]job.Diagnostic, 0)
+	var out strings.Builder
+	changed := false
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		ch, n, ok := fenceMarker(trimmed)
+		if !ok {
+			out.WriteString(lines[i])
+			if i < len(lines)-1 {
+				out.WriteString("\n")
+			}
+			continue
+		}
+		end := closingFence(lines, i+1, ch, n)
+		info := strings.Fields(strings.TrimLeft(trimmed, string(ch)))
+		lang, isDiagram := "", false
+		if len(info) > 0 {
+			lang = strings.ToLower(strings.Trim(info[0], "{}."))
+			spec, ok := diagramLanguages[lang]
+			isDiagram = ok && p.Diagrams.enabled(spec.kind)
+		}
+		if !isDiagram || end < 0 {
+			// 普通代码块原样保留，代码块内的内容不参与识别。
+			last := end
+			if last < 0 {
+				last = len(lines) - 1
+			}
+			out.WriteString(strings.Join(lines[i:last+1], "\n"))
+			if last < len(lines)-1 {
+				out.WriteString("\n")
+			}
+			i = last
+			continue
+		}
+
+		source := strings.Join(lines[i+1:end], "\n") + "\n"
+		image, diag := p.renderDiagram(ctx, task, lang, source)
+		if diag != nil {
+			diag.Line = i + bodyLine
+			diags = append(diags, *diag)
+			out.WriteString(strings.Join(lines[i:end+1], "\n"))
+		} else {
+			out.WriteString("![](<" + filepath.ToSlash(image) + ">)")
+			changed = true
+		}
+		if end < len(lines)-1 {
+			out.WriteString("\n")
+		}
+		i = end
+	}
+	return out.String(), changed, diags
+}
+
+// closingFence 返回从 start 开始第一个能闭合 ch×n 围栏的行下标，未闭合时返回 -1。
+func closingFence(lines []string, start int, ch byte, n int) int {
+	for j := start; j < len(lines); j++ {
+		trimmed := strings.TrimSpace(lines[j])
+		if c, m, ok := fenceMarker(trimmed); ok && c == ch && m >= n && strings.Trim(trimmed, string(ch)) == "" {
+			return j
+		}
+	}
+	return -1
+}
+
+// renderDiagram 渲染单个图表并返回缓存中的图片路径；缓存键为图表类型、渲染命令、格式与源码的哈希。
+func (p *PandocConverter) renderDiagram(ctx context.Context, task job.Task, lang, source string) (string, *job.Diagnostic) {
+	spec := diagramLanguages[lang]
+	opts := p.Diagrams
+	format := opts.format()
+	command := opts.renderer(spec.kind)
+	fail := func(code, msg string, args ...any) (string, *job.Diagnostic) {
+		return "", &job.Diagnostic{
+			Source:   task.SourcePath,
+			Severity: job.SeverityWarn,
+			Code:     code,
+			Message:  fmt.Sprintf(msg, args...),
+		}
+	}
+
+	sum := sha256.Sum256([]byte(spec.kind + "\x00" + command + "\x00" + format + "\x00" + source))
+	cached := filepath.Join(opts.cacheDir(), spec.kind+"-"+hex.EncodeToString(sum[:])[:16]+"."+format)
+	if info, err := os.Stat(cached); err == nil && info.Size() > 0 {
+		return cached, nil
+	}
+
+	args := splitCommand(command)
+	if _, err := execLookPath(args[0]); err != nil {
+		return fail(DiagnosticDiagramRendererMissing, "未找到 %s 渲染器（%s），已保留为代码块", spec.kind, args[0])
+	}
+	data, err := runDiagramRenderer(ctx, args, spec.ext, format, source, filepath.Dir(task.SourcePath))
+	if err != nil {
+		return fail(DiagnosticDiagramRenderFailed, "%s 图表渲染失败，已保留为代码块：%v", spec.kind, err)
+	}
+	if err := writeFileAtomic(cached, data); err != nil {
+		return fail(DiagnosticDiagramRenderFailed, "写入图表缓存失败，已保留为代码块：%v", err)
+	}
+	return cached, nil
+}
+
+func runDiagramRenderer(ctx context.Context, args []string, ext, format, source, dir string) ([]byte, error) {
+	tmp, err := os.MkdirTemp("", "syl-md2doc-diagram-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败：%w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+	input := filepath.Join(tmp, "diagram"+ext)
+	output := filepath.Join(tmp, "diagram."+format)
+	if err := os.WriteFile(input, []byte(source), 0o644); err != nil {
+		return nil, fmt.Errorf("写入临时文件失败：%w", err)
+	}
+
+	usesInput, usesOutput := false, false
+	expanded := make([]string, len(args))
+	for i, a := range args {
+		usesInput = usesInput || strings.Contains(a, "{input}")
+		usesOutput = usesOutput || strings.Contains(a, "{output}")
+		a = strings.ReplaceAll(a, "{input}", input)
+		a = strings.ReplaceAll(a, "{output}", output)
+		expanded[i] = strings.ReplaceAll(a, "{format}", format)
+	}
+	cmd := execCommandContext(ctx, expanded[0], expanded[1:]...)
+	cmd.Dir = dir
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if !usesInput {
+		cmd.Stdin = strings.NewReader(source)
+	}
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w：%s", err, firstLine(msg))
+		}
+		return nil, err
+	}
+	data := stdout.Bytes()
+	if usesOutput {
+		data, err = os.ReadFile(output)
+		if err != nil {
+			return nil, fmt.Errorf("渲染器未生成输出文件")
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("渲染器输出为空")
+	}
+	return data, nil
+}
+
+// writeFileAtomic 先写入同目录临时文件再改名，避免并发转换读到写了一半的缓存。
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// splitCommand 按空白拆分命令行，支持单引号与双引号包住含空格的参数。
+func splitCommand(s string) []string {
+	args := make([]string, 0)
+	var cur strings.Builder
+	quote := rune(0)
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
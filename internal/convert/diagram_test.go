@@ -0,0 +1,167 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	require.Equal(t, []string{"java", "-jar", "/opt/plant uml.jar", "-tpng", "-pipe"}, splitCommand(`java -jar "/opt/plant uml.jar" -tpng  -pipe`))
+	require.Equal(t, []string{"mmdc", "-c", "a b", ""}, splitCommand(`mmdc -c 'a b' ""`))
+	require.Empty(t, splitCommand("  "))
+}
+
+func TestDiagramOptionsValidate(t *testing.T) {
+	require.NoError(t, DiagramOptions{}.Validate())
+	require.NoError(t, DiagramOptions{Format: "svg", Renderers: map[string]string{"PlantUML": "java -jar plantuml.jar -pipe"}}.Validate())
+	require.ErrorContains(t, DiagramOptions{Format: "pdf"}.Validate(), "png 或 svg")
+	require.ErrorContains(t, DiagramOptions{Renderers: map[string]string{"graphviz": "dot"}}.Validate(), "mermaid, plantuml")
+	require.ErrorContains(t, DiagramOptions{Renderers: map[string]string{"mermaid": " "}}.Validate(), "不能为空")
+}
+
+// fakeDiagramTools 让 execLookPath 只找到 available 中的命令，渲染器调用改为 shell 脚本，返回调用记录。
+func fakeDiagramTools(t *testing.T, available ...string) *[][]string {
+	t.Helper()
+	calls := make([][]string, 0)
+	origLook, origExec := execLookPath, execCommandContext
+	t.Cleanup(func() {
+		execLookPath = origLook
+		execCommandContext = origExec
+	})
+	execLookPath = func(file string) (string, error) {
+		for _, name := range available {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		calls = append(calls, append([]string{name}, args...))
+		switch name {
+		case "mmdc":
+			// mmdc -i {input} -o {output}
+			return exec.CommandContext(ctx, "sh", "-c", `printf 'IMG:' > "$2"; cat "$1" >> "$2"`, "sh", args[1], args[3])
+		case "plantuml":
+			return exec.CommandContext(ctx, "sh", "-c", `printf 'IMG:'; cat`)
+		case "broken":
+			return exec.CommandContext(ctx, "sh", "-c", `echo 'syntax error at line 2' >&2; exit 1`)
+		}
+		return exec.CommandContext(ctx, "true")
+	}
+	return &calls
+}
+
+func TestRenderDiagramsReplacesBlocksAndCaches(t *testing.T) {
+	calls := fakeDiagramTools(t, "mmdc")
+	cache := t.TempDir()
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Diagrams = DiagramOptions{CacheDir: cache}
+	task := job.Task{SourcePath: filepath.Join(t.TempDir(), "a.md")}
+	body := "前文\n\n```mermaid\ngraph TD\n  A --> B\n```\n\n```go\nfmt.Println()\n```\n"
+
+	out, changed, diags := conv.renderDiagrams(context.Background(), task, body, 1)
+	require.True(t, changed)
+	require.Empty(t, diags)
+	require.Len(t, *calls, 1)
+	require.Contains(t, out, "```go\nfmt.Println()\n```\n")
+	require.NotContains(t, out, "```mermaid")
+
+	start := strings.Index(out, "![](<") + len("![](<")
+	image := out[start : start+strings.Index(out[start:], ">)")]
+	require.True(t, strings.HasPrefix(image, filepath.ToSlash(cache)))
+	require.True(t, strings.HasSuffix(image, ".png"))
+	data, err := os.ReadFile(filepath.FromSlash(image))
+	require.NoError(t, err)
+	require.Equal(t, "IMG:graph TD\n  A --> B\n", string(data))
+
+	again, _, _ := conv.renderDiagrams(context.Background(), task, body, 1)
+	require.Equal(t, out, again)
+	require.Len(t, *calls, 1, "相同内容应命中缓存")
+}
+
+func TestRenderDiagramsUsesStdoutRenderer(t *testing.T) {
+	calls := fakeDiagramTools(t, "plantuml")
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Diagrams = DiagramOptions{CacheDir: t.TempDir(), Format: "svg"}
+	task := job.Task{SourcePath: filepath.Join(t.TempDir(), "a.md")}
+
+	out, changed, diags := conv.renderDiagrams(context.Background(), task, "~~~puml\n@startuml\nA -> B\n@enduml\n~~~", 1)
+	require.True(t, changed)
+	require.Empty(t, diags)
+	require.Equal(t, []string{"plantuml", "-tsvg", "-pipe"}, (*calls)[0])
+	require.True(t, strings.HasSuffix(out, ".svg>)"))
+}
+
+func TestRenderDiagramsFallsBackToCodeBlock(t *testing.T) {
+	fakeDiagramTools(t, "broken")
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Diagrams = DiagramOptions{CacheDir: t.TempDir(), Renderers: map[string]string{"plantuml": "broken"}}
+	src := filepath.Join(t.TempDir(), "a.md")
+	task := job.Task{SourcePath: src}
+	body := "正文\n```mermaid\ngraph TD\n```\n\n```plantuml\nA -> B\n```\n"
+
+	out, changed, diags := conv.renderDiagrams(context.Background(), task, body, 3)
+	require.False(t, changed)
+	require.Equal(t, body, out)
+	require.Len(t, diags, 2)
+	require.Equal(t, job.Diagnostic{
+		Source:   src,
+		Line:     4,
+		Severity: job.SeverityWarn,
+		Code:     DiagnosticDiagramRendererMissing,
+		Message:  "未找到 mermaid 渲染器（mmdc），已保留为代码块",
+	}, diags[0])
+	require.Equal(t, 8, diags[1].Line)
+	require.Equal(t, DiagnosticDiagramRenderFailed, diags[1].Code)
+	require.Contains(t, diags[1].Message, "syntax error at line 2")
+}
+
+func TestRenderDiagramsConfiguredOnlySkipsDefaultRenderers(t *testing.T) {
+	calls := fakeDiagramTools(t, "mmdc", "plantuml")
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Diagrams = DiagramOptions{CacheDir: t.TempDir(), ConfiguredOnly: true, Renderers: map[string]string{"plantuml": "plantuml -tpng -pipe"}}
+	task := job.Task{SourcePath: filepath.Join(t.TempDir(), "a.md")}
+	body := "```mermaid\ngraph TD\n```\n\n```plantuml\nA -> B\n```\n"
+
+	out, changed, diags := conv.renderDiagrams(context.Background(), task, body, 1)
+	require.True(t, changed)
+	require.Empty(t, diags, "未配置的图表类型按普通代码块输出，不报告缺少渲染器")
+	require.Equal(t, [][]string{{"plantuml", "-tpng", "-pipe"}}, *calls)
+	require.Contains(t, out, "```mermaid\ngraph TD\n```")
+	require.NotContains(t, out, "```plantuml")
+}
+
+func TestConvertSubstitutesRenderedDiagram(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# 流程\n\n```mermaid\ngraph TD\n```\n"), 0o644))
+	fakeDiagramTools(t, "mmdc")
+	var prepared string
+	orig := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if name == "pandoc" {
+			data, err := os.ReadFile(args[0])
+			require.NoError(t, err)
+			prepared = string(data)
+			return exec.CommandContext(ctx, "true")
+		}
+		return orig(ctx, name, args...)
+	}
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Diagrams = DiagramOptions{CacheDir: filepath.Join(dir, "cache")}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Empty(t, res.Diagnostics)
+	require.Contains(t, prepared, "![](<"+filepath.ToSlash(filepath.Join(dir, "cache")))
+	require.NotContains(t, prepared, "```mermaid")
+}
@@ -0,0 +1,119 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/fonts"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+// FontOptions 控制字体嵌入；字体报告在 Verbose 或 Embed 时生成。
+type FontOptions struct {
+	// Embed 把文档引用且能在 Dirs 中找到的字体嵌入 docx。
+	Embed bool
+	// Dirs 为字体目录（绝对路径），查找时优先于系统字体目录；嵌入只使用这些目录中的字体。
+	Dirs []string
+}
+
+func (o FontOptions) Validate() error {
+	if o.Embed && len(o.Dirs) == 0 {
+		return fmt.Errorf("--embed-fonts 需要同时用 --font-dir 指定字体目录")
+	}
+	return nil
+}
+
+// processFonts 列出输出文档引用的字体并查找字体文件，按需嵌入，返回字体报告与告警。
+func (p *PandocConverter) processFonts(task job.Task) ([]job.FontUsage, []string, error) {
+	pkg, err := docx.Open(task.TargetPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	custom := fonts.Scan(p.Fonts.Dirs...)
+	var system *fonts.Index
+	var warnings []string
+	report := make([]job.FontUsage, 0)
+	embedded := false
+	for _, name := range pkg.UsedFonts() {
+		u := job.FontUsage{Name: name}
+		faces := custom.Lookup(name)
+		if len(faces) == 0 {
+			if p.Fonts.Embed {
+				warnings = append(warnings, fmt.Sprintf("字体目录中未找到字体：%s，未嵌入", name))
+			}
+			if system == nil {
+				system = fonts.Scan(fonts.SystemDirs()...)
+			}
+			faces = system.Lookup(name)
+		} else if p.Fonts.Embed {
+			files, warning, err := embeddableFiles(name, faces)
+			if err != nil {
+				return report, warnings, err
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			if len(files) > 0 {
+				if err := pkg.EmbedFont(name, files); err != nil {
+					return report, warnings, err
+				}
+				u.Embedded, embedded = true, true
+			}
+		}
+		if len(faces) > 0 {
+			u.Path = faces[0].Path
+		}
+		report = append(report, u)
+	}
+	if embedded {
+		return report, warnings, pkg.Save()
+	}
+	return report, warnings, nil
+}
+
+// embeddableFiles 为每种字形（常规、粗体、斜体、粗斜体）选出第一个可嵌入的 TrueType 字体。
+func embeddableFiles(name string, faces []fonts.Face) ([]docx.FontFile, string, error) {
+	styles := map[string]bool{}
+	var files []docx.FontFile
+	restricted, cff := false, false
+	for _, f := range faces {
+		style := fontStyle(f)
+		switch {
+		case styles[style]:
+			continue
+		case !f.TrueType:
+			cff = true
+			continue
+		case !f.Embeddable:
+			restricted = true
+			continue
+		}
+		data, err := f.Data()
+		if err != nil {
+			return nil, "", fmt.Errorf("读取字体文件失败（%s）：%w", f.Path, err)
+		}
+		styles[style] = true
+		files = append(files, docx.FontFile{Style: style, Data: data})
+	}
+	switch {
+	case len(files) > 0:
+		return files, "", nil
+	case restricted:
+		return nil, fmt.Sprintf("字体 %s 的许可不允许嵌入，未嵌入", name), nil
+	case cff:
+		return nil, fmt.Sprintf("字体 %s 为 CFF 轮廓的 OpenType 字体，Word 无法嵌入，请改用 TrueType 版本", name), nil
+	}
+	return nil, "", nil
+}
+
+func fontStyle(f fonts.Face) string {
+	switch {
+	case f.Bold && f.Italic:
+		return docx.FontBoldItalic
+	case f.Bold:
+		return docx.FontBold
+	case f.Italic:
+		return docx.FontItalic
+	}
+	return docx.FontRegular
+}
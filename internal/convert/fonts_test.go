@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+// testFontFile 生成只含 name 表（族名 family）的最小 TrueType 字体。
+func testFontFile(family string) []byte {
+	u := utf16.Encode([]rune(family))
+	name := make([]byte, 18)
+	binary.BigEndian.PutUint16(name[2:], 1)
+	binary.BigEndian.PutUint16(name[4:], 18)
+	binary.BigEndian.PutUint16(name[6:], 3)
+	binary.BigEndian.PutUint16(name[12:], 1)
+	binary.BigEndian.PutUint16(name[14:], uint16(2*len(u)))
+	for _, c := range u {
+		name = binary.BigEndian.AppendUint16(name, c)
+	}
+	font := make([]byte, 28)
+	binary.BigEndian.PutUint32(font, 0x00010000)
+	binary.BigEndian.PutUint16(font[4:], 1)
+	copy(font[12:], "name")
+	binary.BigEndian.PutUint32(font[20:], 28)
+	binary.BigEndian.PutUint32(font[24:], uint32(len(name)))
+	return append(font, name...)
+}
+
+func TestConvertEmbedsFontsAndReportsMissing(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	tmp := t.TempDir()
+	fontDir := filepath.Join(tmp, "fonts")
+	require.NoError(t, os.MkdirAll(fontDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(fontDir, "dengxian.ttf"), testFontFile("等线"), 0o644))
+	src := filepath.Join(tmp, "a.md")
+	dst := filepath.Join(tmp, "a.docx")
+	require.NoError(t, os.WriteFile(src, []byte("# a\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Fonts = FontOptions{Embed: true, Dirs: []string{fontDir}}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.NoError(t, res.Error)
+
+	require.Contains(t, res.Fonts, job.FontUsage{Name: "等线", Path: filepath.Join(fontDir, "dengxian.ttf"), Embedded: true})
+	require.Contains(t, res.Warnings, "字体目录中未找到字体：等线 Light，未嵌入")
+	require.Contains(t, readDocxPart(t, dst, docx.PartFontTable), `<w:font w:name="等线"><w:embedRegular r:id="rId1"`)
+	require.Contains(t, readDocxPart(t, dst, docx.PartSettings), "<w:embedTrueTypeFonts/>")
+}
+
+func TestFontOptionsValidate(t *testing.T) {
+	require.NoError(t, FontOptions{}.Validate())
+	require.NoError(t, FontOptions{Dirs: []string{"/fonts"}}.Validate())
+	require.ErrorContains(t, FontOptions{Embed: true}.Validate(), "--font-dir")
+}
@@ -0,0 +1,228 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/frontmatter"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+var nowFunc = time.Now
+
+// HeaderFooterOptions 是页眉页脚模板，空模板表示该位置不设置。
+// 模板中 {name} 引用变量，{page}/{pages}/{section_pages} 生成页码域，| 分隔左/中/右三段，{{ 与 }} 输出花括号，\| 输出竖线。
+type HeaderFooterOptions struct {
+	Header      string
+	Footer      string
+	FirstHeader string
+	FirstFooter string
+	EvenHeader  string
+	EvenFooter  string
+	// DifferentFirstPage 为 true 时首页只使用 First* 模板（为空则首页留白）；
+	// 否则仅在指定了 First* 模板时启用首页不同，未指定的一侧沿用默认模板。
+	DifferentFirstPage bool
+}
+
+func (o HeaderFooterOptions) empty() bool {
+	return o == HeaderFooterOptions{}
+}
+
+var pageFields = map[string]string{
+	"page":          "PAGE",
+	"pages":         "NUMPAGES",
+	"section_pages": "SECTIONPAGES",
+}
+
+// headerFooters 渲染模板，返回待写入的页眉页脚与告警（未定义的变量）。
+func (p *PandocConverter) headerFooters(src preparedSource) ([]docx.HeaderFooter, []string) {
+	o := p.HeaderFooter
+	if o.empty() {
+		return nil, nil
+	}
+	type slot struct {
+		footer bool
+		typ    string
+		tpl    string
+		always bool
+	}
+	slots := []slot{
+		{false, docx.HeaderFooterDefault, o.Header, false},
+		{true, docx.HeaderFooterDefault, o.Footer, false},
+	}
+	if o.DifferentFirstPage || o.FirstHeader != "" || o.FirstFooter != "" {
+		header, footer := o.FirstHeader, o.FirstFooter
+		if !o.DifferentFirstPage {
+			header = firstNonEmpty(header, o.Header)
+			footer = firstNonEmpty(footer, o.Footer)
+		}
+		slots = append(slots, slot{false, docx.HeaderFooterFirst, header, true}, slot{true, docx.HeaderFooterFirst, footer, true})
+	}
+	if o.EvenHeader != "" || o.EvenFooter != "" {
+		slots = append(slots,
+			slot{false, docx.HeaderFooterEven, firstNonEmpty(o.EvenHeader, o.Header), true},
+			slot{true, docx.HeaderFooterEven, firstNonEmpty(o.EvenFooter, o.Footer), true})
+	}
+
+	skip := 0
+	if src.cover {
+		skip = 1
+	}
+	out := make([]docx.HeaderFooter, 0, len(slots))
+	warnings := make([]string, 0)
+	seen := map[string]bool{}
+	for _, s := range slots {
+		if s.tpl == "" && !s.always {
+			continue
+		}
+		segments, undefined := renderHeaderFooterTemplate(s.tpl, src.vars.lookup)
+		warnings = appendUndefined(warnings, seen, "页眉页脚", undefined)
+		out = append(out, docx.HeaderFooter{Footer: s.footer, Type: s.typ, Segments: segments, SkipSections: skip})
+	}
+	return out, warnings
+}
+
+// appendUndefined 为未定义的模板变量追加告警，同名变量只告警一次。
+func appendUndefined(warnings []string, seen map[string]bool, kind string, names []string) []string {
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			warnings = append(warnings, fmt.Sprintf("%s模板变量未定义：{%s}", kind, name))
+		}
+	}
+	return warnings
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// templateVars 按优先级解析模板变量：--var > 文档属性 > front matter > 内置变量（file、filename、date、year、git_rev）。
+type templateVars struct {
+	ctx   context.Context
+	task  job.Task
+	meta  map[string]any
+	cli   map[string]string
+	props map[string]string
+	now   time.Time
+	git   *string
+}
+
+func newTemplateVars(ctx context.Context, task job.Task, meta map[string]any, props, cli map[string]string) *templateVars {
+	return &templateVars{ctx: ctx, task: task, meta: meta, cli: normalizeKeys(cli), props: normalizeKeys(props), now: nowFunc()}
+}
+
+func normalizeKeys(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[docx.NormalizePropertyKey(k)] = v
+	}
+	return out
+}
+
+func (v *templateVars) lookup(name string) (string, bool) {
+	if val, ok := v.cli[docx.NormalizePropertyKey(name)]; ok {
+		return val, true
+	}
+	if val, ok := v.props[docx.NormalizePropertyKey(name)]; ok {
+		return val, true
+	}
+	if raw, ok := frontmatter.Lookup(v.meta, name); ok {
+		if s, ok := frontmatter.String(raw, ", "); ok {
+			return s, true
+		}
+	}
+	switch strings.ToLower(name) {
+	case "file":
+		base := filepath.Base(v.task.SourcePath)
+		return strings.TrimSuffix(base, filepath.Ext(base)), true
+	case "filename":
+		return filepath.Base(v.task.SourcePath), true
+	case "date":
+		return v.now.Format("2006-01-02"), true
+	case "year":
+		return v.now.Format("2006"), true
+	case "git_rev":
+		if v.git == nil {
+			rev := gitRevision(v.ctx, filepath.Dir(v.task.SourcePath))
+			v.git = &rev
+		}
+		return *v.git, *v.git != ""
+	}
+	return "", false
+}
+
+// gitRevision 返回源文件所在 git 仓库的短提交号；不在仓库中或 git 不可用时返回空串。
+func gitRevision(ctx context.Context, dir string) string {
+	out, err := execCommandContext(ctx, "git", "-C", dir, "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// renderHeaderFooterTemplate 把模板解析为分段的 run 列表，并返回未定义的变量名。
+func renderHeaderFooterTemplate(tpl string, lookup func(string) (string, bool)) ([][]docx.Run, []string) {
+	segments := [][]docx.Run{nil}
+	undefined := make([]string, 0)
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			last := len(segments) - 1
+			segments[last] = append(segments[last], docx.Run{Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(tpl); i++ {
+		c := tpl[i]
+		switch {
+		case c == '\\' && i+1 < len(tpl) && tpl[i+1] == '|':
+			text.WriteByte('|')
+			i++
+		case c == '|':
+			flush()
+			segments = append(segments, nil)
+		case c == '{' && i+1 < len(tpl) && tpl[i+1] == '{':
+			text.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(tpl) && tpl[i+1] == '}':
+			text.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(tpl[i+1:], '}')
+			if end < 0 {
+				text.WriteString(tpl[i:])
+				i = len(tpl)
+				continue
+			}
+			name := strings.TrimSpace(tpl[i+1 : i+1+end])
+			i += end + 1
+			if field, ok := pageFields[strings.ToLower(name)]; ok {
+				flush()
+				last := len(segments) - 1
+				segments[last] = append(segments[last], docx.Run{Field: field, Text: "1"})
+				continue
+			}
+			val, ok := lookup(name)
+			if !ok {
+				undefined = append(undefined, name)
+				continue
+			}
+			text.WriteString(val)
+		default:
+			text.WriteByte(c)
+		}
+	}
+	flush()
+	return segments, undefined
+}
@@ -0,0 +1,130 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+)
+
+// builtinHighlightStyles 是 pandoc 内置的高亮主题。
+var builtinHighlightStyles = []string{"pygments", "tango", "espresso", "zenburn", "kate", "monochrome", "breezedark", "haddock"}
+
+// tokenStyles 是 pandoc 代码高亮使用的字符样式（skylighting 的 token 类型）。
+var tokenStyles = []string{
+	"KeywordTok", "DataTypeTok", "DecValTok", "BaseNTok", "FloatTok", "ConstantTok", "CharTok",
+	"SpecialCharTok", "StringTok", "VerbatimStringTok", "SpecialStringTok", "ImportTok", "CommentTok",
+	"DocumentationTok", "AnnotationTok", "CommentVarTok", "OtherTok", "FunctionTok", "VariableTok",
+	"ControlFlowTok", "OperatorTok", "BuiltInTok", "ExtensionTok", "PreprocessorTok", "AttributeTok",
+	"RegionMarkerTok", "InformationTok", "WarningTok", "AlertTok", "ErrorTok", "NormalTok",
+}
+
+const sourceCodeStyle = "Source Code"
+
+// HighlightOptions 控制代码块的语法高亮。
+type HighlightOptions struct {
+	// Style 为内置主题名或 .theme 主题文件（绝对路径）；为空时使用 pandoc 默认主题 pygments。
+	Style string
+	// Disabled 关闭语法高亮，代码块只使用 Source Code 样式。
+	Disabled bool
+	// LineNumbers 为代码块的每一行加上行号。
+	LineNumbers bool
+}
+
+// IsBuiltinHighlightStyle 判断 name 是否为 pandoc 内置高亮主题（不区分大小写）。
+func IsBuiltinHighlightStyle(name string) bool {
+	for _, s := range builtinHighlightStyles {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuiltinHighlightStyles 返回内置高亮主题名。
+func BuiltinHighlightStyles() []string {
+	return append([]string(nil), builtinHighlightStyles...)
+}
+
+func (o HighlightOptions) Validate() error {
+	if o.Disabled && o.Style != "" {
+		return fmt.Errorf("--no-highlight 与 --highlight-style 不能同时使用")
+	}
+	return nil
+}
+
+func (o HighlightOptions) args() []string {
+	switch {
+	case o.Disabled:
+		return []string{"--no-highlight"}
+	case o.Style == "":
+		return nil
+	case IsBuiltinHighlightStyle(o.Style):
+		return []string{"--highlight-style=" + strings.ToLower(o.Style)}
+	default:
+		return []string{"--highlight-style=" + o.Style}
+	}
+}
+
+// referenceStyleWarnings 缓存各参考模板的代码样式检查结果，批量转换时每个模板只解析一次。
+var referenceStyleWarnings sync.Map
+
+// codeStyleWarnings 检查用户指定的参考模板是否定义了 Source Code 段落样式与高亮字符样式。
+// 缺少时 pandoc 会按高亮主题生成默认样式（关闭高亮时代码块退化为正文样式），可能与模板风格不一致。
+func (p *PandocConverter) codeStyleWarnings() []string {
+	ref := strings.TrimSpace(p.ReferenceDocx)
+	if ref == "" {
+		return nil
+	}
+	key := fmt.Sprintf("%s|%t", ref, p.Highlight.Disabled)
+	if cached, ok := referenceStyleWarnings.Load(key); ok {
+		return cached.([]string)
+	}
+	warnings := make([]string, 0)
+	pkg, err := docx.Open(ref)
+	if err != nil {
+		// 模板无法读取时由 pandoc 报告具体错误。
+		return warnings
+	}
+	if len(pkg.MissingStyles(sourceCodeStyle)) > 0 {
+		fallback := "pandoc 将按高亮主题生成默认样式"
+		if p.Highlight.Disabled {
+			fallback = "代码块将使用正文样式"
+		}
+		warnings = append(warnings, fmt.Sprintf("参考模板缺少代码块段落样式 %q，%s", sourceCodeStyle, fallback))
+	}
+	if !p.Highlight.Disabled {
+		missing := pkg.MissingStyles(tokenStyles...)
+		switch {
+		case len(missing) == len(tokenStyles):
+			warnings = append(warnings, "参考模板缺少代码高亮字符样式（KeywordTok、StringTok 等），pandoc 将按高亮主题生成默认样式")
+		case len(missing) > 0:
+			sort.Strings(missing)
+			warnings = append(warnings, fmt.Sprintf("参考模板缺少部分代码高亮字符样式：%s", strings.Join(missing, "、")))
+		}
+	}
+	referenceStyleWarnings.Store(key, warnings)
+	return warnings
+}
+
+// numberCodeLines 为代码块加行号；代码块段落样式按参考模板中的 Source Code 样式查找。
+func numberCodeLines(pkg *docx.Package) error {
+	styleID := pkg.StyleID(sourceCodeStyle)
+	if styleID == "" {
+		styleID = "SourceCode"
+	}
+	_, err := pkg.NumberCodeLines(styleID, pkg.StyleID("line number"))
+	return err
+}
+
+// hasFencedCode 判断正文中是否有围栏代码块。
+func hasFencedCode(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if _, _, ok := fenceMarker(strings.TrimSpace(line)); ok {
+			return true
+		}
+	}
+	return false
+}
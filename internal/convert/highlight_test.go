@@ -0,0 +1,74 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlightOptionsArgs(t *testing.T) {
+	require.Nil(t, HighlightOptions{}.args())
+	require.Equal(t, []string{"--highlight-style=zenburn"}, HighlightOptions{Style: "Zenburn"}.args())
+	require.Equal(t, []string{"--highlight-style=/abs/corp.theme"}, HighlightOptions{Style: "/abs/corp.theme"}.args())
+	require.Equal(t, []string{"--no-highlight"}, HighlightOptions{Disabled: true, LineNumbers: true}.args())
+	require.Error(t, HighlightOptions{Style: "tango", Disabled: true}.Validate())
+	require.NoError(t, HighlightOptions{Style: "tango"}.Validate())
+}
+
+func TestConvertWarnsWhenReferenceLacksCodeStyles(t *testing.T) {
+	dir := t.TempDir()
+	ref := filepath.Join(dir, "corp.docx")
+	require.NoError(t, os.WriteFile(ref, defaultReferenceDocx, 0o644))
+	code := filepath.Join(dir, "code.md")
+	require.NoError(t, os.WriteFile(code, []byte("```go\nfunc main() {}\n```\n"), 0o644))
+	plain := filepath.Join(dir, "plain.md")
+	require.NoError(t, os.WriteFile(plain, []byte("# 标题\n"), 0o644))
+
+	var got []string
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		got = args
+		return exec.CommandContext(ctx, "true")
+	}
+
+	conv := NewPandocConverter("pandoc", ref, false)
+	conv.Highlight = HighlightOptions{Style: "kate"}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: code, TargetPath: filepath.Join(dir, "code.docx")})
+	require.NoError(t, res.Error)
+	require.Contains(t, got, "--highlight-style=kate")
+	require.Equal(t, []string{
+		`参考模板缺少代码块段落样式 "Source Code"，pandoc 将按高亮主题生成默认样式`,
+		"参考模板缺少代码高亮字符样式（KeywordTok、StringTok 等），pandoc 将按高亮主题生成默认样式",
+	}, res.Warnings)
+
+	res = conv.Convert(context.Background(), job.Task{SourcePath: plain, TargetPath: filepath.Join(dir, "plain.docx")})
+	require.NoError(t, res.Error)
+	require.Empty(t, res.Warnings, "没有代码块的文件不告警")
+
+	conv.Highlight = HighlightOptions{Disabled: true}
+	res = conv.Convert(context.Background(), job.Task{SourcePath: code, TargetPath: filepath.Join(dir, "code.docx")})
+	require.NoError(t, res.Error)
+	require.Contains(t, got, "--no-highlight")
+	require.Equal(t, []string{`参考模板缺少代码块段落样式 "Source Code"，代码块将使用正文样式`}, res.Warnings)
+}
+
+func TestConvertWithoutReferenceSkipsCodeStyleCheck(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("```\nx\n```\n"), 0o644))
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "true")
+	}
+
+	res := NewPandocConverter("pandoc", "", false).Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Empty(t, res.Warnings)
+}
@@ -0,0 +1,182 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/frontmatter"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+// maxIncludeDepth 是包含指令允许的最大嵌套层数。
+const maxIncludeDepth = 8
+
+// includeDirectiveRe 匹配独占一行的包含指令：{{< include path.md >}} 或 !include path.md。
+var includeDirectiveRe = regexp.MustCompile(`^ {0,3}(?:\{\{<\s*include\s+(.+?)\s*>\}\}|!include\s+(.+?))\s*$`)
+
+// relativeImageRe 匹配行内图片的目标地址，用于把被包含片段中的相对图片路径改写为绝对路径。
+var relativeImageRe = regexp.MustCompile(`(!\[[^\]]*\]\()(<[^>]+>|[^)\s]+)`)
+
+// sourceLine 是展开后正文中一行对应的源文件与行号。
+type sourceLine struct {
+	path string
+	line int
+}
+
+// includedSource 是展开包含指令后的正文。
+type includedSource struct {
+	body string
+	// lines 与 body 的每一行一一对应，记录其来源。
+	lines []sourceLine
+	// files 是被包含的文件（绝对路径，按首次出现顺序去重）。
+	files []string
+}
+
+// expandIncludes 展开正文中的包含指令；路径相对包含它的文件所在目录，代码块中的指令不处理。
+// base 为主文档中包含路径的解析目录，为空时为主文档所在目录（从流转换时主文档位于临时目录，需另行指定）。
+// 被包含文件必须位于 limit 目录之内（为空时为 base），不接受绝对路径。
+// 被包含文件的 front matter 会被忽略。bodyLine 为正文首行在源文件中的行号。
+func expandIncludes(path, body string, bodyLine int, base, limit string) (includedSource, error) {
+	if base == "" {
+		base = filepath.Dir(path)
+	}
+	if limit == "" {
+		limit = base
+	}
+	e := &includeExpander{root: base, main: path, limit: limit, seen: make(map[string]bool)}
+	out := make([]string, 0)
+	if err := e.expand(path, body, bodyLine, []string{path}, &out); err != nil {
+		return includedSource{}, err
+	}
+	return includedSource{body: strings.Join(out, "\n"), lines: e.lines, files: e.files}, nil
+}
+
+type includeExpander struct {
+	root string
+	// main 为主文档路径，其中的包含路径相对 root 解析。
+	main string
+	// limit 是允许包含的目录，防止通过 ../ 或符号链接读取文档目录之外的文件（如服务端的系统文件）。
+	limit string
+	seen  map[string]bool
+	files []string
+	lines []sourceLine
+}
+
+func (e *includeExpander) expand(path, body string, bodyLine int, stack []string, out *[]string) error {
+	inFence := false
+	fenceChar := byte(0)
+	fenceLen := 0
+	for i, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		lineNo := bodyLine + i
+		if ch, n, ok := fenceMarker(strings.TrimSpace(line)); ok {
+			if !inFence {
+				inFence, fenceChar, fenceLen = true, ch, n
+			} else if ch == fenceChar && n >= fenceLen {
+				inFence = false
+			}
+		}
+		m := includeDirectiveRe.FindStringSubmatch(line)
+		if inFence || m == nil {
+			*out = append(*out, line)
+			e.lines = append(e.lines, sourceLine{path: path, line: lineNo})
+			continue
+		}
+
+		target := strings.Trim(m[1]+m[2], `"'`)
+		where := fmt.Sprintf("%s 第 %d 行", e.display(path), lineNo)
+		if filepath.IsAbs(target) || strings.HasPrefix(target, "/") || strings.HasPrefix(target, `\`) {
+			return fmt.Errorf("%s：包含路径必须是相对路径：%s", where, target)
+		}
+		dir := filepath.Dir(path)
+		if path == e.main {
+			dir = e.root
+		}
+		target = filepath.Clean(filepath.Join(dir, target))
+		if !insideDir(e.limit, target) {
+			return fmt.Errorf("%s：包含文件超出允许的目录 %s：%s", where, e.limit, e.display(target))
+		}
+		for _, p := range stack {
+			if p == target {
+				chain := make([]string, 0, len(stack)+1)
+				for _, s := range append(stack, target) {
+					chain = append(chain, e.display(s))
+				}
+				return fmt.Errorf("%s：检测到循环包含：%s", where, strings.Join(chain, " → "))
+			}
+		}
+		// stack 以主文档开头，target 的嵌套层数即 len(stack)：主文档直接包含的片段为第 1 层。
+		if depth := len(stack); depth > maxIncludeDepth {
+			return fmt.Errorf("%s：包含层级超过 %d 层：%s", where, maxIncludeDepth, e.display(target))
+		}
+		content, err := os.ReadFile(target)
+		if err != nil {
+			return fmt.Errorf("%s：读取包含文件失败：%w", where, err)
+		}
+		if !e.seen[target] {
+			e.seen[target] = true
+			e.files = append(e.files, target)
+		}
+		doc := frontmatter.Split(string(content))
+		included := absolutizeImages(strings.TrimSuffix(strings.ReplaceAll(doc.Body, "\r\n", "\n"), "\n"), filepath.Dir(target))
+		if err := e.expand(target, included, doc.BodyLine, append(stack[:len(stack):len(stack)], target), out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insideDir 判断 path 是否位于 dir 之内；已存在的文件按符号链接解析后的实际路径判断。
+func insideDir(dir, path string) bool {
+	if !within(dir, path) {
+		return false
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		// 文件不存在等错误留给读取时报告。
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		resolved = dir
+	}
+	return within(resolved, real)
+}
+
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// display 返回相对主文档包含解析目录的路径，用于错误信息。
+func (e *includeExpander) display(path string) string {
+	if rel, err := filepath.Rel(e.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// absolutizeImages 把片段中相对片段目录的图片路径改写为绝对路径，使其在被其他目录的文档包含时仍能找到。
+func absolutizeImages(body, dir string) string {
+	return relativeImageRe.ReplaceAllStringFunc(body, func(s string) string {
+		m := relativeImageRe.FindStringSubmatch(s)
+		target := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+		if target == "" || filepath.IsAbs(target) || strings.Contains(target, "://") || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "data:") {
+			return s
+		}
+		return m[1] + "<" + filepath.ToSlash(filepath.Join(dir, target)) + ">"
+	})
+}
+
+// locate 把基于展开后正文行号（从 1 开始）的诊断映射回实际的源文件与行号。
+func (s includedSource) locate(diags []job.Diagnostic) []job.Diagnostic {
+	for i := range diags {
+		if n := diags[i].Line - 1; n >= 0 && n < len(s.lines) {
+			diags[i].Source = s.lines[n].path
+			diags[i].Line = s.lines[n].line
+		}
+	}
+	return diags
+}
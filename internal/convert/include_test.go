@@ -0,0 +1,157 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestExpandIncludesNestedFragments(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"shared/legal.md":    "---\ntitle: 片段\n---\n## 法律声明\n!include \"glossary.md\"\n![logo](img/logo.png)\n",
+		"shared/glossary.md": "术语表\n",
+	})
+	main := filepath.Join(dir, "a.md")
+	body := "# 标题\n{{< include shared/legal.md >}}\n```\n!include shared/legal.md\n```\n{{< include shared/glossary.md >}}\n"
+
+	inc, err := expandIncludes(main, body, 3, "", "")
+	require.NoError(t, err)
+	logo := filepath.ToSlash(filepath.Join(dir, "shared", "img", "logo.png"))
+	require.Equal(t, "# 标题\n## 法律声明\n术语表\n![logo](<"+logo+">)\n```\n!include shared/legal.md\n```\n术语表\n", inc.body)
+	require.Equal(t, []string{filepath.Join(dir, "shared", "legal.md"), filepath.Join(dir, "shared", "glossary.md")}, inc.files)
+	require.Equal(t, sourceLine{path: filepath.Join(dir, "shared", "legal.md"), line: 4}, inc.lines[1])
+	require.Equal(t, sourceLine{path: filepath.Join(dir, "shared", "glossary.md"), line: 1}, inc.lines[2])
+	require.Equal(t, sourceLine{path: main, line: 5}, inc.lines[4])
+	require.Len(t, inc.lines, strings.Count(inc.body, "\n")+1)
+}
+
+func TestExpandIncludesDetectsCycles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.md": "!include b.md\n",
+		"b.md": "正文\n!include a.md\n",
+	})
+	_, err := expandIncludes(filepath.Join(dir, "a.md"), "!include b.md\n", 1, "", "")
+	require.EqualError(t, err, "b.md 第 2 行：检测到循环包含：a.md → b.md → a.md")
+}
+
+func TestExpandIncludesLimitsDepth(t *testing.T) {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i <= maxIncludeDepth+1; i++ {
+		files[filepath.Join("d", string(rune('a'+i))+".md")] = "!include " + string(rune('a'+i+1)) + ".md\n"
+	}
+	writeFiles(t, dir, files)
+	_, err := expandIncludes(filepath.Join(dir, "main.md"), "!include d/a.md", 1, "", "")
+	require.ErrorContains(t, err, "包含层级超过 8 层")
+}
+
+func TestExpandIncludesDepthBoundary(t *testing.T) {
+	chain := func(levels int) string {
+		dir := t.TempDir()
+		files := make(map[string]string)
+		for i := 1; i <= levels; i++ {
+			body := "第 " + strconv.Itoa(i) + " 层\n"
+			if i < levels {
+				body += "!include l" + strconv.Itoa(i+1) + ".md\n"
+			}
+			files["l"+strconv.Itoa(i)+".md"] = body
+		}
+		writeFiles(t, dir, files)
+		return dir
+	}
+
+	dir := chain(maxIncludeDepth)
+	inc, err := expandIncludes(filepath.Join(dir, "main.md"), "!include l1.md", 1, "", "")
+	require.NoError(t, err)
+	require.Len(t, inc.files, maxIncludeDepth)
+	require.Contains(t, inc.body, "第 8 层")
+
+	dir = chain(maxIncludeDepth + 1)
+	_, err = expandIncludes(filepath.Join(dir, "main.md"), "!include l1.md", 1, "", "")
+	require.ErrorContains(t, err, "l8.md 第 2 行：包含层级超过 8 层：l9.md")
+}
+
+func TestExpandIncludesReportsMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	_, err := expandIncludes(filepath.Join(dir, "a.md"), "正文\n!include missing.md", 5, "", "")
+	require.ErrorContains(t, err, "a.md 第 6 行：读取包含文件失败")
+}
+
+func TestExpandIncludesRejectsPathsOutsideLimit(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docs/a.md":        "!include ../shared/legal.md\n",
+		"shared/legal.md":  "法律声明\n",
+		"secret/token.txt": "s3cret\n",
+	})
+	main := filepath.Join(dir, "docs", "a.md")
+
+	_, err := expandIncludes(main, "!include /etc/hostname\n", 1, "", "")
+	require.ErrorContains(t, err, "a.md 第 1 行：包含路径必须是相对路径：/etc/hostname")
+
+	_, err = expandIncludes(main, "!include ../shared/legal.md\n", 1, "", "")
+	require.ErrorContains(t, err, "包含文件超出允许的目录")
+
+	inc, err := expandIncludes(main, "!include ../shared/legal.md\n", 1, "", dir)
+	require.NoError(t, err)
+	require.Equal(t, "法律声明\n", inc.body)
+
+	_, err = expandIncludes(main, "!include ../../etc/hostname\n", 1, "", dir)
+	require.ErrorContains(t, err, "包含文件超出允许的目录")
+}
+
+func TestExpandIncludesRejectsSymlinkEscape(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	writeFiles(t, outside, map[string]string{"token.txt": "s3cret\n"})
+	require.NoError(t, os.Symlink(filepath.Join(outside, "token.txt"), filepath.Join(dir, "link.md")))
+
+	_, err := expandIncludes(filepath.Join(dir, "a.md"), "!include link.md\n", 1, "", "")
+	require.ErrorContains(t, err, "包含文件超出允许的目录")
+}
+
+func TestConvertRecordsIncludesAndLocatesDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.md":    "---\nnote: t\n---\n# 标题\n!include part.md\n",
+		"part.md": "片段\n公式 $\\frac{1}{2$\n",
+	})
+	var prepared string
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		data, err := os.ReadFile(args[0])
+		require.NoError(t, err)
+		prepared = string(data)
+		return exec.CommandContext(ctx, "true")
+	}
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Math = true
+	res := conv.Convert(context.Background(), job.Task{SourcePath: filepath.Join(dir, "a.md"), TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Equal(t, []string{filepath.Join(dir, "part.md")}, res.Includes)
+	require.Contains(t, prepared, "片段")
+	require.NotContains(t, prepared, "!include")
+	require.Len(t, res.Diagnostics, 1)
+	require.Equal(t, filepath.Join(dir, "part.md"), res.Diagnostics[0].Source)
+	require.Equal(t, 2, res.Diagnostics[0].Line)
+}
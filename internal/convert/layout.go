@@ -0,0 +1,157 @@
+package convert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+)
+
+var (
+	// landscapeDivRe 匹配横向节的开始行：::: landscape 或 ::: {.landscape}。
+	landscapeDivRe = regexp.MustCompile(`^ {0,3}:{3,}\s*(?:landscape|\{\s*\.landscape\s*\})\s*:*\s*$`)
+	// closingDivRe 匹配 ::: 围栏块的结束行。
+	closingDivRe = regexp.MustCompile(`^ {0,3}:{3,}\s*$`)
+	marginRe     = regexp.MustCompile(`^(\d+(?:\.\d+)?)(mm|cm|in|pt)?$`)
+)
+
+// marginUnits 是页边距单位对应的 twip 数。
+var marginUnits = map[string]float64{"mm": 1440 / 25.4, "cm": 1440 / 2.54, "in": 1440, "pt": 20}
+
+// PageOptions 配置页面设置，应用到文档的每一节；空值保持参考模板的设置。
+// Margins 为 1、2 或 4 个以逗号或空格分隔的长度（同 CSS 顺序：上 右 下 左），单位 mm/cm/in/pt，省略单位按 mm。
+type PageOptions struct {
+	Paper       string
+	Margins     string
+	Orientation string
+}
+
+func (o PageOptions) Validate() error {
+	_, err := o.setup()
+	return err
+}
+
+func (o PageOptions) isZero() bool {
+	return o == PageOptions{}
+}
+
+func (o PageOptions) setup() (docx.PageSetup, error) {
+	var s docx.PageSetup
+	if paper := strings.TrimSpace(o.Paper); paper != "" {
+		w, h, ok := docx.PaperSize(paper)
+		if !ok {
+			return s, fmt.Errorf("--paper 不支持的纸张：%s（可用：%s）", paper, strings.Join(docx.PaperNames(), ", "))
+		}
+		s.Width, s.Height = w, h
+	}
+	if strings.TrimSpace(o.Margins) != "" {
+		m, err := parseMargins(o.Margins)
+		if err != nil {
+			return s, err
+		}
+		s.Margins = &m
+	}
+	switch orientation := strings.ToLower(strings.TrimSpace(o.Orientation)); orientation {
+	case "":
+	case docx.OrientationPortrait, docx.OrientationLandscape:
+		s.Orientation = orientation
+	default:
+		return s, fmt.Errorf("--orientation 仅支持 %s 或 %s：%s", docx.OrientationPortrait, docx.OrientationLandscape, o.Orientation)
+	}
+	return s, nil
+}
+
+// parseMargins 解析 1、2 或 4 个页边距长度，返回 twip。
+func parseMargins(s string) (docx.PageMargins, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || isSpace(byte(r)) })
+	values := make([]int, 0, len(fields))
+	for _, f := range fields {
+		m := marginRe.FindStringSubmatch(strings.ToLower(f))
+		if m == nil {
+			return docx.PageMargins{}, fmt.Errorf("--margins 长度格式错误：%s（示例：2.5cm、20mm、1in）", f)
+		}
+		n, _ := strconv.ParseFloat(m[1], 64)
+		unit := m[2]
+		if unit == "" {
+			unit = "mm"
+		}
+		values = append(values, int(math.Round(n*marginUnits[unit])))
+	}
+	switch len(values) {
+	case 1:
+		return docx.PageMargins{Top: values[0], Right: values[0], Bottom: values[0], Left: values[0]}, nil
+	case 2:
+		return docx.PageMargins{Top: values[0], Right: values[1], Bottom: values[0], Left: values[1]}, nil
+	case 4:
+		return docx.PageMargins{Top: values[0], Right: values[1], Bottom: values[2], Left: values[3]}, nil
+	}
+	return docx.PageMargins{}, fmt.Errorf("--margins 须为 1、2 或 4 个长度：%s", s)
+}
+
+// landscapeSections 把 ::: landscape 围栏块替换为前后两个 raw openxml 分节符，块内内容单独成为横向节。
+// 块位于正文开头时省略前一个分节符，位于正文末尾时省略后一个并返回 lastLandscape，由后处理把最后一节设为横向。
+// 返回的 sections 表示插入了分节符或需要设置最后一节。
+func landscapeSections(body string) (out string, sections, lastLandscape bool) {
+	lines := strings.Split(body, "\n")
+	code := codeLines(lines)
+	kept := make([]string, 0, len(lines))
+	// open 记录外层各个 ::: 块是否为横向块，用于匹配结束行。
+	var open []bool
+	content := false
+	pendingClose := false
+	keep := func(line string) {
+		if strings.TrimSpace(line) != "" {
+			if pendingClose {
+				kept = append(kept, "```{=openxml}", docx.LandscapeSectionBreakParagraph, "```")
+				pendingClose = false
+			}
+			content = true
+		}
+		kept = append(kept, line)
+	}
+	for i, line := range lines {
+		if code[i] || !fencedDivRe.MatchString(line) {
+			keep(line)
+			continue
+		}
+		if closingDivRe.MatchString(line) {
+			if len(open) > 0 && open[len(open)-1] {
+				// 结束分节符推迟到后面出现内容时再插入，避免末尾多出一个空节。
+				open = open[:len(open)-1]
+				pendingClose = true
+				continue
+			}
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+			keep(line)
+			continue
+		}
+		if landscapeDivRe.MatchString(line) {
+			open = append(open, true)
+			sections = true
+			if pendingClose {
+				// 相邻的两个横向块合并为同一节。
+				pendingClose = false
+				continue
+			}
+			if content {
+				kept = append(kept, "```{=openxml}", docx.SectionBreakParagraph, "```")
+			}
+			continue
+		}
+		open = append(open, false)
+		keep(line)
+	}
+	if !sections {
+		return body, false, false
+	}
+	for _, landscape := range open {
+		// 未闭合的横向块延续到正文末尾。
+		pendingClose = pendingClose || landscape
+	}
+	return strings.Join(kept, "\n"), true, pendingClose
+}
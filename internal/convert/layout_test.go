@@ -0,0 +1,91 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageOptionsValidate(t *testing.T) {
+	require.NoError(t, PageOptions{}.Validate())
+	require.NoError(t, PageOptions{Paper: "letter", Margins: "2.5cm", Orientation: "Landscape"}.Validate())
+	require.ErrorContains(t, PageOptions{Paper: "B4"}.Validate(), "--paper 不支持的纸张：B4")
+	require.ErrorContains(t, PageOptions{Margins: "2.5 厘米"}.Validate(), "--margins 长度格式错误：厘米")
+	require.ErrorContains(t, PageOptions{Margins: "1in 2in 3in"}.Validate(), "--margins 须为 1、2 或 4 个长度")
+	require.ErrorContains(t, PageOptions{Orientation: "wide"}.Validate(), "--orientation 仅支持 portrait 或 landscape：wide")
+}
+
+func TestParseMargins(t *testing.T) {
+	m, err := parseMargins("1in")
+	require.NoError(t, err)
+	require.Equal(t, docx.PageMargins{Top: 1440, Right: 1440, Bottom: 1440, Left: 1440}, m)
+
+	m, err = parseMargins("25mm, 2cm")
+	require.NoError(t, err)
+	require.Equal(t, docx.PageMargins{Top: 1417, Right: 1134, Bottom: 1417, Left: 1134}, m)
+
+	m, err = parseMargins("72pt 20 1in 0.5in")
+	require.NoError(t, err)
+	require.Equal(t, docx.PageMargins{Top: 1440, Right: 1134, Bottom: 1440, Left: 720}, m)
+}
+
+func TestLandscapeSections(t *testing.T) {
+	portraitBreak := "```{=openxml}\n" + docx.SectionBreakParagraph + "\n```\n"
+	landscapeBreak := "```{=openxml}\n" + docx.LandscapeSectionBreakParagraph + "\n```\n"
+
+	out, sections, last := landscapeSections("# 接口\n\n::: landscape\n| a | b |\n\n::: note\n宽表说明\n:::\n:::\n\n后续\n")
+	require.True(t, sections)
+	require.False(t, last)
+	require.Equal(t, "# 接口\n\n"+portraitBreak+"| a | b |\n\n::: note\n宽表说明\n:::\n\n"+landscapeBreak+"后续\n", out)
+
+	// 开头的横向块不产生空的纵向节，末尾的横向块由后处理把最后一节设为横向。
+	out, sections, last = landscapeSections("::: {.landscape}\n宽表\n:::\n")
+	require.True(t, sections)
+	require.True(t, last)
+	require.Equal(t, "宽表\n", out)
+
+	body := "```\n::: landscape\n```\n::: warning\n小心\n:::\n"
+	out, sections, _ = landscapeSections(body)
+	require.False(t, sections)
+	require.Equal(t, body, out)
+}
+
+func TestPrepareSourceInsertsLandscapeSections(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# 概述\n\n::: landscape\n宽表\n:::\n"), 0o644))
+
+	prepared, _, err := (&PandocConverter{}).prepareSource(context.Background(), job.Task{SourcePath: src})
+	require.NoError(t, err)
+	defer os.Remove(prepared.path)
+	require.True(t, prepared.sections)
+	require.True(t, prepared.lastLandscape)
+	require.False(t, prepared.divs, "横向块已转换为分节符，无需 fenced_divs")
+	bs, err := os.ReadFile(prepared.path)
+	require.NoError(t, err)
+	require.Contains(t, string(bs), docx.SectionBreakParagraph)
+	require.NotContains(t, string(bs), ":::")
+}
+
+func TestConvertAppliesPageSetup(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	dst := filepath.Join(tmp, "a.docx")
+	require.NoError(t, os.WriteFile(src, []byte("# a\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Page = PageOptions{Paper: "Letter", Margins: "1in", Orientation: "landscape"}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.NoError(t, res.Error)
+
+	doc := readDocxPart(t, dst, docx.PartDocument)
+	body := doc[strings.Index(doc, "<w:body>"):]
+	require.Contains(t, body, `<w:pgSz w:w="15840" w:h="12240" w:orient="landscape"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="851" w:footer="992" w:gutter="0"/>`)
+}
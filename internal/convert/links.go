@@ -0,0 +1,136 @@
+package convert
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const DiagnosticLinkOutsideInputs = "link-outside-inputs"
+
+var (
+	// inlineLinkRe 匹配行内链接 [文字](目标 "标题")，不含图片。
+	inlineLinkRe = regexp.MustCompile(`(^|[^!\\])(\[(?:[^\]\\]|\\.)*\]\(\s*)(<[^>]*>|[^)\s]+)`)
+	// linkDefinitionRe 匹配引用式链接定义 [id]: 目标。
+	linkDefinitionRe = regexp.MustCompile(`^(\s{0,3}\[[^\]]+\]:\s*)(<[^>]*>|\S+)`)
+	// urlSchemeRe 匹配带协议的地址（http:、mailto: 等）。
+	urlSchemeRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// LinkTargets 返回本批次全部任务的源文件到目标 docx 的映射，赋给各任务的 Targets，
+// 用于把文档间的 .md 链接改写为对应的 docx。
+func LinkTargets(tasks []job.Task) map[string]string {
+	targets := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		targets[filepath.Clean(t.SourcePath)] = t.TargetPath
+	}
+	return targets
+}
+
+// resolveMarkdownLinks 改写正文中指向 .md 文件的相对链接：指向本文件的改为文内书签（#anchor），
+// 指向本批次其他文件的改为对应 docx 相对本文件 docx 的路径（保留 #anchor）。
+// 指向本批次之外的 .md 文件时保留原链接并返回告警；任务未带 Targets 时只处理指向本文件的链接。
+func (p *PandocConverter) resolveMarkdownLinks(task job.Task, src includedSource) (includedSource, []job.Diagnostic) {
+	lines := strings.Split(src.body, "\n")
+	code := codeLines(lines)
+	diags := make([]job.Diagnostic, 0)
+	self := filepath.Clean(task.SourcePath)
+	rewrite := func(i int, target string) (string, bool) {
+		raw := strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+		if raw == "" || strings.HasPrefix(raw, "#") || urlSchemeRe.MatchString(raw) || strings.HasPrefix(raw, "//") {
+			return "", false
+		}
+		file, anchor, _ := strings.Cut(raw, "#")
+		if decoded, err := url.PathUnescape(file); err == nil {
+			file = decoded
+		}
+		if ext := strings.ToLower(filepath.Ext(file)); ext != ".md" && ext != ".markdown" {
+			return "", false
+		}
+		if !filepath.IsAbs(file) {
+			// 包含进来的片段中的链接相对片段自身所在目录。
+			file = filepath.Join(filepath.Dir(src.lines[i].path), file)
+		}
+		file = filepath.Clean(file)
+		if file == self {
+			// 指向本文件且不带锚点的链接没有可跳转的位置，保持原样。
+			return "#" + anchor, anchor != ""
+		}
+		if task.Targets == nil {
+			return "", false
+		}
+		docxPath, ok := task.Targets[file]
+		if !ok {
+			diags = append(diags, job.Diagnostic{
+				Source:   task.SourcePath,
+				Line:     i + 1,
+				Severity: job.SeverityWarn,
+				Code:     DiagnosticLinkOutsideInputs,
+				Message:  fmt.Sprintf("链接的 Markdown 文件不在本次转换的输入中，已保留原链接：%s", raw),
+			})
+			return "", false
+		}
+		rel, err := filepath.Rel(filepath.Dir(task.TargetPath), docxPath)
+		if err != nil {
+			rel = docxPath
+		}
+		out := filepath.ToSlash(rel)
+		if anchor != "" {
+			out += "#" + anchor
+		}
+		if strings.ContainsAny(out, " <>") {
+			out = "<" + out + ">"
+		}
+		return out, true
+	}
+
+	for i, line := range lines {
+		if code[i] || !strings.Contains(line, "](") && !strings.Contains(line, "]:") {
+			continue
+		}
+		if m := linkDefinitionRe.FindStringSubmatchIndex(line); m != nil {
+			if out, ok := rewrite(i, line[m[4]:m[5]]); ok {
+				lines[i] = line[:m[4]] + out + line[m[5]:]
+			}
+			continue
+		}
+		lines[i] = mapOutsideCode(line, func(s string) string {
+			return inlineLinkRe.ReplaceAllStringFunc(s, func(link string) string {
+				m := inlineLinkRe.FindStringSubmatch(link)
+				if out, ok := rewrite(i, m[3]); ok {
+					return m[1] + m[2] + out
+				}
+				return link
+			})
+		})
+	}
+	return includedSource{body: strings.Join(lines, "\n"), lines: src.lines, files: src.files}, src.locate(diags)
+}
+
+// mapOutsideCode 对行内代码之外的文字片段应用 fn，行内代码原样保留。
+func mapOutsideCode(line string, fn func(string) string) string {
+	var b strings.Builder
+	for pos := 0; pos < len(line); {
+		tick := strings.IndexByte(line[pos:], '`')
+		if tick < 0 {
+			b.WriteString(fn(line[pos:]))
+			break
+		}
+		end := pos + tick
+		b.WriteString(fn(line[pos:end]))
+		n := runLength(line[end:], '`')
+		close := strings.Index(line[end+n:], strings.Repeat("`", n))
+		if close < 0 {
+			b.WriteString(line[end:])
+			break
+		}
+		next := end + n + close + n
+		b.WriteString(line[end:next])
+		pos = next
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMarkdownLinksRewritesToPlannedDocx(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"shared/legal.md": "参见 [安装](../install.md#prereqs)\n"})
+	conv := NewPandocConverter("pandoc", "", false)
+	task := job.Task{SourcePath: filepath.Join(dir, "guide.md"), TargetPath: filepath.Join(dir, "out", "guide_Ab12Cd.docx")}
+	task.Targets = LinkTargets([]job.Task{
+		task,
+		{SourcePath: filepath.Join(dir, "install.md"), TargetPath: filepath.Join(dir, "out", "install_Xy12Z9.docx")},
+		{SourcePath: filepath.Join(dir, "api", "ref guide.md"), TargetPath: filepath.Join(dir, "out", "api", "ref guide_Qw34Er.docx")},
+	})
+	body := "见 [安装](install.md#prereqs) 与 [API](api/ref%20guide.md \"接口\")，[本节](guide.md#faq)、[本文](./guide.md)。\n" +
+		"`[代码](install.md)`，![图](install.md)，[外站](https://example.com/a.md)，[文内](#faq)\n" +
+		"```\n[代码块](install.md)\n```\n" +
+		"[def]: <install.md>\n" +
+		"!include shared/legal.md"
+	inc, err := expandIncludes(task.SourcePath, body, 1, "", "")
+	require.NoError(t, err)
+
+	out, diags := conv.resolveMarkdownLinks(task, inc)
+	require.Empty(t, diags)
+	require.Equal(t, "见 [安装](install_Xy12Z9.docx#prereqs) 与 [API](<api/ref guide_Qw34Er.docx> \"接口\")，[本节](#faq)、[本文](./guide.md)。\n"+
+		"`[代码](install.md)`，![图](install.md)，[外站](https://example.com/a.md)，[文内](#faq)\n"+
+		"```\n[代码块](install.md)\n```\n"+
+		"[def]: install_Xy12Z9.docx\n"+
+		"参见 [安装](install_Xy12Z9.docx#prereqs)", out.body)
+	require.Equal(t, inc.lines, out.lines)
+}
+
+func TestResolveMarkdownLinksWarnsOutsideInputs(t *testing.T) {
+	dir := t.TempDir()
+	task := job.Task{SourcePath: filepath.Join(dir, "a.md"), TargetPath: filepath.Join(dir, "a.docx")}
+	body := "第一行\n[旧版](../legacy/old.md) 与 [本节](a.md#x)"
+	inc, err := expandIncludes(task.SourcePath, body, 3, "", "")
+	require.NoError(t, err)
+
+	conv := NewPandocConverter("pandoc", "", false)
+	out, diags := conv.resolveMarkdownLinks(task, inc)
+	require.Empty(t, diags, "未设置任务列表时不检查其他文件")
+	require.True(t, strings.HasSuffix(out.body, "[本节](#x)"))
+
+	task.Targets = LinkTargets([]job.Task{task})
+	out, diags = conv.resolveMarkdownLinks(task, inc)
+	require.Contains(t, out.body, "[旧版](../legacy/old.md)")
+	require.Equal(t, []job.Diagnostic{{
+		Source:   task.SourcePath,
+		Line:     4,
+		Severity: job.SeverityWarn,
+		Code:     DiagnosticLinkOutsideInputs,
+		Message:  "链接的 Markdown 文件不在本次转换的输入中，已保留原链接：../legacy/old.md",
+	}}, diags)
+}
+
+func TestConvertUsesEachTasksOwnLinkTargetsConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	prepared := make([]string, 0)
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		data, err := os.ReadFile(args[0])
+		require.NoError(t, err)
+		mu.Lock()
+		prepared = append(prepared, string(data))
+		mu.Unlock()
+		return exec.CommandContext(ctx, "true")
+	}
+
+	// 多个批次共用同一个转换器（如 serve 的并发请求），每个任务只应使用自己批次的链接映射。
+	conv := NewPandocConverter("pandoc", "", false)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		dir := t.TempDir()
+		batch := fmt.Sprintf("batch%d", i)
+		writeFiles(t, dir, map[string]string{"a.md": batch + " [b](b.md)\n"})
+		a := job.Task{SourcePath: filepath.Join(dir, "a.md"), TargetPath: filepath.Join(dir, "a.docx")}
+		a.Targets = LinkTargets([]job.Task{a, {SourcePath: filepath.Join(dir, "b.md"), TargetPath: filepath.Join(dir, "b_"+batch+".docx")}})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := conv.Convert(context.Background(), a)
+			require.NoError(t, res.Error)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, prepared, 8)
+	for _, body := range prepared {
+		batch, _, _ := strings.Cut(body, " ")
+		require.Contains(t, body, "[b](b_"+batch+".docx)")
+	}
+}
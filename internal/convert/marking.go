@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/frontmatter"
+)
+
+const (
+	defaultWatermarkOpacity = 0.5
+	defaultWatermarkColor   = "#C0C0C0"
+	classificationRunProps  = `<w:b/><w:color w:val="C00000"/>`
+)
+
+var (
+	hexColorRe   = regexp.MustCompile(`^#?[0-9A-Fa-f]{6}$`)
+	namedColorRe = regexp.MustCompile(`^[A-Za-z]+$`)
+)
+
+// WatermarkOptions 配置文字水印；Text 为空时使用 front matter 的 watermark 字段。
+// Opacity 为 0 时取 0.5，Color 为空时取 #C0C0C0；Angle 为逆时针角度，0 表示水平。
+type WatermarkOptions struct {
+	Text    string
+	Opacity float64
+	Angle   float64
+	Color   string
+}
+
+// Validate 检查水印参数，供命令行在转换前报错。
+func (o WatermarkOptions) Validate() error {
+	if o.Opacity < 0 || o.Opacity > 1 {
+		return fmt.Errorf("水印不透明度须在 0 到 1 之间：%g", o.Opacity)
+	}
+	if o.Angle < -360 || o.Angle > 360 {
+		return fmt.Errorf("水印角度须在 -360 到 360 之间：%g", o.Angle)
+	}
+	if o.Color != "" && !hexColorRe.MatchString(o.Color) && !namedColorRe.MatchString(o.Color) {
+		return fmt.Errorf("水印颜色须为 #RRGGBB 或颜色名：%s", o.Color)
+	}
+	return nil
+}
+
+func (o WatermarkOptions) watermark(text string) docx.Watermark {
+	w := docx.Watermark{Text: text, Opacity: o.Opacity, Angle: o.Angle, Color: o.Color}
+	if w.Opacity == 0 {
+		w.Opacity = defaultWatermarkOpacity
+	}
+	if w.Color == "" {
+		w.Color = defaultWatermarkColor
+	}
+	if hexColorRe.MatchString(w.Color) && !strings.HasPrefix(w.Color, "#") {
+		w.Color = "#" + w.Color
+	}
+	return w
+}
+
+// markings 返回本文件的水印与密级文字：命令行参数优先，其次 front matter 的 watermark / classification 字段。
+func (p *PandocConverter) markings(meta map[string]any) (string, string) {
+	watermark := p.Watermark.Text
+	if watermark == "" {
+		watermark, _ = frontmatter.String(meta["watermark"], " ")
+	}
+	classification := p.Classification
+	if classification == "" {
+		classification, _ = frontmatter.String(meta["classification"], " ")
+	}
+	return strings.TrimSpace(watermark), strings.TrimSpace(classification)
+}
+
+func (p *PandocConverter) applyMarkings(pkg *docx.Package, watermark, classification string) error {
+	if classification != "" {
+		if err := pkg.AddMarking(classification, classificationRunProps); err != nil {
+			return err
+		}
+	}
+	if watermark != "" {
+		return pkg.AddWatermark(p.Watermark.watermark(watermark))
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const DiagnosticInvalidMath = "invalid-math"
+
+// mathExtension 让 pandoc 解析 $...$ 与 $$...$$，docx writer 会把公式转为原生 OMML。
+const mathExtension = "+tex_math_dollars"
+
+// mathFormula 是从 Markdown 中识别出的一个公式。
+type mathFormula struct {
+	line    int
+	display bool
+	tex     string
+}
+
+// mathDiagnostics 校验正文中的公式，body 为去掉 front matter 后的正文，bodyLine 为其首行在源文件中的行号。
+func mathDiagnostics(task job.Task, body string, bodyLine int) []job.Diagnostic {
+	formulas, unclosed := scanMath(body)
+	diags := make([]job.Diagnostic, 0)
+	add := func(line int, format string, args ...any) {
+		diags = append(diags, job.Diagnostic{
+			Source:   task.SourcePath,
+			Line:     line + bodyLine - 1,
+			Severity: job.SeverityWarn,
+			Code:     DiagnosticInvalidMath,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	for _, f := range formulas {
+		if problem := validateTeX(f.tex); problem != "" {
+			delim := "$"
+			if f.display {
+				delim = "$$"
+			}
+			add(f.line, "公式语法错误：%s：%s%s%s", problem, delim, abbreviate(f.tex, 40), delim)
+		}
+	}
+	for _, line := range unclosed {
+		add(line, "行间公式 $$ 未闭合")
+	}
+	return diags
+}
+
+// scanMath 按 pandoc tex_math_dollars 的规则找出公式，跳过代码块与行内代码；返回公式与未闭合 $$ 的行号（相对 body）。
+// 行内公式：开头 $ 后不能是空白，结尾 $ 前不能是空白且其后不能紧跟数字（因此 $5 与 $10 这类金额不会被识别）。
+func scanMath(body string) ([]mathFormula, []int) {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	formulas := make([]mathFormula, 0)
+	unclosed := make([]int, 0)
+	inFence := false
+	fenceChar := byte(0)
+	fenceLen := 0
+	var display *mathFormula
+	var displayText strings.Builder
+
+	for i, line := range lines {
+		lineNo := i + 1
+		if display == nil {
+			if ch, n, ok := fenceMarker(strings.TrimSpace(line)); ok {
+				if !inFence {
+					inFence, fenceChar, fenceLen = true, ch, n
+					continue
+				}
+				if ch == fenceChar && n >= fenceLen {
+					inFence = false
+					continue
+				}
+			}
+			if inFence {
+				continue
+			}
+		} else if strings.TrimSpace(line) == "" {
+			// 行间公式不能跨越空行，pandoc 会把它当作普通文本。
+			unclosed = append(unclosed, display.line)
+			display = nil
+			continue
+		}
+
+		for pos := 0; pos < len(line); pos++ {
+			c := line[pos]
+			if display != nil {
+				if c == '\\' && pos+1 < len(line) {
+					displayText.WriteString(line[pos : pos+2])
+					pos++
+					continue
+				}
+				if strings.HasPrefix(line[pos:], "$$") {
+					display.tex = displayText.String()
+					formulas = append(formulas, *display)
+					display = nil
+					pos++
+					continue
+				}
+				displayText.WriteByte(c)
+				continue
+			}
+			switch {
+			case c == '\\':
+				pos++
+			case c == '`':
+				n := runLength(line[pos:], '`')
+				if end := strings.Index(line[pos+n:], strings.Repeat("`", n)); end >= 0 {
+					pos += n + end + n - 1
+				} else {
+					pos += n - 1
+				}
+			case strings.HasPrefix(line[pos:], "$$"):
+				display = &mathFormula{line: lineNo, display: true}
+				displayText.Reset()
+				pos++
+			case c == '$':
+				if end, ok := inlineMathEnd(line, pos); ok {
+					formulas = append(formulas, mathFormula{line: lineNo, tex: line[pos+1 : end]})
+					pos = end
+				}
+			}
+		}
+		if display != nil {
+			displayText.WriteByte('\n')
+		}
+	}
+	if display != nil {
+		unclosed = append(unclosed, display.line)
+	}
+	return formulas, unclosed
+}
+
+// inlineMathEnd 返回从 open 处开始的行内公式的结束 $ 位置。
+func inlineMathEnd(line string, open int) (int, bool) {
+	if open+1 >= len(line) || isSpace(line[open+1]) {
+		return 0, false
+	}
+	for i := open + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '$':
+			// 公式内容不能含未转义的 $；遇到不能作为结尾的 $ 时整体按普通文本处理。
+			if isSpace(line[i-1]) || (i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9') {
+				return 0, false
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// validateTeX 做轻量的结构检查：花括号配对、\begin/\end 环境配对、\left/\right 配对、末尾悬空的反斜杠。
+// 返回问题描述，无问题时返回空串。
+func validateTeX(tex string) string {
+	if strings.TrimSpace(tex) == "" {
+		return "公式为空"
+	}
+	depth := 0
+	lefts := 0
+	envs := make([]string, 0)
+	for i := 0; i < len(tex); i++ {
+		switch tex[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				return "多余的 }"
+			}
+		case '\\':
+			if i+1 >= len(tex) {
+				return "末尾有悬空的反斜杠"
+			}
+			name := texCommand(tex[i+1:])
+			if name == "" {
+				i++ // \{、\}、\\ 等转义字符
+				continue
+			}
+			i += len(name)
+			switch name {
+			case "left":
+				lefts++
+			case "right":
+				lefts--
+				if lefts < 0 {
+					return "\\right 缺少对应的 \\left"
+				}
+			case "begin", "end":
+				env, n, ok := texGroup(tex[i+1:])
+				if !ok {
+					return fmt.Sprintf("\\%s 缺少环境名", name)
+				}
+				i += n
+				if name == "begin" {
+					envs = append(envs, env)
+					continue
+				}
+				if len(envs) == 0 {
+					return fmt.Sprintf("\\end{%s} 缺少对应的 \\begin", env)
+				}
+				if open := envs[len(envs)-1]; open != env {
+					return fmt.Sprintf("\\begin{%s} 与 \\end{%s} 不匹配", open, env)
+				}
+				envs = envs[:len(envs)-1]
+			}
+		}
+	}
+	switch {
+	case depth > 0:
+		return "花括号未闭合（缺少 }）"
+	case len(envs) > 0:
+		return fmt.Sprintf("\\begin{%s} 缺少对应的 \\end", envs[len(envs)-1])
+	case lefts > 0:
+		return "\\left 缺少对应的 \\right"
+	}
+	return ""
+}
+
+// texCommand 返回 s 开头的命令名（字母序列）。
+func texCommand(s string) string {
+	n := 0
+	for n < len(s) && (s[n] >= 'a' && s[n] <= 'z' || s[n] >= 'A' && s[n] <= 'Z') {
+		n++
+	}
+	return s[:n]
+}
+
+// texGroup 读取 s 开头（可有空白）的 {name}，返回 name 与消耗的字节数。
+func texGroup(s string) (string, int, bool) {
+	trimmed := strings.TrimLeft(s, " ")
+	skip := len(s) - len(trimmed)
+	if !strings.HasPrefix(trimmed, "{") {
+		return "", 0, false
+	}
+	end := strings.IndexByte(trimmed, '}')
+	if end < 0 {
+		return "", 0, false
+	}
+	return strings.TrimSpace(trimmed[1:end]), skip + end + 1, true
+}
+
+func abbreviate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
@@ -0,0 +1,79 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanMath(t *testing.T) {
+	body := "价格 $5 到 $10，公式 $a^2 + b^2$ 与 `$not$`。\n" +
+		"```\n$$ignored$$\n```\n" +
+		"$$\n\\frac{1}{2}\n$$\n" +
+		"转义 \\$x$ 与 $ y$\n" +
+		"$$x\n\n正文\n"
+	formulas, unclosed := scanMath(body)
+	require.Equal(t, []mathFormula{
+		{line: 1, tex: "a^2 + b^2"},
+		{line: 5, display: true, tex: "\n\\frac{1}{2}\n"},
+	}, formulas)
+	require.Equal(t, []int{9}, unclosed)
+}
+
+func TestValidateTeX(t *testing.T) {
+	cases := map[string]string{
+		`\frac{a}{b}`:                       "",
+		`\{x\} \\ y`:                        "",
+		`\left( \frac{a}{b} \right)`:        "",
+		`\begin{matrix} a & b \end{matrix}`: "",
+		`\frac{a}{b`:                        "花括号未闭合（缺少 }）",
+		`a}`:                                "多余的 }",
+		`\left( x`:                          `\left 缺少对应的 \right`,
+		`x \right)`:                         `\right 缺少对应的 \left`,
+		`\begin{pmatrix} a \end{bmatrix}`:   `\begin{pmatrix} 与 \end{bmatrix} 不匹配`,
+		`\begin{cases} a`:                   `\begin{cases} 缺少对应的 \end`,
+		`\end{cases}`:                       `\end{cases} 缺少对应的 \begin`,
+		`x \`:                               "末尾有悬空的反斜杠",
+		"  ":                                "公式为空",
+		`\begin {aligned} a &= b \end{ aligned }`: "",
+	}
+	for tex, want := range cases {
+		require.Equal(t, want, validateTeX(tex), tex)
+	}
+}
+
+func TestMathDiagnosticsUseSourceLines(t *testing.T) {
+	task := job.Task{SourcePath: "/abs/a.md"}
+	diags := mathDiagnostics(task, "正文\n$\\frac{a}{b$\n$$\n\\left( x\n$$\n", 4)
+	require.Equal(t, []job.Diagnostic{
+		{Source: "/abs/a.md", Line: 5, Severity: job.SeverityWarn, Code: DiagnosticInvalidMath, Message: `公式语法错误：花括号未闭合（缺少 }）：$\frac{a}{b$`},
+		{Source: "/abs/a.md", Line: 6, Severity: job.SeverityWarn, Code: DiagnosticInvalidMath, Message: `公式语法错误：\left 缺少对应的 \right：$$\left( x$$`},
+	}, diags)
+}
+
+func TestConvertMathEnablesDollarMath(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("---\nnote: t\n---\n面积 $\\pi r^2$，错误 $\\frac{1}{2$\n"), 0o644))
+	var got []string
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		got = args
+		return exec.CommandContext(ctx, "true")
+	}
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Math = true
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Contains(t, got, "gfm+raw_attribute+hard_line_breaks+tex_math_dollars")
+	require.Len(t, res.Diagnostics, 1)
+	require.Equal(t, 4, res.Diagnostics[0].Line)
+	require.Equal(t, DiagnosticInvalidMath, res.Diagnostics[0].Code)
+}
@@ -0,0 +1,99 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+)
+
+const (
+	NotesFootnotes = "footnotes"
+	NotesEndnotes  = "endnotes"
+)
+
+// footnoteDefRe 匹配脚注定义的开始行：[^id]: 内容。
+var footnoteDefRe = regexp.MustCompile(`^ {0,3}\[\^[^\]\s]+\]:`)
+
+// noteFormats 是 --note-format 取值对应的 Word 编号格式（w:numFmt）。
+var noteFormats = map[string]string{
+	"decimal":      "decimal",
+	"lower-roman":  "lowerRoman",
+	"upper-roman":  "upperRoman",
+	"lower-letter": "lowerLetter",
+	"upper-letter": "upperLetter",
+	"symbol":       "chicago",
+	"chinese":      "chineseCounting",
+}
+
+// noteRestarts 是 --note-restart 取值对应的重新编号位置（w:numRestart）。
+var noteRestarts = map[string]string{
+	"continuous": docx.NoteRestartContinuous,
+	"section":    docx.NoteRestartEachSect,
+	"page":       docx.NoteRestartEachPage,
+}
+
+// NoteOptions 控制 Markdown 脚注在 Word 中的形式与编号；零值保持 pandoc 默认的脚注。
+type NoteOptions struct {
+	// Kind 为 footnotes（默认）或 endnotes（全部改为尾注，集中在文末）。
+	Kind string
+	// Format 为编号格式：decimal、lower-roman、upper-roman、lower-letter、upper-letter、symbol 或 chinese。
+	Format string
+	// Restart 为重新编号的位置：continuous、section 或 page（仅脚注）。
+	Restart string
+}
+
+func (o NoteOptions) Validate() error {
+	kind := o.kind()
+	if kind != NotesFootnotes && kind != NotesEndnotes {
+		return fmt.Errorf("--notes 仅支持 %s 或 %s：%s", NotesFootnotes, NotesEndnotes, o.Kind)
+	}
+	if format := normalizeOption(o.Format); format != "" && noteFormats[format] == "" {
+		return fmt.Errorf("--note-format 仅支持 decimal、lower-roman、upper-roman、lower-letter、upper-letter、symbol 或 chinese：%s", o.Format)
+	}
+	switch restart := normalizeOption(o.Restart); {
+	case restart == "":
+	case noteRestarts[restart] == "":
+		return fmt.Errorf("--note-restart 仅支持 continuous、section 或 page：%s", o.Restart)
+	case restart == "page" && kind == NotesEndnotes:
+		return fmt.Errorf("--note-restart=page 仅适用于脚注，尾注请使用 continuous 或 section")
+	}
+	return nil
+}
+
+func (o NoteOptions) isZero() bool {
+	return o.kind() == NotesFootnotes && normalizeOption(o.Format) == "" && normalizeOption(o.Restart) == ""
+}
+
+func (o NoteOptions) kind() string {
+	if kind := normalizeOption(o.Kind); kind != "" {
+		return kind
+	}
+	return NotesFootnotes
+}
+
+func (o NoteOptions) numbering() docx.NoteNumbering {
+	return docx.NoteNumbering{
+		Format:  noteFormats[normalizeOption(o.Format)],
+		Restart: noteRestarts[normalizeOption(o.Restart)],
+	}
+}
+
+func normalizeOption(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// applyNotes 按 p.Notes 把脚注改为尾注并设置编号。
+func (p *PandocConverter) applyNotes(pkg *docx.Package) error {
+	endnotes := p.Notes.kind() == NotesEndnotes
+	if endnotes {
+		if _, err := pkg.ConvertFootnotesToEndnotes(); err != nil {
+			return err
+		}
+	}
+	if n := p.Notes.numbering(); n != (docx.NoteNumbering{}) {
+		return pkg.SetNoteNumbering(endnotes, n)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoteOptionsValidate(t *testing.T) {
+	require.NoError(t, NoteOptions{}.Validate())
+	require.NoError(t, NoteOptions{Kind: "Endnotes", Format: "lower-roman", Restart: "section"}.Validate())
+	require.ErrorContains(t, NoteOptions{Kind: "sidenotes"}.Validate(), "--notes 仅支持 footnotes 或 endnotes：sidenotes")
+	require.ErrorContains(t, NoteOptions{Format: "roman"}.Validate(), "--note-format 仅支持")
+	require.ErrorContains(t, NoteOptions{Restart: "chapter"}.Validate(), "--note-restart 仅支持")
+	require.ErrorContains(t, NoteOptions{Kind: "endnotes", Restart: "page"}.Validate(), "仅适用于脚注")
+	require.True(t, NoteOptions{Kind: "footnotes"}.isZero())
+	require.Equal(t, docx.NoteNumbering{Format: "chicago", Restart: "eachPage"}, NoteOptions{Format: "symbol", Restart: "page"}.numbering())
+}
+
+func TestConvertAppliesNoteNumbering(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	dst := filepath.Join(tmp, "a.docx")
+	require.NoError(t, os.WriteFile(src, []byte("正文[^1]\n\n[^1]: 注释\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Notes = NoteOptions{Kind: "endnotes", Format: "upper-roman", Restart: "section"}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.NoError(t, res.Error)
+
+	doc := readDocxPart(t, dst, docx.PartDocument)
+	require.Contains(t, doc, `<w:endnotePr><w:numFmt w:val="upperRoman"/><w:numRestart w:val="eachSect"/></w:endnotePr>`)
+}
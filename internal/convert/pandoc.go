@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,7 +12,8 @@ import (
 	"runtime"
 	"strings"
 
-	"syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/frontmatter"
+	"github.com/hooziwang/syl-md2doc/internal/job"
 )
 
 var execCommandContext = exec.CommandContext
@@ -29,6 +31,39 @@ type PandocConverter struct {
 	PandocPath    string
 	ReferenceDocx string
 	Verbose       bool
+	ResourcePath  string
+	// IncludeRoot 为包含指令允许读取的目录（绝对路径），为空时为 IncludeBase。
+	IncludeRoot string
+	// IncludeBase 为主文档中包含路径的解析目录（绝对路径），为空时为主文档所在目录。
+	IncludeBase string
+	// SandboxDir 不为空时，正文图片与 front matter 中的参考文献、引用样式都必须位于该目录之内（HTTP 服务为请求目录）。
+	SandboxDir   string
+	Properties   PropertyOptions
+	HeaderFooter HeaderFooterOptions
+	// Cover 为封面模板路径：.docx 为 Word 片段，其余按 Markdown 模板处理。
+	Cover string
+	// Vars 是命令行变量（--var），在模板变量中优先级最高。
+	Vars map[string]string
+	// Template 启用正文中的 {{ name }} 变量与 {{ if }} 条件块；未启用时正文中的 {{ ... }} 原样保留。
+	Template       bool
+	Watermark      WatermarkOptions
+	Classification string
+	// Bibliography 与 CSL 为命令行指定的参考文献与引用样式（绝对路径），设置后启用 citeproc。
+	Bibliography []string
+	CSL          string
+	// Math 启用 $...$ / $$...$$ 公式解析（输出为 Word 原生公式），并在预处理时校验公式语法。
+	Math        bool
+	Highlight   HighlightOptions
+	Admonitions AdmonitionOptions
+	Diagrams    DiagramOptions
+	Page        PageOptions
+	// BlankLines 控制正文空行是否转换为空段落，为空时按 preserve 处理。
+	BlankLines BlankLineMode
+	Tables     TableOptions
+	Notes      NoteOptions
+	Fonts      FontOptions
+	// Validate 在转换完成后校验输出 docx 的结构，存在错误时任务失败。
+	Validate bool
 }
 
 func NewPandocConverter(pandocPath, referenceDocx string, verbose bool) *PandocConverter {
@@ -72,7 +107,7 @@ func (p *PandocConverter) Convert(ctx context.Context, task job.Task) job.Result
 	if refPath == "" {
 		tmpRef, err := materializeDefaultReferenceDocx()
 		if err != nil {
-			res.Error = fmt.Errorf("准备内置 reference-docx 失败：%w", err)
+			res.Error = job.Transient(fmt.Errorf("准备内置 reference-docx 失败：%w", err))
 			return res
 		}
 		refPath = tmpRef
@@ -81,31 +116,62 @@ func (p *PandocConverter) Convert(ctx context.Context, task job.Task) job.Result
 		}()
 	}
 
-	sourcePath := task.SourcePath
-	tmpSourcePath, err := materializeSourceWithBlankParagraphs(task.SourcePath)
+	src, prepWarnings, err := p.prepareSource(ctx, task)
+	res.Warnings = append(res.Warnings, prepWarnings...)
+	res.Diagnostics = append(res.Diagnostics, src.diagnostics...)
+	res.Includes = src.includes
+	if src.code {
+		res.Warnings = append(res.Warnings, p.codeStyleWarnings()...)
+	}
 	if err != nil {
-		res.Error = fmt.Errorf("预处理 Markdown 空行失败：%w", err)
+		res.Error = fmt.Errorf("预处理 Markdown 失败：%w", err)
 		return res
 	}
-	if tmpSourcePath != "" {
-		sourcePath = tmpSourcePath
+	sourcePath := src.path
+	if src.temp {
 		defer func() {
-			_ = os.Remove(tmpSourcePath)
+			_ = os.Remove(src.path)
 		}()
 	}
 
-	luaFilterPath, err := materializeHighlightLuaFilter()
+	luaFilterPath, err := materializeLuaFilter(buildHighlightLuaFilter() + buildAdmonitionLuaFilter(p.Admonitions))
 	if err != nil {
-		res.Error = fmt.Errorf("准备高亮过滤器失败：%w", err)
+		res.Error = job.Transient(fmt.Errorf("准备 Lua 过滤器失败：%w", err))
 		return res
 	}
 	defer func() {
 		_ = os.Remove(luaFilterPath)
 	}()
 
-	args := []string{sourcePath, "-f", "gfm+raw_attribute+hard_line_breaks", "-t", "docx", "-o", task.TargetPath}
+	citeArgs, metaFile, err := src.citeproc.args()
+	if err != nil {
+		res.Error = err
+		return res
+	}
+	if metaFile != "" {
+		defer func() {
+			_ = os.Remove(metaFile)
+		}()
+	}
+
+	from := "gfm+raw_attribute+hard_line_breaks"
+	if src.citeproc.enabled() {
+		from += "+citations"
+	}
+	if p.Math {
+		from += mathExtension
+	}
+	if src.divs {
+		from += "+fenced_divs"
+	}
+	args := []string{sourcePath, "-f", from, "-t", "docx", "-o", task.TargetPath}
 	args = append(args, "--reference-doc="+refPath)
 	args = append(args, "--lua-filter="+luaFilterPath)
+	if rp := strings.TrimSpace(p.ResourcePath); rp != "" {
+		args = append(args, "--resource-path="+rp)
+	}
+	args = append(args, p.Highlight.args()...)
+	args = append(args, citeArgs...)
 
 	cmd := execCommandContext(ctx, bin, args...)
 	stderr := bytes.NewBuffer(nil)
@@ -116,24 +182,101 @@ func (p *PandocConverter) Convert(ctx context.Context, task job.Task) job.Result
 
 	err = cmd.Run()
 	stderrText := strings.TrimSpace(stderr.String())
+	citeDiags, stderrText := citationDiagnostics(stderrText, task, src.body)
+	res.Diagnostics = append(res.Diagnostics, citeDiags...)
 	res.Warnings = append(res.Warnings, collectWarnings(stderrText)...)
 
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			res.Error = fmt.Errorf("转换已取消：%w", ctxErr)
+			return res
+		}
+		missingAssetOnly := false
 		if isMissingAssetOnly(stderrText) {
 			if _, stErr := os.Stat(task.TargetPath); stErr == nil {
 				res.Warnings = append(res.Warnings, "检测到缺失资源，已忽略并继续")
-				return res
+				missingAssetOnly = true
+			}
+		}
+		if !missingAssetOnly {
+			reason := stderrText
+			if reason == "" {
+				reason = err.Error()
+			}
+			res.Error = fmt.Errorf("pandoc 转换失败：%s", reason)
+			if isTransientFailure(err, stderrText) {
+				res.Error = job.Transient(res.Error)
 			}
+			return res
 		}
-		reason := stderrText
-		if reason == "" {
-			reason = err.Error()
+	}
+
+	warnings, err := p.postProcess(ctx, task, src)
+	res.Warnings = append(res.Warnings, warnings...)
+	if err != nil {
+		res.Error = fmt.Errorf("docx 后处理失败：%w", err)
+		return res
+	}
+	if p.Verbose || p.Fonts.Embed {
+		fonts, fontWarnings, err := p.processFonts(task)
+		res.Fonts = fonts
+		res.Warnings = append(res.Warnings, fontWarnings...)
+		switch {
+		case err != nil && p.Fonts.Embed:
+			res.Error = fmt.Errorf("嵌入字体失败：%w", err)
+		case err != nil:
+			// 只生成报告时，读取失败不影响转换结果。
+			res.Warnings = append(res.Warnings, fmt.Sprintf("生成字体报告失败：%v", err))
 		}
-		res.Error = fmt.Errorf("pandoc 转换失败：%s", reason)
+	}
+	if p.Validate && res.Error == nil {
+		diags, err := validateOutput(task)
+		res.Diagnostics = append(res.Diagnostics, diags...)
+		res.Error = err
 	}
 	return res
 }
 
+// isTransientFailure 判断 pandoc 失败是否属于环境抖动（被信号终止、内存或文件句柄耗尽、临时文件创建冲突），这类失败值得重试。
+// 磁盘空间不足等短时间内不会恢复的失败不重试。
+func isTransientFailure(runErr error, stderrText string) bool {
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) && exitErr.ExitCode() == -1 {
+		return true
+	}
+	lower := strings.ToLower(stderrText + "\n" + errString(runErr))
+	if strings.Contains(lower, "no space left on device") {
+		// Haskell 运行时把磁盘已满报告为 resource exhausted，需先排除。
+		return false
+	}
+	patterns := []string{
+		"out of memory",
+		"cannot allocate memory",
+		"heap exhausted",
+		"resource exhausted",
+		"resource temporarily unavailable",
+		"too many open files",
+		"signal: killed",
+		// pandoc（Haskell 运行时）创建临时文件或目录失败时，错误信息以这些函数名开头。
+		"opentempfile",
+		"openbinarytempfile",
+		"withtempdirectory",
+	}
+	for _, p := range patterns {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
 func collectWarnings(stderrText string) []string {
 	if strings.TrimSpace(stderrText) == "" {
 		return nil
@@ -269,18 +412,18 @@ func materializeDefaultReferenceDocx() (string, error) {
 	return f.Name(), nil
 }
 
-func materializeHighlightLuaFilter() (string, error) {
-	content := buildHighlightLuaFilter()
-	f, err := os.CreateTemp("", "syl-md2doc-highlight-*.lua")
+// materializeLuaFilter 把内置 Lua 过滤器（关键词高亮、提示块）写入临时文件。
+func materializeLuaFilter(content string) (string, error) {
+	f, err := os.CreateTemp("", "syl-md2doc-filter-*.lua")
 	if err != nil {
-		return "", fmt.Errorf("创建临时高亮过滤器失败：%w", err)
+		return "", fmt.Errorf("创建临时 Lua 过滤器失败：%w", err)
 	}
 	defer func() {
 		_ = f.Close()
 	}()
 	if _, err := f.WriteString(content); err != nil {
 		_ = os.Remove(f.Name())
-		return "", fmt.Errorf("写入临时高亮过滤器失败：%w", err)
+		return "", fmt.Errorf("写入临时 Lua 过滤器失败：%w", err)
 	}
 	return f.Name(), nil
 }
@@ -293,71 +436,126 @@ func buildHighlightLuaFilter() string {
 	return b.String()
 }
 
-func materializeSourceWithBlankParagraphs(sourcePath string) (string, error) {
-	content, err := os.ReadFile(sourcePath)
+type preparedSource struct {
+	path  string
+	temp  bool
+	meta  map[string]any
+	props map[string]string
+	vars  *templateVars
+	// body 是展开包含、套用模板后的正文及行号映射，用于把 pandoc 告警定位回实际的源文件与行号。
+	body     includedSource
+	citeproc citeproc
+	// diagnostics 是预处理阶段发现的问题（如公式语法错误）。
+	diagnostics []job.Diagnostic
+	// code 表示正文含围栏代码块；divs 表示使用了 ::: 围栏块。
+	code bool
+	divs bool
+	// includes 是通过包含指令引入的文件。
+	includes []string
+	// cover 表示正文前插入了封面节。
+	cover bool
+	// sections 表示正文中插入了分节符（分节标记或 ::: landscape 横向节）；lastLandscape 表示最后一节为横向。
+	sections      bool
+	lastLandscape bool
+	// tableWidths 是表格列宽标记书签名到相对列宽的映射。
+	tableWidths map[string][]float64
+}
+
+// prepareSource 拆出 front matter、插入 Markdown 封面并执行 Markdown 预处理；内容有变化时写入临时文件。
+func (p *PandocConverter) prepareSource(ctx context.Context, task job.Task) (preparedSource, []string, error) {
+	content, err := os.ReadFile(task.SourcePath)
 	if err != nil {
-		return "", fmt.Errorf("读取 Markdown 源文件失败：%w", err)
+		return preparedSource{}, nil, fmt.Errorf("读取 Markdown 源文件失败：%w", err)
 	}
-	processed, changed := preserveMarkdownBlankLines(string(content))
-	if !changed {
-		return "", nil
+	doc := frontmatter.Split(string(content))
+	props := documentProperties(p.Properties, doc.Meta)
+	cite, err := p.citeprocFor(task, doc.Meta)
+	if err != nil {
+		return preparedSource{}, nil, err
+	}
+	inc, err := expandIncludes(task.SourcePath, doc.Body, doc.BodyLine, p.IncludeBase, p.IncludeRoot)
+	if err != nil {
+		return preparedSource{}, nil, err
+	}
+	vars := newTemplateVars(ctx, task, doc.Meta, props, p.Vars)
+	expanded := inc.body
+	if p.Template {
+		var templateDiags []job.Diagnostic
+		inc, templateDiags = renderTemplate(task, inc, vars.lookup)
+		if len(templateDiags) > 0 {
+			first := templateDiags[0]
+			return preparedSource{diagnostics: templateDiags}, nil, fmt.Errorf("模板处理失败（%d 处错误）：%s 第 %d 行：%s", len(templateDiags), filepath.Base(first.Source), first.Line, first.Message)
+		}
+	}
+	src := preparedSource{
+		path:     task.SourcePath,
+		meta:     doc.Meta,
+		props:    props,
+		vars:     vars,
+		citeproc: cite,
+		code:     hasFencedCode(inc.body),
+		includes: inc.files,
+	}
+	inc, linkDiags := p.resolveMarkdownLinks(task, inc)
+	src.diagnostics = append(src.diagnostics, linkDiags...)
+	inc, tableWidths, tableDiags := tableWidthHints(task, inc)
+	src.tableWidths = tableWidths
+	src.diagnostics = append(src.diagnostics, tableDiags...)
+	inc, refDiags := resolveCrossrefs(task, inc)
+	src.diagnostics = append(src.diagnostics, refDiags...)
+	src.body = inc
+	if p.SandboxDir != "" {
+		// 在渲染图表之前检查：图表图片位于缓存目录，不受限制。
+		if err := checkSandboxImages(inc, p.SandboxDir); err != nil {
+			return preparedSource{}, nil, err
+		}
+	}
+	// 公式与图表在展开包含、套用模板后的正文上处理，诊断再映射回实际所在的文件与行号。
+	if p.Math {
+		src.diagnostics = append(src.diagnostics, inc.locate(mathDiagnostics(task, inc.body, 1))...)
+	}
+	body, rendered, diagramDiags := p.renderDiagrams(ctx, task, inc.body, 1)
+	src.diagnostics = append(src.diagnostics, inc.locate(diagramDiags)...)
+
+	body, broken, sectionBreaks := explicitBreaks(body)
+	body, src.sections, src.lastLandscape = landscapeSections(body)
+	src.sections = src.sections || sectionBreaks
+
+	processed, changed := formatBlankLines(body, p.BlankLines)
+	changed = changed || rendered || broken || src.sections || inc.body != expanded || len(inc.files) > 0
+	var warnings []string
+	if p.Cover != "" {
+		src.cover = true
+		if !isDocxCover(p.Cover) {
+			cover, warns, err := renderMarkdownCover(p.Cover, src.vars)
+			if err != nil {
+				return preparedSource{}, nil, err
+			}
+			warnings = warns
+			// 封面与正文分别做空行保留，避免分节符两侧多出空段落。
+			cover, _ = formatBlankLines(cover, p.BlankLines)
+			processed = cover + markdownCoverBreak + processed
+			changed = true
+		}
+	}
+	src.divs = hasFencedDivs(processed)
+	if !changed && doc.Meta == nil {
+		return src, warnings, nil
 	}
 	f, err := os.CreateTemp("", "syl-md2doc-source-*.md")
 	if err != nil {
-		return "", fmt.Errorf("创建临时 Markdown 文件失败：%w", err)
+		return preparedSource{}, nil, job.Transient(fmt.Errorf("创建临时 Markdown 文件失败：%w", err))
 	}
 	defer func() {
 		_ = f.Close()
 	}()
 	if _, err := f.WriteString(processed); err != nil {
 		_ = os.Remove(f.Name())
-		return "", fmt.Errorf("写入临时 Markdown 文件失败：%w", err)
-	}
-	return f.Name(), nil
-}
-
-func preserveMarkdownBlankLines(input string) (string, bool) {
-	normalized := strings.ReplaceAll(input, "\r\n", "\n")
-	lines := strings.Split(normalized, "\n")
-	hasTrailingNewline := strings.HasSuffix(normalized, "\n")
-	if hasTrailingNewline && len(lines) > 0 {
-		lines = lines[:len(lines)-1]
-	}
-
-	var b strings.Builder
-	inFence := false
-	fenceChar := byte(0)
-	fenceLen := 0
-	changed := false
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if ch, ln, ok := fenceMarker(trimmed); ok {
-			if !inFence {
-				inFence = true
-				fenceChar = ch
-				fenceLen = ln
-			} else if ch == fenceChar && ln >= fenceLen {
-				inFence = false
-				fenceChar = 0
-				fenceLen = 0
-			}
-		}
-
-		if !inFence && trimmed == "" {
-			b.WriteString("```{=openxml}\n<w:p/>\n```")
-			changed = true
-		} else {
-			b.WriteString(line)
-		}
-		if i < len(lines)-1 {
-			b.WriteString("\n")
-		}
-	}
-	if hasTrailingNewline {
-		b.WriteString("\n")
+		return preparedSource{}, nil, job.Transient(fmt.Errorf("写入临时 Markdown 文件失败：%w", err))
 	}
-	return b.String(), changed
+	src.path = f.Name()
+	src.temp = true
+	return src, warnings, nil
 }
 
 func fenceMarker(trimmed string) (byte, int, bool) {
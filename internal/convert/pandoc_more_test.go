@@ -2,14 +2,15 @@ package convert
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/hooziwang/syl-md2doc/internal/job"
 	"github.com/stretchr/testify/require"
-	"syl-md2doc/internal/job"
 )
 
 func TestEnsurePandocAvailableVersionTooLowStillPass(t *testing.T) {
@@ -91,3 +92,107 @@ func TestBuildHighlightLuaFilterIncludesStrongRule(t *testing.T) {
 	require.Contains(t, script, "pandoc.Strong")
 	require.Contains(t, script, "KeywordHighlight")
 }
+
+func TestPandocConverterMarksResourceExhaustionTransient(t *testing.T) {
+	orig := execCommandContext
+	defer func() { execCommandContext = orig }()
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "echo 'pandoc: out of memory (requested 1048576 bytes)' 1>&2; exit 251")
+	}
+
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# x"), 0o644))
+
+	res := NewPandocConverter("pandoc", "", false).Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(tmp, "a.docx")})
+	require.Error(t, res.Error)
+	require.True(t, job.IsTransient(res.Error))
+}
+
+func TestPandocConverterSignalKillTransient(t *testing.T) {
+	orig := execCommandContext
+	defer func() { execCommandContext = orig }()
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "kill -9 $$")
+	}
+
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# x"), 0o644))
+
+	res := NewPandocConverter("pandoc", "", false).Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(tmp, "a.docx")})
+	require.Error(t, res.Error)
+	require.True(t, job.IsTransient(res.Error))
+}
+
+func TestPandocConverterSyntaxErrorNotTransient(t *testing.T) {
+	orig := execCommandContext
+	defer func() { execCommandContext = orig }()
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "echo 'fatal parser error' 1>&2; exit 1")
+	}
+
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("# x"), 0o644))
+
+	res := NewPandocConverter("pandoc", "", false).Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(tmp, "a.docx")})
+	require.Error(t, res.Error)
+	require.False(t, job.IsTransient(res.Error))
+}
+
+func TestIsTransientFailureStderrPatterns(t *testing.T) {
+	for stderr, want := range map[string]bool{
+		"pandoc: /tmp/pandoc-1234/media: openBinaryTempFile: resource busy":                 true,
+		"pandoc: withTempDirectory: already exists":                                         true,
+		"pandoc: /out/a.docx: withBinaryFile: resource exhausted (No space left on device)": false,
+		"Could not fetch resource 'missing-temporary file.png'":                             false,
+	} {
+		require.Equal(t, want, isTransientFailure(errors.New("exit status 1"), stderr), stderr)
+	}
+}
+
+func TestPrepareFailureUnderTempNamedDirNotTransient(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "临时")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	src := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("!include missing.md\n"), 0o644))
+
+	res := NewPandocConverter("pandoc", "", false).Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.ErrorContains(t, res.Error, "临时")
+	require.False(t, job.IsTransient(res.Error))
+}
+
+func TestDocumentPropertiesPrecedence(t *testing.T) {
+	props := documentProperties(PropertyOptions{
+		Defaults:  map[string]string{"company": "Default Co", "Classification": "Internal", "title": "Default"},
+		Overrides: map[string]string{"Title": "CLI Title"},
+	}, map[string]any{
+		"title":      "FM Title",
+		"author":     []any{"Alice", "Bob"},
+		"properties": map[string]any{"classification": "Secret"},
+	})
+	require.Equal(t, map[string]string{
+		"company":        "Default Co",
+		"classification": "Secret",
+		"Title":          "CLI Title",
+		"author":         "Alice; Bob",
+	}, props)
+	require.Nil(t, documentProperties(PropertyOptions{}, nil))
+}
+
+func TestPrepareSourceStripsFrontMatter(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("---\ntitle: T\n---\n# a\n"), 0o644))
+
+	prepared, warnings, err := (&PandocConverter{}).prepareSource(context.Background(), job.Task{SourcePath: src})
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	defer os.Remove(prepared.path)
+	require.True(t, prepared.temp)
+	require.Equal(t, "T", prepared.meta["title"])
+	bs, err := os.ReadFile(prepared.path)
+	require.NoError(t, err)
+	require.Equal(t, "# a\n", string(bs))
+}
@@ -8,8 +8,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hooziwang/syl-md2doc/internal/job"
 	"github.com/stretchr/testify/require"
-	"syl-md2doc/internal/job"
 )
 
 func TestEnsurePandocAvailableMissingBinary(t *testing.T) {
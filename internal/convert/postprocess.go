@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"context"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/frontmatter"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+// PropertyOptions 是写入 docx 文档属性的来源；优先级：Defaults < front matter < Overrides。
+type PropertyOptions struct {
+	Defaults  map[string]string
+	Overrides map[string]string
+}
+
+// frontMatterPropertyKeys 是直接映射为文档属性的 front matter 字段；其余属性放在 front matter 的 properties 映射中。
+var frontMatterPropertyKeys = []string{
+	"title", "subject", "author", "keywords", "description", "category",
+	"company", "manager", "status", "version", "language", "identifier",
+}
+
+// postProcess 在 pandoc 产出 docx 后就地修补，返回告警；没有需要修补的内容时不打开 docx。
+func (p *PandocConverter) postProcess(ctx context.Context, task job.Task, src preparedSource) ([]string, error) {
+	props := src.props
+	headerFooters, warnings := p.headerFooters(src)
+	watermark, classification := p.markings(src.meta)
+	lineNumbers := p.Highlight.LineNumbers && src.code
+	if len(props) == 0 && len(headerFooters) == 0 && !src.cover && watermark == "" && classification == "" && !lineNumbers && !src.sections && p.Page.isZero() &&
+		p.Tables.isZero() && len(src.tableWidths) == 0 && p.Notes.isZero() {
+		return warnings, nil
+	}
+	pkg, err := docx.Open(task.TargetPath)
+	if err != nil {
+		return warnings, err
+	}
+	if err := pkg.SetProperties(props); err != nil {
+		return warnings, err
+	}
+	if src.sections || !p.Page.isZero() {
+		setup, err := p.Page.setup()
+		if err != nil {
+			return warnings, err
+		}
+		if err := pkg.FinishSections(setup, src.lastLandscape); err != nil {
+			return warnings, err
+		}
+	}
+	if f, tableWarnings, ok := p.tableFormat(pkg, src.tableWidths); ok {
+		warnings = append(warnings, tableWarnings...)
+		if err := pkg.FormatTables(f); err != nil {
+			return warnings, err
+		}
+	}
+	if !p.Notes.isZero() {
+		if err := p.applyNotes(pkg); err != nil {
+			return warnings, err
+		}
+	}
+	if lineNumbers {
+		if err := numberCodeLines(pkg); err != nil {
+			return warnings, err
+		}
+	}
+	if src.cover {
+		coverWarnings, err := p.applyCover(pkg, src.vars)
+		warnings = append(warnings, coverWarnings...)
+		if err != nil {
+			return warnings, err
+		}
+	}
+	for _, hf := range headerFooters {
+		if err := pkg.SetHeaderFooter(hf); err != nil {
+			return warnings, err
+		}
+	}
+	if err := p.applyMarkings(pkg, watermark, classification); err != nil {
+		return warnings, err
+	}
+	return warnings, pkg.Save()
+}
+
+func documentProperties(opts PropertyOptions, meta map[string]any) map[string]string {
+	type named struct {
+		name  string
+		value string
+	}
+	merged := map[string]named{}
+	put := func(props map[string]string) {
+		for k, v := range props {
+			merged[docx.NormalizePropertyKey(k)] = named{name: k, value: v}
+		}
+	}
+
+	put(opts.Defaults)
+	if meta != nil {
+		fm := map[string]string{}
+		for _, key := range frontMatterPropertyKeys {
+			sep := ", "
+			if key == "author" {
+				sep = "; "
+			}
+			if v, ok := frontmatter.String(meta[key], sep); ok {
+				fm[key] = v
+			}
+		}
+		put(fm)
+		put(frontmatter.StringMap(meta, "properties"))
+	}
+	put(opts.Overrides)
+
+	if len(merged) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(merged))
+	for _, n := range merged {
+		out[n.name] = n.value
+	}
+	return out
+}
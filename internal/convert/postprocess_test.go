@@ -0,0 +1,228 @@
+package convert
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+// useReferenceOutputPandoc 让 pandoc 直接把 --reference-doc 复制为输出，用真实 docx 验证后处理。
+func useReferenceOutputPandoc(t *testing.T) {
+	t.Helper()
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	script := `out=""; ref=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -o) out="$2"; shift 2; continue ;;
+    --reference-doc=*) ref="${1#--reference-doc=}" ;;
+  esac
+  shift
+done
+cp "$ref" "$out"`
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if name == "git" {
+			return exec.CommandContext(ctx, "sh", "-c", "echo abc1234")
+		}
+		return exec.CommandContext(ctx, "sh", append([]string{"-c", script, "pandoc"}, args...)...)
+	}
+}
+
+func readDocxPart(t *testing.T, path, name string) string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		require.NoError(t, err)
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		return string(data)
+	}
+	t.Fatalf("docx 中不存在部件 %s", name)
+	return ""
+}
+
+func TestConvertAppliesHeaderFooterTemplates(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	origNow := nowFunc
+	t.Cleanup(func() { nowFunc = origNow })
+	nowFunc = func() time.Time { return time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC) }
+
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "spec.md")
+	dst := filepath.Join(tmp, "spec.docx")
+	require.NoError(t, os.WriteFile(src, []byte("---\ntitle: 接口规范\nversion: 1.2\n---\n# a\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.HeaderFooter = HeaderFooterOptions{
+		Header:             "{title} — {version}|{file} @ {git_rev}",
+		Footer:             "Page {page} of {pages}",
+		DifferentFirstPage: true,
+		EvenHeader:         "{date} {missing}",
+	}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.NoError(t, res.Error)
+	require.Contains(t, res.Warnings, "页眉页脚模板变量未定义：{missing}")
+
+	require.Contains(t, readDocxPart(t, dst, "word/header1.xml"), "接口规范 — 1.2")
+	require.Contains(t, readDocxPart(t, dst, "word/header1.xml"), "spec @ abc1234")
+	require.Contains(t, readDocxPart(t, dst, "word/footer1.xml"), " NUMPAGES ")
+	require.Contains(t, readDocxPart(t, dst, "word/header3.xml"), "2026-03-04 ")
+
+	doc := readDocxPart(t, dst, docx.PartDocument)
+	require.Contains(t, doc, `w:type="first"`)
+	require.Contains(t, doc, `<w:titlePg/><w:docGrid`)
+	require.Contains(t, readDocxPart(t, dst, docx.PartSettings), "<w:evenAndOddHeaders/><w:drawingGridVerticalSpacing")
+	require.Contains(t, readDocxPart(t, dst, docx.PartCoreProps), "接口规范")
+}
+
+func TestRenderHeaderFooterTemplate(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "title" {
+			return "T", true
+		}
+		return "", false
+	}
+	segments, undefined := renderHeaderFooterTemplate(`{title} \| {{x}}|{page}/{ PAGES }|{nope}{open`, lookup)
+	require.Equal(t, [][]docx.Run{
+		{{Text: "T | {x}"}},
+		{{Field: "PAGE", Text: "1"}, {Text: "/"}, {Field: "NUMPAGES", Text: "1"}},
+		{{Text: "{open"}},
+	}, segments)
+	require.Equal(t, []string{"nope"}, undefined)
+}
+
+func TestHeaderFootersInheritDefaults(t *testing.T) {
+	src := preparedSource{vars: newTemplateVars(context.Background(), job.Task{SourcePath: "/x/a.md"}, nil, nil, nil)}
+	conv := &PandocConverter{HeaderFooter: HeaderFooterOptions{Header: "H", Footer: "F", FirstHeader: "FH"}}
+	hfs, warnings := conv.headerFooters(src)
+	require.Empty(t, warnings)
+	require.Len(t, hfs, 4)
+	require.Equal(t, docx.HeaderFooter{Footer: true, Type: docx.HeaderFooterFirst, Segments: [][]docx.Run{{{Text: "F"}}}}, hfs[3])
+
+	conv.HeaderFooter = HeaderFooterOptions{Footer: "F", DifferentFirstPage: true}
+	src.cover = true
+	hfs, _ = conv.headerFooters(src)
+	require.Len(t, hfs, 3)
+	require.Equal(t, docx.HeaderFooter{Footer: true, Type: docx.HeaderFooterFirst, Segments: [][]docx.Run{nil}, SkipSections: 1}, hfs[2])
+}
+
+const coverFrontMatter = `---
+title: 接口规范
+version: "1.2"
+revisions:
+  - version: "1.0"
+    date: 2026-01-05
+    author: Alice
+    changes: 初稿
+  - version: "1.2"
+    date: 2026-02-01
+    author: Bob
+---
+# 正文
+`
+
+func TestPrepareSourcePrependsMarkdownCover(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "spec.md")
+	cover := filepath.Join(tmp, "cover.md")
+	require.NoError(t, os.WriteFile(src, []byte(coverFrontMatter), 0o644))
+	require.NoError(t, os.WriteFile(cover, []byte("# {title}\n\n{subtitle}版本 {version}（{owner}）\n\n{revision_table}\n"), 0o644))
+
+	conv := &PandocConverter{Cover: cover, Vars: map[string]string{"owner": "平台组"}}
+	prepared, warnings, err := conv.prepareSource(context.Background(), job.Task{SourcePath: src})
+	require.NoError(t, err)
+	defer os.Remove(prepared.path)
+	require.True(t, prepared.cover)
+	require.Equal(t, []string{"封面模板变量未定义：{subtitle}"}, warnings)
+
+	bs, err := os.ReadFile(prepared.path)
+	require.NoError(t, err)
+	cover, _ = preserveMarkdownBlankLines("# 接口规范\n\n版本 1.2（平台组）\n\n" +
+		"| 版本 | 日期 | 作者 | 修订说明 |\n| --- | --- | --- | --- |\n| 1.0 | 2026-01-05 | Alice | 初稿 |\n| 1.2 | 2026-02-01 | Bob |  |")
+	require.Equal(t, cover+markdownCoverBreak+"# 正文\n", string(bs))
+}
+
+func TestConvertPrependsDocxCover(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "spec.md")
+	dst := filepath.Join(tmp, "spec.docx")
+	require.NoError(t, os.WriteFile(src, []byte(coverFrontMatter), 0o644))
+
+	fragment, err := docx.Read(defaultReferenceDocx)
+	require.NoError(t, err)
+	fragment.SetPart(docx.PartDocument, []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>{ti</w:t></w:r><w:r><w:t>tle}</w:t></w:r></w:p><w:p><w:r><w:t>{revision_table}</w:t></w:r></w:p><w:sectPr/></w:body></w:document>`))
+	data, err := fragment.Bytes()
+	require.NoError(t, err)
+	cover := filepath.Join(tmp, "cover.docx")
+	require.NoError(t, os.WriteFile(cover, data, 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Cover = cover
+	conv.HeaderFooter = HeaderFooterOptions{Footer: "{page}"}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.NoError(t, res.Error)
+	require.Empty(t, res.Warnings)
+
+	doc := readDocxPart(t, dst, docx.PartDocument)
+	body := doc[strings.Index(doc, "<w:body>"):]
+	require.True(t, strings.HasPrefix(body, `<w:body><w:p><w:r><w:t xml:space="preserve">接口规范</w:t></w:r>`), body)
+	require.Contains(t, body, "<w:tbl>")
+	require.Contains(t, body, ">Alice<")
+	require.Contains(t, body, `<w:p><w:pPr><w:sectPr w:rsidR="002E36B3"><w:pgSz w:w="11906" w:h="16838"/>`)
+	require.Equal(t, 1, strings.Count(body, "w:footerReference"))
+	require.Contains(t, body, `<w:footerReference w:type="default" r:id="rId6"/><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1800" w:bottom="1440" w:left="1800" w:header="851" w:footer="992" w:gutter="0"/><w:pgNumType w:start="1"/>`)
+}
+
+func TestConvertAddsWatermarkAndClassification(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	dst := filepath.Join(tmp, "a.docx")
+	require.NoError(t, os.WriteFile(src, []byte("---\nwatermark: 草稿\nclassification: 内部\n---\n# a\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Classification = "CONFIDENTIAL"
+	conv.Watermark = WatermarkOptions{Angle: 30, Color: "FF0000"}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.NoError(t, res.Error)
+
+	// 参考模板没有页眉页脚：默认页眉/页脚各新建一份。
+	header := readDocxPart(t, dst, "word/header1.xml")
+	require.Contains(t, header, `string="草稿"`)
+	require.Contains(t, header, `rotation:330;`)
+	require.Contains(t, header, `fillcolor="#FF0000"`)
+	require.Contains(t, header, `<v:fill opacity="0.5"/>`)
+	require.Contains(t, header, ">CONFIDENTIAL<")
+	require.NotContains(t, header, ">内部<")
+	require.Contains(t, readDocxPart(t, dst, "word/footer1.xml"), ">CONFIDENTIAL<")
+
+	doc := readDocxPart(t, dst, docx.PartDocument)
+	require.Equal(t, 1, strings.Count(doc, `<w:footerReference w:type="default" r:id="rId7"/><w:headerReference w:type="default" r:id="rId6"/>`))
+}
+
+func TestWatermarkOptionsValidate(t *testing.T) {
+	require.NoError(t, WatermarkOptions{Opacity: 0.5, Angle: 45, Color: "#C0C0C0"}.Validate())
+	require.NoError(t, WatermarkOptions{Color: "silver"}.Validate())
+	require.Error(t, WatermarkOptions{Opacity: 1.5}.Validate())
+	require.Error(t, WatermarkOptions{Angle: 400}.Validate())
+	require.Error(t, WatermarkOptions{Color: "#GG0000"}.Validate())
+}
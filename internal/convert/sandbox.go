@@ -0,0 +1,88 @@
+package convert
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// sandboxImageRe 匹配行内图片 ![说明](目标) 与引用式图片 ![说明][标签]、![标签][]、![标签]。
+	sandboxImageRe = regexp.MustCompile(`!\[((?:[^\]\\]|\\.)*)\](?:\(\s*(<[^>]*>|[^)\s]+)|\[([^\]]*)\])?`)
+	// sandboxDefinitionRe 匹配引用式链接定义 [标签]: 目标。
+	sandboxDefinitionRe = regexp.MustCompile(`^\s{0,3}\[([^\]]+)\]:\s*(<[^>]*>|\S+)`)
+)
+
+// checkSandboxImages 检查正文中的图片是否都位于 dir 之内（HTTP 服务为请求目录），防止通过图片把服务端文件嵌入返回的 docx。
+// 绝对路径须位于 dir 之内（被包含片段中的图片已改写为绝对路径）；相对路径不能以 ../ 跳出；
+// 带协议的地址（data: 除外）会让 pandoc 访问远程地址，同样不允许。代码块与行内代码中的内容不检查。
+func checkSandboxImages(src includedSource, dir string) error {
+	lines := strings.Split(src.body, "\n")
+	code := codeLines(lines)
+	definitions := make(map[string]int)
+	for i, line := range lines {
+		if m := sandboxDefinitionRe.FindStringSubmatch(line); m != nil && !code[i] {
+			definitions[strings.ToLower(strings.TrimSpace(m[1]))] = i
+		}
+	}
+	check := func(i int, raw string) error {
+		target := strings.TrimSuffix(strings.TrimPrefix(raw, "<"), ">")
+		if sandboxImageAllowed(target, dir) {
+			return nil
+		}
+		loc := src.lines[i]
+		where := filepath.Base(loc.path)
+		if rel, err := filepath.Rel(dir, loc.path); err == nil && within(dir, loc.path) {
+			where = filepath.ToSlash(rel)
+		}
+		return fmt.Errorf("%s 第 %d 行：图片超出请求内的文件：%s", where, loc.line, target)
+	}
+	for i, line := range lines {
+		if code[i] || !strings.Contains(line, "![") {
+			continue
+		}
+		var err error
+		mapOutsideCode(line, func(s string) string {
+			for _, m := range sandboxImageRe.FindAllStringSubmatch(s, -1) {
+				if err != nil {
+					break
+				}
+				if m[2] != "" {
+					err = check(i, m[2])
+					continue
+				}
+				label := m[3]
+				if label == "" {
+					label = m[1]
+				}
+				if def, ok := definitions[strings.ToLower(strings.TrimSpace(label))]; ok {
+					err = check(def, sandboxDefinitionRe.FindStringSubmatch(lines[def])[2])
+				}
+			}
+			return s
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sandboxImageAllowed 判断图片目标是否位于 dir 之内；目标按 URL 解码后判断。
+func sandboxImageAllowed(target, dir string) bool {
+	if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(strings.ToLower(target), "data:") {
+		return true
+	}
+	if urlSchemeRe.MatchString(target) || strings.HasPrefix(target, "//") {
+		return false
+	}
+	if decoded, err := url.PathUnescape(target); err == nil {
+		target = decoded
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return insideDir(dir, filepath.Clean(target))
+}
@@ -0,0 +1,56 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSandboxImages(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	writeFiles(t, dir, map[string]string{"img/a.png": "png", "shared/part.md": "![片段图](img/b.png)\n"})
+	writeFiles(t, outside, map[string]string{"secret.png": "png"})
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.png"), filepath.Join(dir, "img", "link.png")))
+	main := filepath.Join(dir, "a.md")
+
+	for body, want := range map[string]string{
+		"![a](img/a.png) ![b](<img/a b.png>) ![](data:image/png;base64,AA==)\n!include shared/part.md": "",
+		"`![](/etc/hostname)`\n```\n![](../../etc/passwd)\n```":                                        "",
+		"正文\n![](/etc/hostname)":                       "a.md 第 2 行：图片超出请求内的文件：/etc/hostname",
+		"![](../../etc/passwd)":                        "图片超出请求内的文件：../../etc/passwd",
+		"![](%2E%2E/%2E%2E/etc/passwd)":                "图片超出请求内的文件",
+		"![](img/link.png)":                            "图片超出请求内的文件：img/link.png",
+		"![](http://169.254.169.254/latest)":           "图片超出请求内的文件",
+		"![logo][l]\n\n[l]: /etc/hostname":             "a.md 第 3 行：图片超出请求内的文件：/etc/hostname",
+		"![l]\n\n[L]: </etc/hostname>":                 "图片超出请求内的文件：/etc/hostname",
+		"[home][l]\n\n[l]: /etc/hostname":              "",
+		"![](" + filepath.Join(dir, "img/a.png") + ")": "",
+	} {
+		inc, err := expandIncludes(main, body, 1, "", "")
+		require.NoError(t, err, body)
+		err = checkSandboxImages(inc, dir)
+		if want == "" {
+			require.NoError(t, err, body)
+			continue
+		}
+		require.ErrorContains(t, err, want, body)
+	}
+}
+
+func TestCiteprocForRejectsBibliographyOutsideSandbox(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"refs.bib": ""})
+	p := &PandocConverter{SandboxDir: dir}
+	task := job.Task{SourcePath: filepath.Join(dir, "a.md")}
+
+	_, err := p.citeprocFor(task, map[string]any{"bibliography": "refs.bib"})
+	require.NoError(t, err)
+	_, err = p.citeprocFor(task, map[string]any{"bibliography": "/etc/passwd"})
+	require.ErrorContains(t, err, "front matter 中的参考文献文件超出请求内的文件：/etc/passwd")
+	_, err = p.citeprocFor(task, map[string]any{"csl": "../x.csl"})
+	require.ErrorContains(t, err, "front matter 中的引用样式文件超出请求内的文件：../x.csl")
+}
@@ -0,0 +1,184 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const DiagnosticTableWidths = "table-widths"
+
+// tableWidthBookmarkBase 是列宽标记书签的起始 id，避开交叉引用书签。
+const tableWidthBookmarkBase = 200000
+
+const defaultTableBandFill = "F2F2F2"
+
+var (
+	// tableCaptionRe 匹配表格标题行：Table: 说明 {#tbl:id widths="30,70"} 或 : 说明 {...}，写在表格的上一行或下一行。
+	tableCaptionRe = regexp.MustCompile(`^\s{0,3}(?:Table)?:\s+(.*?)\s*\{([^{}]*)\}\s*$`)
+	tableAttrRe    = regexp.MustCompile(`#tbl:([A-Za-z0-9_-]+)|widths\s*=\s*(?:"([^"]*)"|(\S+))`)
+	// tableDelimiterRe 匹配管道表格的分隔行，如 |---|:---:|。
+	tableDelimiterRe = regexp.MustCompile(`^\s{0,3}\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	widthValueRe     = regexp.MustCompile(`^(\d+(?:\.\d+)?)%?$`)
+)
+
+// TableOptions 控制 pandoc 生成的表格的格式；零值保持 pandoc 的默认 Table 样式。
+type TableOptions struct {
+	// Style 为参考模板中的表格样式名（显示名或 styleId）。
+	Style string
+	// RepeatHeader 让表头行在跨页时重复显示。
+	RepeatHeader bool
+	// Banded 打开条带行；样式未定义条带格式时为隔行加浅灰底纹。
+	Banded bool
+}
+
+func (o TableOptions) isZero() bool {
+	return o == TableOptions{}
+}
+
+// tableCaption 是表格标题行的解析结果。
+type tableCaption struct {
+	text   string
+	label  string
+	widths string
+}
+
+// numbered 表示该标题行生成编号题注：带标签或有说明文字。
+func (c tableCaption) numbered() bool {
+	return c.label != "" || strings.TrimSpace(c.text) != ""
+}
+
+// parseTableCaption 解析表格标题行；属性块中只允许 #tbl:id 与 widths，至少含其一。
+func parseTableCaption(line string) (tableCaption, bool) {
+	m := tableCaptionRe.FindStringSubmatch(line)
+	if m == nil {
+		return tableCaption{}, false
+	}
+	c := tableCaption{text: m[1]}
+	for _, attr := range tableAttrRe.FindAllStringSubmatch(m[2], -1) {
+		switch {
+		case attr[1] != "":
+			c.label = attr[1]
+		case attr[2] != "":
+			c.widths = attr[2]
+		default:
+			c.widths = attr[3]
+		}
+	}
+	if strings.TrimSpace(tableAttrRe.ReplaceAllString(m[2], "")) != "" || c.label == "" && c.widths == "" {
+		return tableCaption{}, false
+	}
+	return c, true
+}
+
+// parseColumnWidths 解析以逗号或空格分隔的相对列宽（可带 %）。
+func parseColumnWidths(s string) ([]float64, bool) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || isSpace(byte(r)) })
+	if len(fields) == 0 {
+		return nil, false
+	}
+	out := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		m := widthValueRe.FindStringSubmatch(f)
+		if m == nil {
+			return nil, false
+		}
+		v, _ := strconv.ParseFloat(m[1], 64)
+		if v <= 0 {
+			return nil, false
+		}
+		out = append(out, v)
+	}
+	return out, true
+}
+
+// tableWidthHints 为带 widths 属性的表格标题行在相邻表格的表头第一个单元格中插入隐藏书签，
+// 后处理按书签找到对应表格设置列宽。返回书签名到相对列宽的映射。
+func tableWidthHints(task job.Task, src includedSource) (includedSource, map[string][]float64, []job.Diagnostic) {
+	lines := strings.Split(src.body, "\n")
+	code := codeLines(lines)
+	diags := make([]job.Diagnostic, 0)
+	report := func(line int, format string, args ...any) {
+		diags = append(diags, job.Diagnostic{
+			Source:   task.SourcePath,
+			Line:     line,
+			Severity: job.SeverityWarn,
+			Code:     DiagnosticTableWidths,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	isRow := func(i int) bool {
+		return i >= 0 && i < len(lines) && !code[i] && strings.Contains(lines[i], "|")
+	}
+
+	var widths map[string][]float64
+	for i, line := range lines {
+		if code[i] {
+			continue
+		}
+		c, ok := parseTableCaption(line)
+		if !ok || c.widths == "" {
+			continue
+		}
+		values, ok := parseColumnWidths(c.widths)
+		if !ok {
+			report(i+1, "表格列宽格式错误：%s（示例：widths=\"30,70\"）", c.widths)
+			continue
+		}
+		// 标题在表格上方时表头为下一行；在下方时向上找到表格的第一行。
+		header := -1
+		if isRow(i + 1) {
+			header = i + 1
+		} else if isRow(i - 1) {
+			header = i - 1
+			for isRow(header - 1) {
+				header--
+			}
+		}
+		if header < 0 || !isRow(header+1) || !tableDelimiterRe.MatchString(lines[header+1]) {
+			report(i+1, "表格列宽未找到相邻的表格，标题行须紧挨表格的上一行或下一行")
+			continue
+		}
+		columns := len(strings.Split(strings.Trim(strings.TrimSpace(lines[header+1]), "|"), "|"))
+		if columns != len(values) {
+			report(i+1, "表格列宽数量（%d）与表格列数（%d）不一致，已忽略", len(values), columns)
+			continue
+		}
+		if widths == nil {
+			widths = make(map[string][]float64)
+		}
+		n := len(widths) + 1
+		name := fmt.Sprintf("%s%d", docx.TableWidthMarkerPrefix, n)
+		widths[name] = values
+		marker := fmt.Sprintf("`<w:bookmarkStart w:id=\"%d\" w:name=\"%s\"/><w:bookmarkEnd w:id=\"%d\"/>`{=openxml}", tableWidthBookmarkBase+n, name, tableWidthBookmarkBase+n)
+		row := lines[header]
+		indent := len(row) - len(strings.TrimLeft(row, " "))
+		at := indent
+		if strings.HasPrefix(row[indent:], "|") {
+			at++
+		}
+		lines[header] = row[:at] + marker + row[at:]
+	}
+	return includedSource{body: strings.Join(lines, "\n"), lines: src.lines, files: src.files}, widths, src.locate(diags)
+}
+
+// tableFormat 返回写入 docx 的表格格式；没有需要修改的内容时返回 false。
+func (p *PandocConverter) tableFormat(pkg *docx.Package, widths map[string][]float64) (docx.TableFormat, []string, bool) {
+	o := p.Tables
+	if o.isZero() && len(widths) == 0 {
+		return docx.TableFormat{}, nil, false
+	}
+	var warnings []string
+	f := docx.TableFormat{RepeatHeader: o.RepeatHeader, Banded: o.Banded, BandFill: defaultTableBandFill, Widths: widths}
+	if style := strings.TrimSpace(o.Style); style != "" {
+		f.StyleID = pkg.StyleID(style)
+		if f.StyleID == "" {
+			warnings = append(warnings, fmt.Sprintf("参考模板中不存在表格样式：%s，已保留默认样式", style))
+		}
+	}
+	return f, warnings, true
+}
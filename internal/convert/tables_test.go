@@ -0,0 +1,73 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTableCaption(t *testing.T) {
+	c, ok := parseTableCaption(`Table: 性能对比 {#tbl:perf widths="20,30,50"}`)
+	require.True(t, ok)
+	require.Equal(t, tableCaption{text: "性能对比", label: "perf", widths: "20,30,50"}, c)
+
+	c, ok = parseTableCaption(`: {widths=1,3}`)
+	require.True(t, ok)
+	require.False(t, c.numbered())
+	require.Equal(t, "1,3", c.widths)
+
+	_, ok = parseTableCaption(`Table: 说明 {.wide}`)
+	require.False(t, ok)
+	_, ok = parseTableCaption(`Table: 说明`)
+	require.False(t, ok)
+}
+
+func TestTableWidthHintsMarksHeaderRow(t *testing.T) {
+	body := "Table: 接口 {#tbl:api widths=\"30%,70%\"}\n| 接口 | 说明 |\n|---|---|\n| a | b |\n\n" +
+		"  a | b | c\n  --|--|--\n  1 | 2 | 3\n: {widths=\"1 1 2\"}\n\n" +
+		"| x | y |\n|---|---|\nTable: 错配 {widths=\"1,2,3\"}\n\n" +
+		"Table: 孤立 {widths=\"1,2\"}\n\n" +
+		"Table: 格式 {widths=\"宽,窄\"}"
+	inc, err := expandIncludes("/abs/a.md", body, 1, "", "")
+	require.NoError(t, err)
+	out, widths, diags := tableWidthHints(job.Task{SourcePath: "/abs/a.md"}, inc)
+
+	require.Equal(t, map[string][]float64{"_TblW1": {30, 70}, "_TblW2": {1, 1, 2}}, widths)
+	lines := strings.Split(out.body, "\n")
+	require.Equal(t, "|`<w:bookmarkStart w:id=\"200001\" w:name=\"_TblW1\"/><w:bookmarkEnd w:id=\"200001\"/>`{=openxml} 接口 | 说明 |", lines[1])
+	require.Equal(t, "  `<w:bookmarkStart w:id=\"200002\" w:name=\"_TblW2\"/><w:bookmarkEnd w:id=\"200002\"/>`{=openxml}a | b | c", lines[5])
+	require.Equal(t, []job.Diagnostic{
+		{Source: "/abs/a.md", Line: 13, Severity: job.SeverityWarn, Code: DiagnosticTableWidths, Message: "表格列宽数量（3）与表格列数（2）不一致，已忽略"},
+		{Source: "/abs/a.md", Line: 15, Severity: job.SeverityWarn, Code: DiagnosticTableWidths, Message: "表格列宽未找到相邻的表格，标题行须紧挨表格的上一行或下一行"},
+		{Source: "/abs/a.md", Line: 17, Severity: job.SeverityWarn, Code: DiagnosticTableWidths, Message: "表格列宽格式错误：宽,窄（示例：widths=\"30,70\"）"},
+	}, diags)
+}
+
+func TestResolveCrossrefsNumbersUnlabeledTableCaptions(t *testing.T) {
+	body := "见 @tbl:b。\nTable: 第一张 {widths=\"1,1\"}\n| a | b |\n|---|---|\n\n: {widths=\"1,1\"}\n| c | d |\n|---|---|\n\nTable: 第二张 {#tbl:b}\n| e | f |\n|---|---|"
+	out, diags := resolveCrossrefBody(t, body)
+	require.Empty(t, diags)
+	require.NotContains(t, out, "widths")
+	require.Equal(t, 2, strings.Count(out, "TableCaption"))
+	// 未带标签的题注同样参与编号，引用显示为“表 2”。
+	require.Contains(t, out, `<w:t xml:space="preserve">表 2</w:t>`)
+	require.Contains(t, out, "\n| c | d |")
+}
+
+func TestConvertWarnsMissingTableStyle(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("| a |\n|---|\n| 1 |\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Tables = TableOptions{Style: "Grid Table 4", RepeatHeader: true}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(tmp, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Equal(t, []string{"参考模板中不存在表格样式：Grid Table 4，已保留默认样式"}, res.Warnings)
+}
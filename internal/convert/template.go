@@ -0,0 +1,277 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const (
+	DiagnosticUndefinedVariable = "undefined-variable"
+	DiagnosticTemplateSyntax    = "template-syntax"
+)
+
+// templateNameRe 是 {{ name }} 中合法的变量名；点号可访问 front matter 的嵌套字段。
+var templateNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// templateTermRe 匹配条件中的单个判断：[not|!] name [(==|!=) value]。
+var templateTermRe = regexp.MustCompile(`^(not\s+|!\s*)?([A-Za-z_][A-Za-z0-9_.-]*)\s*(?:(==|!=)\s*("[^"]*"|'[^']*'|\S+))?$`)
+
+// templateFrame 是一层 {{ if }} 条件块。
+type templateFrame struct {
+	line int
+	// active 表示当前分支的内容会输出；taken 表示已有分支命中；outer 表示外层是否输出。
+	active bool
+	taken  bool
+	outer  bool
+	inElse bool
+}
+
+// renderTemplate 替换正文中的 {{ name }} 变量并处理 {{ if }} / {{ else if }} / {{ else }} / {{ end }} 条件块。
+// 代码块与行内代码不参与处理；\{{ 输出字面的 {{。只含条件指令的行整行删除。
+// 返回的正文保留行号映射；未定义的变量与不配对的条件指令以 error 级诊断返回。
+func renderTemplate(task job.Task, src includedSource, lookup func(string) (string, bool)) (includedSource, []job.Diagnostic) {
+	out := includedSource{files: src.files, lines: make([]sourceLine, 0, len(src.lines))}
+	kept := make([]string, 0, len(src.lines))
+	diags := make([]job.Diagnostic, 0)
+	var stack []templateFrame
+	active := func() bool {
+		return len(stack) == 0 || stack[len(stack)-1].active
+	}
+	report := func(line int, code, format string, args ...any) {
+		diags = append(diags, job.Diagnostic{
+			Source:   task.SourcePath,
+			Line:     line,
+			Severity: job.SeverityError,
+			Code:     code,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	inFence := false
+	fenceChar := byte(0)
+	fenceLen := 0
+	for i, line := range strings.Split(src.body, "\n") {
+		lineNo := i + 1
+		if ch, n, ok := fenceMarker(strings.TrimSpace(line)); ok {
+			if !inFence {
+				inFence, fenceChar, fenceLen = true, ch, n
+				if active() {
+					kept = append(kept, line)
+					out.lines = append(out.lines, src.lines[i])
+				}
+				continue
+			}
+			if ch == fenceChar && n >= fenceLen {
+				inFence = false
+				if active() {
+					kept = append(kept, line)
+					out.lines = append(out.lines, src.lines[i])
+				}
+				continue
+			}
+		}
+		if inFence {
+			if active() {
+				kept = append(kept, line)
+				out.lines = append(out.lines, src.lines[i])
+			}
+			continue
+		}
+
+		var b strings.Builder
+		directive, text := false, false
+		for pos := 0; pos < len(line); pos++ {
+			c := line[pos]
+			switch {
+			case c == '`':
+				n := runLength(line[pos:], '`')
+				end := pos + n
+				if close := strings.Index(line[end:], strings.Repeat("`", n)); close >= 0 {
+					end += close + n
+				}
+				if active() {
+					b.WriteString(line[pos:end])
+					text = true
+				}
+				pos = end - 1
+			case c == '\\' && strings.HasPrefix(line[pos+1:], "{{"):
+				if active() {
+					b.WriteString("{{")
+					text = true
+				}
+				pos += 2
+			case strings.HasPrefix(line[pos:], "{{") && !strings.HasPrefix(line[pos:], "{{<"):
+				end := strings.Index(line[pos+2:], "}}")
+				if end < 0 {
+					if active() {
+						b.WriteString(line[pos:])
+						text = true
+					}
+					pos = len(line)
+					continue
+				}
+				raw := line[pos : pos+2+end+2]
+				inner := strings.TrimSpace(line[pos+2 : pos+2+end])
+				pos += 2 + end + 1
+				keyword, rest, _ := strings.Cut(inner, " ")
+				rest = strings.TrimSpace(rest)
+				if keyword == "else" && strings.HasPrefix(rest, "if ") {
+					keyword, rest = "elif", strings.TrimSpace(strings.TrimPrefix(rest, "if "))
+				}
+				switch keyword {
+				case "if":
+					directive = true
+					frame := templateFrame{line: lineNo, outer: active()}
+					if frame.outer {
+						frame.active = evalTemplateCondition(rest, lookup, func(code, format string, args ...any) {
+							report(lineNo, code, format, args...)
+						})
+						frame.taken = frame.active
+					}
+					stack = append(stack, frame)
+				case "elif", "else", "end", "endif":
+					directive = true
+					if len(stack) == 0 {
+						report(lineNo, DiagnosticTemplateSyntax, "%s 缺少对应的 {{ if }}", raw)
+						continue
+					}
+					top := &stack[len(stack)-1]
+					switch {
+					case keyword == "end" || keyword == "endif":
+						stack = stack[:len(stack)-1]
+					case top.inElse:
+						report(lineNo, DiagnosticTemplateSyntax, "%s 出现在 {{ else }} 之后", raw)
+					case keyword == "else":
+						top.inElse = true
+						top.active = top.outer && !top.taken
+						top.taken = top.taken || top.active
+					default:
+						top.active = false
+						if top.outer && !top.taken {
+							top.active = evalTemplateCondition(rest, lookup, func(code, format string, args ...any) {
+								report(lineNo, code, format, args...)
+							})
+							top.taken = top.active
+						}
+					}
+				default:
+					if !templateNameRe.MatchString(inner) || rest != "" {
+						// 不是变量名的 {{ ... }}（如其他模板语言的示例）原样保留。
+						if active() {
+							b.WriteString(raw)
+							text = true
+						}
+						continue
+					}
+					if !active() {
+						continue
+					}
+					text = true
+					val, ok := lookup(inner)
+					if !ok {
+						report(lineNo, DiagnosticUndefinedVariable, "模板变量未定义：{{ %s }}", inner)
+						continue
+					}
+					b.WriteString(val)
+				}
+			default:
+				if active() {
+					b.WriteByte(c)
+					text = text || !isSpace(c)
+				}
+			}
+		}
+		if directive && !text {
+			continue
+		}
+		if !directive && !active() {
+			continue
+		}
+		kept = append(kept, b.String())
+		out.lines = append(out.lines, src.lines[i])
+	}
+	for _, frame := range stack {
+		report(frame.line, DiagnosticTemplateSyntax, "{{ if }} 缺少对应的 {{ end }}")
+	}
+	out.body = strings.Join(kept, "\n")
+	return out, src.locate(diags)
+}
+
+// evalTemplateCondition 计算条件表达式：多个判断可用 and / or 连接（and 优先）。
+// 单独的变量名按真值判断（未定义、空串、false、0、no、off 为假）；== / != 的右侧为字面值，可加引号。
+func evalTemplateCondition(expr string, lookup func(string) (string, bool), report func(code, format string, args ...any)) bool {
+	if strings.TrimSpace(expr) == "" {
+		report(DiagnosticTemplateSyntax, "{{ if }} 缺少条件")
+		return false
+	}
+	result := false
+	for _, alt := range splitTemplateCondition(expr, "or") {
+		all := true
+		for _, term := range splitTemplateCondition(alt, "and") {
+			ok, valid := evalTemplateTerm(strings.TrimSpace(term), lookup, report)
+			if !valid {
+				return false
+			}
+			all = all && ok
+		}
+		result = result || all
+	}
+	return result
+}
+
+func evalTemplateTerm(term string, lookup func(string) (string, bool), report func(code, format string, args ...any)) (bool, bool) {
+	m := templateTermRe.FindStringSubmatch(term)
+	if m == nil {
+		report(DiagnosticTemplateSyntax, "条件表达式无效：%s", term)
+		return false, false
+	}
+	negate := m[1] != ""
+	val, defined := lookup(m[2])
+	if m[3] == "" {
+		truthy := defined
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "", "false", "0", "no", "off":
+			truthy = false
+		}
+		return truthy != negate, true
+	}
+	if !defined {
+		report(DiagnosticUndefinedVariable, "条件中的模板变量未定义：%s", m[2])
+		return false, false
+	}
+	want := m[4]
+	if len(want) >= 2 && (want[0] == '"' || want[0] == '\'') {
+		want = want[1 : len(want)-1]
+	}
+	equal := val == want
+	if m[3] == "!=" {
+		equal = !equal
+	}
+	return equal != negate, true
+}
+
+// splitTemplateCondition 按独立的 and / or 关键字拆分条件，引号内的内容不拆分。
+func splitTemplateCondition(expr, keyword string) []string {
+	parts := make([]string, 0, 1)
+	quote := byte(0)
+	start := 0
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case isSpace(c) && strings.HasPrefix(expr[i+1:], keyword) && len(expr) > i+1+len(keyword) && isSpace(expr[i+1+len(keyword)]):
+			parts = append(parts, expr[start:i])
+			i += len(keyword)
+			start = i + 1
+		}
+	}
+	return append(parts, expr[start:])
+}
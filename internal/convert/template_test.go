@@ -0,0 +1,129 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func templateLookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func renderTemplateBody(t *testing.T, body string, vars map[string]string) (string, []job.Diagnostic) {
+	t.Helper()
+	inc, err := expandIncludes("/abs/a.md", body, 1, "", "")
+	require.NoError(t, err)
+	out, diags := renderTemplate(job.Task{SourcePath: "/abs/a.md"}, inc, templateLookup(vars))
+	require.Len(t, out.lines, strings.Count(out.body, "\n")+1)
+	return out.body, diags
+}
+
+func TestRenderTemplateSubstitutesVariables(t *testing.T) {
+	body := "欢迎使用 {{ product }} {{version}}。\n" +
+		"`{{ product }}` 与 \\{{ product }} 保持原样，{{ .Values.x }} 也是。\n" +
+		"```\n{{ missing }}\n```"
+	out, diags := renderTemplateBody(t, body, map[string]string{"product": "ACME", "version": "2.0"})
+	require.Empty(t, diags)
+	require.Equal(t, "欢迎使用 ACME 2.0。\n"+
+		"`{{ product }}` 与 {{ product }} 保持原样，{{ .Values.x }} 也是。\n"+
+		"```\n{{ missing }}\n```", out)
+}
+
+func TestRenderTemplateConditionals(t *testing.T) {
+	body := "# 手册\n" +
+		"{{ if edition == \"pro\" }}\n" +
+		"## 专业版功能\n" +
+		"{{ else if edition == 'team' and seats != 0 }}\n" +
+		"## 团队版功能\n" +
+		"{{ else }}\n" +
+		"## 社区版\n" +
+		"{{ end }}\n" +
+		"价格：{{ if not trial }}{{ price }}{{ else }}免费{{ end }}\n" +
+		"{{ if beta }}\n测试功能\n{{ end }}\n" +
+		"结尾"
+	out, diags := renderTemplateBody(t, body, map[string]string{"edition": "team", "seats": "5", "price": "¥99"})
+	require.Empty(t, diags)
+	require.Equal(t, "# 手册\n## 团队版功能\n价格：¥99\n结尾", out)
+
+	out, diags = renderTemplateBody(t, body, map[string]string{"edition": "pro", "trial": "yes", "beta": "true"})
+	require.Empty(t, diags)
+	require.Equal(t, "# 手册\n## 专业版功能\n价格：免费\n测试功能\n结尾", out)
+}
+
+func TestRenderTemplateReportsErrorsWithLines(t *testing.T) {
+	body := "第一行\n{{ if edition == \"pro\" }}\n{{ customer }}\n{{ else }}\n{{ skipped }}\n{{ end }}\n{{ end }}\n{{ if plan == x }}\n{{ if ok }}"
+	_, diags := renderTemplateBody(t, body, map[string]string{"edition": "pro"})
+	require.Equal(t, []job.Diagnostic{
+		{Source: "/abs/a.md", Line: 3, Severity: job.SeverityError, Code: DiagnosticUndefinedVariable, Message: "模板变量未定义：{{ customer }}"},
+		{Source: "/abs/a.md", Line: 7, Severity: job.SeverityError, Code: DiagnosticTemplateSyntax, Message: "{{ end }} 缺少对应的 {{ if }}"},
+		{Source: "/abs/a.md", Line: 8, Severity: job.SeverityError, Code: DiagnosticUndefinedVariable, Message: "条件中的模板变量未定义：plan"},
+		{Source: "/abs/a.md", Line: 8, Severity: job.SeverityError, Code: DiagnosticTemplateSyntax, Message: "{{ if }} 缺少对应的 {{ end }}"},
+		{Source: "/abs/a.md", Line: 9, Severity: job.SeverityError, Code: DiagnosticTemplateSyntax, Message: "{{ if }} 缺少对应的 {{ end }}"},
+	}, diags)
+}
+
+func TestConvertFailsOnUndefinedTemplateVariable(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(src, []byte("---\nedition: pro\n---\n{{ if edition == \"pro\" }}\n客户：{{ customer }}\n{{ end }}\n"), 0o644))
+	called := false
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		called = true
+		return exec.CommandContext(ctx, "true")
+	}
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Template = true
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.ErrorContains(t, res.Error, "模板处理失败（1 处错误）：a.md 第 5 行：模板变量未定义：{{ customer }}")
+	require.False(t, called)
+	require.Len(t, res.Diagnostics, 1)
+	require.Equal(t, 5, res.Diagnostics[0].Line)
+
+	var prepared string
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		data, err := os.ReadFile(args[0])
+		require.NoError(t, err)
+		prepared = string(data)
+		return exec.CommandContext(ctx, "true")
+	}
+	conv.Vars = map[string]string{"customer": "ACME"}
+	res = conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Equal(t, "客户：ACME\n", prepared)
+}
+
+func TestConvertLeavesTemplateSyntaxWhenDisabled(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.md")
+	body := "Write {{ user }} in your template.\n{{ if x }}\n$\\mathbf{{x}}$\n"
+	require.NoError(t, os.WriteFile(src, []byte(body), 0o644))
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	var prepared string
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		data, err := os.ReadFile(args[0])
+		require.NoError(t, err)
+		prepared = string(data)
+		return exec.CommandContext(ctx, "true")
+	}
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Math = true
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: filepath.Join(dir, "a.docx")})
+	require.NoError(t, res.Error)
+	require.Empty(t, res.Diagnostics)
+	require.Contains(t, prepared, "Write {{ user }} in your template.\n{{ if x }}\n$\\mathbf{{x}}$")
+}
@@ -0,0 +1,19 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/validate"
+)
+
+// validateOutput 对输出 docx 做结构校验，问题以诊断返回（来源为 docx 路径）；存在 error 级问题时返回失败原因。
+func validateOutput(task job.Task) ([]job.Diagnostic, error) {
+	diags := validate.CheckFile(task.TargetPath)
+	for _, d := range diags {
+		if d.Severity == job.SeverityError {
+			return diags, fmt.Errorf("docx 校验失败：共 %d 个错误，首个为 %s", job.CountErrors(diags), d.Message)
+		}
+	}
+	return diags, nil
+}
@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/validate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertValidatesPostProcessedOutput(t *testing.T) {
+	useReferenceOutputPandoc(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	dst := filepath.Join(tmp, "a.docx")
+	require.NoError(t, os.WriteFile(src, []byte("# a\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Validate = true
+	conv.HeaderFooter = HeaderFooterOptions{Header: "{{title}}", Footer: "第 {{page}} 页"}
+	conv.Watermark = WatermarkOptions{Text: "内部资料"}
+	conv.Classification = "秘密"
+	conv.Page = PageOptions{Paper: "a4", Orientation: "landscape"}
+	conv.Notes = NoteOptions{Kind: NotesEndnotes, Format: "lower-roman"}
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.NoError(t, res.Error)
+	require.Empty(t, res.Diagnostics)
+}
+
+func TestConvertValidateFailsOnBrokenOutput(t *testing.T) {
+	orig := execCommandContext
+	t.Cleanup(func() { execCommandContext = orig })
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		script := `while [ "$1" != "-o" ]; do shift; done; printf 'not a zip' > "$2"`
+		return exec.CommandContext(ctx, "sh", append([]string{"-c", script, "pandoc"}, args...)...)
+	}
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "a.md")
+	dst := filepath.Join(tmp, "a.docx")
+	require.NoError(t, os.WriteFile(src, []byte("# a\n"), 0o644))
+
+	conv := NewPandocConverter("pandoc", "", false)
+	conv.Validate = true
+	res := conv.Convert(context.Background(), job.Task{SourcePath: src, TargetPath: dst})
+	require.ErrorContains(t, res.Error, "docx 校验失败：共 1 个错误，首个为 不是有效的 zip 文件")
+	require.False(t, job.IsTransient(res.Error))
+	require.Len(t, res.Diagnostics, 1)
+	require.Equal(t, validate.RuleZip, res.Diagnostics[0].Code)
+	require.Equal(t, dst, res.Diagnostics[0].Source)
+}
@@ -0,0 +1,62 @@
+package docx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// lineBreakRunRe 匹配 pandoc 在代码块中用于换行的 run（<w:r><w:br/></w:r>）。
+var lineBreakRunRe = regexp.MustCompile(`<w:r>\s*<w:br\s*/>\s*</w:r>`)
+
+// NumberCodeLines 在样式为 styleID 的代码块段落中为每一行加上行号，返回处理的代码块数。
+// 行号 run 使用 numberStyleID 字符样式（为空时使用灰色文字），宽度按代码块的总行数右对齐。
+func (p *Package) NumberCodeLines(styleID, numberStyleID string) (int, error) {
+	data, ok := p.Part(PartDocument)
+	if !ok {
+		return 0, errMissingPart(PartDocument)
+	}
+	styleRe := regexp.MustCompile(`<w:pStyle\s+w:val="` + regexp.QuoteMeta(styleID) + `"\s*/>`)
+	rPr := `<w:color w:val="808080"/>`
+	if numberStyleID != "" {
+		rPr = `<w:rStyle w:val="` + escapeXML(numberStyleID) + `"/>`
+	}
+	count := 0
+	content := paragraphRe.ReplaceAllStringFunc(string(data), func(para string) string {
+		if !styleRe.MatchString(para) {
+			return para
+		}
+		count++
+		lines := len(lineBreakRunRe.FindAllStringIndex(para, -1)) + 1
+		width := len(fmt.Sprint(lines))
+		number := func(n int) string {
+			return TextRun(fmt.Sprintf("%*d  ", width, n), rPr)
+		}
+		line := 1
+		para = lineBreakRunRe.ReplaceAllStringFunc(para, func(br string) string {
+			line++
+			return br + number(line)
+		})
+		if end := strings.Index(para, "</w:pPr>"); end >= 0 {
+			end += len("</w:pPr>")
+			return para[:end] + number(1) + para[end:]
+		}
+		open := strings.Index(para, ">") + 1
+		return para[:open] + number(1) + para[open:]
+	})
+	if count > 0 {
+		p.SetPart(PartDocument, []byte(content))
+	}
+	return count, nil
+}
+
+// MissingStyles 返回 names 中在 styles.xml 里找不到（按 styleId 或显示名）的样式。
+func (p *Package) MissingStyles(names ...string) []string {
+	missing := make([]string, 0)
+	for _, name := range names {
+		if p.StyleID(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
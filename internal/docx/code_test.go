@@ -0,0 +1,40 @@
+package docx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumberCodeLines(t *testing.T) {
+	code := `<w:p><w:pPr><w:pStyle w:val="SourceCode" /></w:pPr>`
+	for i := 1; i <= 10; i++ {
+		if i > 1 {
+			code += `<w:r><w:br /></w:r>`
+		}
+		code += `<w:r><w:rPr><w:rStyle w:val="NormalTok" /></w:rPr><w:t xml:space="preserve">line</w:t></w:r>`
+	}
+	code += `</w:p>`
+	doc := strings.Replace(testDocument, "<w:body>", "<w:body>"+code+`<w:p><w:r><w:t>text</w:t></w:r><w:r><w:br/></w:r></w:p>`, 1)
+	pkg := reopen(t, writeTestDocx(t, map[string]string{PartDocument: doc}))
+
+	n, err := pkg.NumberCodeLines("SourceCode", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	content := part(t, pkg, PartDocument)
+	require.Contains(t, content, `<w:pStyle w:val="SourceCode" /></w:pPr><w:r><w:rPr><w:color w:val="808080"/></w:rPr><w:t xml:space="preserve"> 1  </w:t></w:r><w:r><w:rPr><w:rStyle w:val="NormalTok" />`)
+	require.Contains(t, content, `<w:r><w:br /></w:r><w:r><w:rPr><w:color w:val="808080"/></w:rPr><w:t xml:space="preserve">10  </w:t></w:r>`)
+	require.Equal(t, 10, strings.Count(content, "808080"), "非代码段落不加行号")
+
+	n, err = pkg.NumberCodeLines("Missing", "LineNumber")
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestMissingStyles(t *testing.T) {
+	styles := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:style w:type="paragraph" w:styleId="a5"><w:name w:val="Source Code"/></w:style><w:style w:type="character" w:styleId="KeywordTok"><w:name w:val="KeywordTok"/></w:style></w:styles>`
+	pkg := reopen(t, writeTestDocx(t, map[string]string{PartStyles: styles}))
+	require.Equal(t, []string{"StringTok"}, pkg.MissingStyles("Source Code", "KeywordTok", "StringTok"))
+}
@@ -0,0 +1,299 @@
+package docx
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	RelTypeImage     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	RelTypeHyperlink = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+)
+
+// SectionBreakParagraph 是只承载分节符的空段落；页面设置由 FinishCoverSection 等后处理补齐。
+const SectionBreakParagraph = `<w:p><w:pPr><w:sectPr/></w:pPr></w:p>`
+
+var (
+	// PlaceholderRe 匹配 {name} 形式的占位符；名称须以字母或下划线开头，避免误伤 {#id}、{=openxml} 等 pandoc 语法。
+	PlaceholderRe = regexp.MustCompile(`\{([A-Za-z_][\w.-]*)\}`)
+
+	paragraphRe    = regexp.MustCompile(`(?s)<w:p(?:\s[^>]*)?/>|<w:p(?:\s[^>]*)?>.*?</w:p>`)
+	textNodeRe     = regexp.MustCompile(`(?s)(<w:t(?:\s[^>]*)?>)(.*?)(</w:t>)`)
+	bodyRe         = regexp.MustCompile(`(?s)<w:body>(.*)</w:body>`)
+	trailingSectRe = regexp.MustCompile(`(?s)<w:sectPr(?:\s[^>]*)?(?:/>|>.*?</w:sectPr>)\s*$`)
+	relAttrRe      = regexp.MustCompile(`\br:(embed|id|link|pict)="([^"]*)"`)
+	docPrIDRe      = regexp.MustCompile(`(<wp:docPr\s[^>]*\bid=")(\d+)(")`)
+	rootOpenRe     = regexp.MustCompile(`<w:document\s[^>]*>`)
+	nsDeclRe       = regexp.MustCompile(`xmlns:(\w+)="([^"]*)"`)
+)
+
+var imageContentTypes = map[string]string{
+	"png":  "image/png",
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+	"gif":  "image/gif",
+	"bmp":  "image/bmp",
+	"tif":  "image/tiff",
+	"tiff": "image/tiff",
+	"svg":  "image/svg+xml",
+	"emf":  "image/x-emf",
+	"wmf":  "image/x-wmf",
+}
+
+// FillText 替换文本中的 {name} 占位符，返回结果与未定义的占位符名。
+func FillText(text string, lookup func(string) (string, bool)) (string, []string) {
+	undefined := make([]string, 0)
+	out := PlaceholderRe.ReplaceAllStringFunc(text, func(m string) string {
+		name := m[1 : len(m)-1]
+		if v, ok := lookup(name); ok {
+			return v
+		}
+		undefined = append(undefined, name)
+		return ""
+	})
+	return out, undefined
+}
+
+// FillPlaceholders 替换 WordprocessingML 中各段落的 {name} 占位符；占位符可以跨越多个 run。
+// block 非空时，整段只含一个占位符的段落可被 block 返回的块级 XML（如表格）整体替换。
+func FillPlaceholders(content string, lookup func(string) (string, bool), block func(string) (string, bool)) (string, []string) {
+	undefined := make([]string, 0)
+	out := paragraphRe.ReplaceAllStringFunc(content, func(para string) string {
+		nodes := textNodeRe.FindAllStringSubmatchIndex(para, -1)
+		if len(nodes) == 0 {
+			return para
+		}
+		texts := make([]string, len(nodes))
+		var full strings.Builder
+		for i, n := range nodes {
+			texts[i] = unescapeXML(para[n[4]:n[5]])
+			full.WriteString(texts[i])
+		}
+		joined := full.String()
+		if block != nil {
+			if m := PlaceholderRe.FindStringSubmatch(strings.TrimSpace(joined)); m != nil && m[0] == strings.TrimSpace(joined) {
+				if xml, ok := block(m[1]); ok {
+					return xml
+				}
+			}
+		}
+		matches := PlaceholderRe.FindAllStringSubmatchIndex(joined, -1)
+		if len(matches) == 0 {
+			return para
+		}
+
+		// 替换值写入占位符起始所在的文本节点，占位符其余字符从各节点中删除。
+		replaced := make([]strings.Builder, len(nodes))
+		node, nodeEnd := 0, len(texts[0])
+		next := 0
+		for pos := 0; pos < len(joined); pos++ {
+			for pos >= nodeEnd && node < len(nodes)-1 {
+				node++
+				nodeEnd += len(texts[node])
+			}
+			if next < len(matches) && pos >= matches[next][0] {
+				m := matches[next]
+				if pos == m[0] {
+					name := joined[m[2]:m[3]]
+					if v, ok := lookup(name); ok {
+						replaced[node].WriteString(v)
+					} else {
+						undefined = append(undefined, name)
+					}
+				}
+				if pos == m[1]-1 {
+					next++
+				}
+				continue
+			}
+			replaced[node].WriteByte(joined[pos])
+		}
+
+		var b strings.Builder
+		last := 0
+		for i, n := range nodes {
+			b.WriteString(para[last:n[0]])
+			open := para[n[2]:n[3]]
+			if !strings.Contains(open, "xml:space=") {
+				open = strings.TrimSuffix(open, ">") + ` xml:space="preserve">`
+			}
+			b.WriteString(open + EscapeText(replaced[i].String()) + "</w:t>")
+			last = n[1]
+		}
+		b.WriteString(para[last:])
+		return b.String()
+	})
+	return out, undefined
+}
+
+// Table 生成带表头行的简单表格；styleID 为空时仅使用单线边框。
+func Table(headers []string, rows [][]string, styleID string) string {
+	var b strings.Builder
+	b.WriteString("<w:tbl><w:tblPr>")
+	if styleID != "" {
+		b.WriteString(`<w:tblStyle w:val="` + escapeXML(styleID) + `"/>`)
+	}
+	b.WriteString(`<w:tblW w:w="5000" w:type="pct"/><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		b.WriteString(`<w:` + side + ` w:val="single" w:sz="4" w:space="0" w:color="auto"/>`)
+	}
+	b.WriteString(`</w:tblBorders><w:tblLook w:val="04A0" w:firstRow="1" w:lastRow="0" w:firstColumn="0" w:lastColumn="0" w:noHBand="0" w:noVBand="1"/></w:tblPr><w:tblGrid>`)
+	for range headers {
+		b.WriteString("<w:gridCol/>")
+	}
+	b.WriteString("</w:tblGrid>")
+	writeRow := func(cells []string, header bool) {
+		b.WriteString("<w:tr>")
+		if header {
+			b.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for i := range headers {
+			text := ""
+			if i < len(cells) {
+				text = cells[i]
+			}
+			rPr := ""
+			if header {
+				rPr = "<w:b/>"
+			}
+			b.WriteString("<w:tc><w:p>" + TextRun(text, rPr) + "</w:p></w:tc>")
+		}
+		b.WriteString("</w:tr>")
+	}
+	writeRow(headers, true)
+	for _, row := range rows {
+		writeRow(row, false)
+	}
+	b.WriteString("</w:tbl>")
+	return b.String()
+}
+
+// PrependFragment 把 fragment 的正文（不含末尾 sectPr）插入到正文开头并在其后分节。
+// 片段引用的图片与超链接关系会一并复制；fill 可在插入前改写片段 XML（如替换占位符）。
+func (p *Package) PrependFragment(fragment *Package, fill func(string) string) error {
+	data, ok := fragment.Part(PartDocument)
+	if !ok {
+		return errMissingPart(PartDocument)
+	}
+	m := bodyRe.FindStringSubmatch(string(data))
+	if m == nil {
+		return fmt.Errorf("封面 docx 缺少 w:body")
+	}
+	body := trailingSectRe.ReplaceAllString(m[1], "")
+
+	rels := map[string]Relationship{}
+	for _, r := range fragment.Relationships(RelsPartFor(PartDocument)) {
+		rels[r.ID] = r
+	}
+	mapped := map[string]string{}
+	var relErr error
+	body = relAttrRe.ReplaceAllStringFunc(body, func(attr string) string {
+		sub := relAttrRe.FindStringSubmatch(attr)
+		oldID := sub[2]
+		if newID, ok := mapped[oldID]; ok {
+			return "r:" + sub[1] + `="` + newID + `"`
+		}
+		newID, err := p.copyRelationship(fragment, rels[oldID], oldID)
+		if err != nil {
+			relErr = err
+			return attr
+		}
+		mapped[oldID] = newID
+		return "r:" + sub[1] + `="` + newID + `"`
+	})
+	if relErr != nil {
+		return relErr
+	}
+	// 片段中的绘图对象 id 可能与正文重复，统一加上偏移量。
+	body = docPrIDRe.ReplaceAllStringFunc(body, func(s string) string {
+		sub := docPrIDRe.FindStringSubmatch(s)
+		n, _ := strconv.Atoi(sub[2])
+		return sub[1] + strconv.Itoa(n+100000) + sub[3]
+	})
+	if fill != nil {
+		body = fill(body)
+	}
+
+	doc, ok := p.Part(PartDocument)
+	if !ok {
+		return errMissingPart(PartDocument)
+	}
+	content := string(doc)
+	if root := rootOpenRe.FindString(string(data)); root != "" {
+		for _, ns := range nsDeclRe.FindAllStringSubmatch(root, -1) {
+			content = ensureNamespace(content, "w:document", ns[1], ns[2])
+		}
+	}
+	idx := strings.Index(content, "<w:body>")
+	if idx < 0 {
+		return fmt.Errorf("docx 缺少 w:body")
+	}
+	idx += len("<w:body>")
+	content = content[:idx] + body + SectionBreakParagraph + content[idx:]
+	p.SetPart(PartDocument, []byte(content))
+	return nil
+}
+
+func (p *Package) copyRelationship(fragment *Package, rel Relationship, oldID string) (string, error) {
+	if rel.ID == "" {
+		return "", fmt.Errorf("封面 docx 引用了不存在的关系：%s", oldID)
+	}
+	if rel.TargetMode == "External" {
+		return p.AddExternalRelationship(PartDocumentRels, rel.Type, rel.Target), nil
+	}
+	if rel.Type != RelTypeImage {
+		return "", fmt.Errorf("封面 docx 包含不支持的内容（关系类型 %s）", path.Base(rel.Type))
+	}
+	src := ResolveTarget(PartDocument, rel.Target)
+	data, ok := fragment.Part(src)
+	if !ok {
+		return "", errMissingPart(src)
+	}
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(src)), ".")
+	name := "word/media/cover-" + path.Base(src)
+	for i := 2; p.Has(name); i++ {
+		name = fmt.Sprintf("word/media/cover-%d-%s", i, path.Base(src))
+	}
+	p.SetPart(name, data)
+	if ct, ok := imageContentTypes[ext]; ok {
+		p.EnsureDefault(ext, ct)
+	}
+	return p.AddRelationship(PartDocumentRels, RelTypeImage, strings.TrimPrefix(name, "word/")), nil
+}
+
+// FinishCoverSection 补齐第一个分节符（封面节）的页面设置并去掉其页眉页脚引用，正文节页码从 1 开始。
+func (p *Package) FinishCoverSection() error {
+	data, ok := p.Part(PartDocument)
+	if !ok {
+		return errMissingPart(PartDocument)
+	}
+	sects := sectPrRe.FindAllString(string(data), -1)
+	if len(sects) < 2 {
+		return nil
+	}
+	page := sects[len(sects)-1]
+	for _, name := range []string{"w:headerReference", "w:footerReference", "w:titlePg", "w:pgNumType"} {
+		page = RemoveElement(page, name, nil)
+	}
+	return p.EditSections(func(index int, sectPr string) string {
+		switch index {
+		case 0:
+			return page
+		case 1:
+			return setPageNumberStart(sectPr, 1)
+		}
+		return sectPr
+	})
+}
+
+func setPageNumberStart(sectPr string, start int) string {
+	re := regexp.MustCompile(`<w:pgNumType(\s[^>]*?)?\s*/>`)
+	if m := re.FindStringSubmatch(sectPr); m != nil {
+		attrsPart := regexp.MustCompile(`\s*w:start="[^"]*"`).ReplaceAllString(m[1], "")
+		return strings.Replace(sectPr, m[0], fmt.Sprintf(`<w:pgNumType%s w:start="%d"/>`, attrsPart, start), 1)
+	}
+	return SetSectionChild(sectPr, "w:pgNumType", fmt.Sprintf(`<w:pgNumType w:start="%d"/>`, start))
+}
@@ -0,0 +1,72 @@
+package docx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFillPlaceholdersAcrossRuns(t *testing.T) {
+	in := `<w:p><w:r><w:t>Title: {ti</w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>tle} v{version}</w:t></w:r></w:p><w:p><w:r><w:t>{missing}</w:t></w:r></w:p><w:p><w:r><w:t xml:space="preserve"> {table} </w:t></w:r></w:p>`
+	lookup := func(name string) (string, bool) {
+		switch name {
+		case "title":
+			return "A & B", true
+		case "version":
+			return "2", true
+		}
+		return "", false
+	}
+	block := func(name string) (string, bool) {
+		return "<w:tbl/>", name == "table"
+	}
+	out, undefined := FillPlaceholders(in, lookup, block)
+	require.Equal(t, `<w:p><w:r><w:t xml:space="preserve">Title: A &amp; B</w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve"> v2</w:t></w:r></w:p><w:p><w:r><w:t xml:space="preserve"></w:t></w:r></w:p><w:tbl/>`, out)
+	require.Equal(t, []string{"missing"}, undefined)
+}
+
+func TestFillTextKeepsPandocAttributes(t *testing.T) {
+	out, undefined := FillText("# {title} {#cover}\n```{=openxml}\n", func(string) (string, bool) { return "T", true })
+	require.Equal(t, "# T {#cover}\n```{=openxml}\n", out)
+	require.Empty(t, undefined)
+}
+
+func TestPrependFragmentCopiesImagesAndFinishesCoverSection(t *testing.T) {
+	fragmentDoc := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"><w:body><w:p><w:r><w:t>{title}</w:t></w:r></w:p><w:p><w:r><w:drawing><wp:inline><wp:docPr id="1" name="logo"/><a:blip r:embed="rId7"/></wp:inline></w:drawing></w:r><w:hyperlink r:id="rId8"><w:r><w:t>site</w:t></w:r></w:hyperlink></w:p><w:sectPr><w:pgSz w:w="1" w:h="1"/></w:sectPr></w:body></w:document>`
+	fragmentRels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId7" Type="` + RelTypeImage + `" Target="media/image1.png"/><Relationship Id="rId8" Type="` + RelTypeHyperlink + `" Target="https://example.com" TargetMode="External"/></Relationships>`
+	fragment := reopen(t, writeTestDocx(t, map[string]string{
+		PartDocument:              fragmentDoc,
+		RelsPartFor(PartDocument): fragmentRels,
+		"word/media/image1.png":   "PNG",
+	}))
+
+	doc := strings.Replace(testDocument, "<w:sectPr>", `<w:sectPr><w:headerReference w:type="default" r:id="rId5"/>`, 1)
+	path := writeTestDocx(t, map[string]string{PartDocument: doc})
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.PrependFragment(fragment, func(body string) string {
+		out, _ := FillPlaceholders(body, func(string) (string, bool) { return "Cover", true }, nil)
+		return out
+	}))
+	require.NoError(t, pkg.FinishCoverSection())
+	require.NoError(t, pkg.Save())
+
+	pkg = reopen(t, path)
+	got := part(t, pkg, PartDocument)
+	require.Contains(t, got, `xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"`)
+	require.True(t, strings.HasPrefix(got[strings.Index(got, "<w:body>"):], `<w:body><w:p><w:r><w:t xml:space="preserve">Cover</w:t>`))
+	require.Contains(t, got, `<wp:docPr id="100001" name="logo"/><a:blip r:embed="rId1"/>`)
+	require.Contains(t, got, `<w:hyperlink r:id="rId2">`)
+	require.NotContains(t, got, `w:w="1"`)
+	// 封面节复制正文页面设置但不带页眉；正文节页码从 1 开始。
+	require.Contains(t, got, `<w:p><w:pPr><w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar`)
+	require.Equal(t, 1, strings.Count(got, "headerReference"))
+	require.Contains(t, got, `<w:pgNumType w:start="1"/></w:sectPr></w:body>`)
+
+	require.Equal(t, "PNG", part(t, pkg, "word/media/cover-image1.png"))
+	require.Contains(t, part(t, pkg, PartContentTypes), `<Default Extension="png" ContentType="image/png"/>`)
+	rels := part(t, pkg, PartDocumentRels)
+	require.Contains(t, rels, `Id="rId2" Type="`+RelTypeHyperlink+`" Target="https://example.com" TargetMode="External"`)
+}
@@ -0,0 +1,174 @@
+package docx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	PartFontTable = "word/fontTable.xml"
+
+	RelTypeFontTable     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/fontTable"
+	RelTypeFont          = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/font"
+	RelTypeTheme         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme"
+	ContentTypeFontTable = "application/vnd.openxmlformats-officedocument.wordprocessingml.fontTable+xml"
+	ContentTypeFont      = "application/vnd.openxmlformats-officedocument.obfuscatedFont"
+)
+
+// 嵌入字体的字形，对应 w:embedRegular 等元素。
+const (
+	FontRegular    = "Regular"
+	FontBold       = "Bold"
+	FontItalic     = "Italic"
+	FontBoldItalic = "BoldItalic"
+)
+
+// fontOrder 是 w:font 子元素在 schema 中的顺序。
+var fontOrder = []string{
+	"w:altName", "w:panose1", "w:charset", "w:family", "w:notTrueType", "w:pitch", "w:sig",
+	"w:embedRegular", "w:embedBold", "w:embedItalic", "w:embedBoldItalic",
+}
+
+var (
+	rFontsRe      = regexp.MustCompile(`<w:rFonts\s[^>]*>`)
+	themeFontRe   = regexp.MustCompile(`(?s)<a:(major|minor)Font>(.*?)</a:(?:major|minor)Font>`)
+	themeFaceRe   = regexp.MustCompile(`<a:(latin|ea|cs)\s[^>]*typeface="([^"]*)"`)
+	themeHansRe   = regexp.MustCompile(`<a:font\s+script="Hans"\s+typeface="([^"]*)"`)
+	fontPartRe    = regexp.MustCompile(`^word/(?:document|styles|numbering|footnotes|endnotes|header\d*|footer\d*)\.xml$`)
+	fontNameAttr  = []string{"w:ascii", "w:hAnsi", "w:eastAsia", "w:cs"}
+	fontThemeAttr = []string{"w:asciiTheme", "w:hAnsiTheme", "w:eastAsiaTheme", "w:cstheme"}
+)
+
+// FontFile 是待嵌入的一个字形的字体数据（TrueType）。
+type FontFile struct {
+	// Style 为 FontRegular、FontBold、FontItalic 或 FontBoldItalic。
+	Style string
+	Data  []byte
+}
+
+// UsedFonts 返回样式、正文、页眉页脚与脚注尾注中引用的字体名，主题字体解析为主题中的实际字体。
+func (p *Package) UsedFonts() []string {
+	theme := p.themeFonts()
+	seen := map[string]bool{}
+	for _, name := range p.PartNames() {
+		if !fontPartRe.MatchString(name) {
+			continue
+		}
+		data, _ := p.Part(name)
+		for _, tag := range rFontsRe.FindAllString(string(data), -1) {
+			a := attrs(tag)
+			for _, attr := range fontNameAttr {
+				if v := strings.TrimSpace(a[attr]); v != "" {
+					seen[v] = true
+				}
+			}
+			for _, attr := range fontThemeAttr {
+				if v := theme[a[attr]]; v != "" {
+					seen[v] = true
+				}
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for name := range seen {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// themeFonts 把主题字体引用（如 minorHAnsi、majorEastAsia）映射为主题中的字体名；
+// 东亚字体为空时取简体中文（Hans）脚本的字体。
+func (p *Package) themeFonts() map[string]string {
+	out := map[string]string{}
+	part := ""
+	for _, rel := range p.Relationships(PartDocumentRels) {
+		if rel.Type == RelTypeTheme {
+			part = ResolveTarget(PartDocument, rel.Target)
+		}
+	}
+	data, ok := p.Part(part)
+	if !ok {
+		return out
+	}
+	for _, m := range themeFontRe.FindAllStringSubmatch(string(data), -1) {
+		kind, body := m[1], m[2]
+		faces := map[string]string{}
+		for _, f := range themeFaceRe.FindAllStringSubmatch(body, -1) {
+			faces[f[1]] = unescapeXML(f[2])
+		}
+		if faces["ea"] == "" {
+			if h := themeHansRe.FindStringSubmatch(body); h != nil {
+				faces["ea"] = unescapeXML(h[1])
+			}
+		}
+		out[kind+"Ascii"] = faces["latin"]
+		out[kind+"HAnsi"] = faces["latin"]
+		out[kind+"EastAsia"] = faces["ea"]
+		out[kind+"Bidi"] = faces["cs"]
+	}
+	return out
+}
+
+// EmbedFont 把字体数据混淆后嵌入文档，并在字体表中为 name 登记；文档设置同时打开“将字体嵌入文件”。
+func (p *Package) EmbedFont(name string, files []FontFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	table, ok := p.Part(PartFontTable)
+	if !ok {
+		table = []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:fonts xmlns:w="%s" xmlns:r="%s"></w:fonts>`, NamespaceW, NamespaceR))
+		p.EnsureOverride(PartFontTable, ContentTypeFontTable)
+		p.AddRelationship(PartDocumentRels, RelTypeFontTable, "fontTable.xml")
+	}
+	content := ensureNamespace(string(table), "w:fonts", "r", NamespaceR)
+	font := fontEntry(content, name)
+	if font == "" {
+		font = `<w:font w:name="` + escapeXML(name) + `"></w:font>`
+		content = insertBeforeClose(content, "w:fonts", font)
+	}
+	entry := expandEmpty(font, "w:font")
+	p.EnsureDefault("odttf", ContentTypeFont)
+	for _, f := range files {
+		key := fontKey(f.Data)
+		part := ""
+		for i := 1; part == "" || p.Has(part); i++ {
+			part = fmt.Sprintf("word/fonts/font%d.odttf", i)
+		}
+		p.SetPart(part, obfuscateFont(f.Data, key))
+		id := p.AddRelationship(RelsPartFor(PartFontTable), RelTypeFont, strings.TrimPrefix(part, "word/"))
+		element := "w:embed" + f.Style
+		entry = setOrderedChild(entry, "w:font", element, fmt.Sprintf(`<%s r:id="%s" w:fontKey="%s"/>`, element, id, key), fontOrder)
+	}
+	p.SetPart(PartFontTable, []byte(strings.Replace(content, font, entry, 1)))
+	p.SetSetting("w:embedTrueTypeFonts", "<w:embedTrueTypeFonts/>")
+	return nil
+}
+
+// fontEntry 返回字体表中名为 name 的 w:font 元素；不存在时返回空串。
+func fontEntry(content, name string) string {
+	re := regexp.MustCompile(`(?s)<w:font\s+w:name="` + regexp.QuoteMeta(escapeXML(name)) + `"\s*/>|<w:font\s+w:name="` + regexp.QuoteMeta(escapeXML(name)) + `"\s*>.*?</w:font>`)
+	return re.FindString(content)
+}
+
+// fontKey 由字体数据生成确定的 GUID，同一字体在多次输出中保持一致。
+func fontKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	h := strings.ToUpper(hex.EncodeToString(sum[:16]))
+	return "{" + h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32] + "}"
+}
+
+// obfuscateFont 按 ECMA-376 的字体混淆规则，用 GUID 的逆序字节异或字体数据的前 32 字节。
+func obfuscateFont(data []byte, key string) []byte {
+	raw, _ := hex.DecodeString(strings.NewReplacer("{", "", "}", "", "-", "").Replace(key))
+	out := append([]byte(nil), data...)
+	for i := 0; i < 32 && i < len(out); i++ {
+		out[i] ^= raw[15-i%16]
+	}
+	return out
+}
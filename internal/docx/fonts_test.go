@@ -0,0 +1,72 @@
+package docx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testFontTheme = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><a:themeElements><a:fontScheme name="x">` +
+	`<a:majorFont><a:latin typeface="Major Latin"/><a:ea typeface=""/><a:cs typeface=""/><a:font script="Hans" typeface="等线 Light"/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Minor Latin"/><a:ea typeface="Minor EA"/><a:cs typeface=""/></a:minorFont></a:fontScheme></a:themeElements></a:theme>`
+
+func TestUsedFontsResolvesThemeFonts(t *testing.T) {
+	styles := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:asciiTheme="minorHAnsi" w:eastAsiaTheme="minorEastAsia" w:hAnsiTheme="minorHAnsi" w:cstheme="minorBidi"/></w:rPr></w:rPrDefault></w:docDefaults>` +
+		`<w:style w:type="paragraph" w:styleId="Heading1"><w:rPr><w:rFonts w:asciiTheme="majorHAnsi" w:eastAsiaTheme="majorEastAsia"/></w:rPr></w:style></w:styles>`
+	doc := strings.Replace(testDocument, "<w:r>", `<w:r><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:eastAsia="宋体"/></w:rPr>`, 1)
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="` + RelTypeTheme + `" Target="theme/theme1.xml"/></Relationships>`
+	pkg := reopen(t, writeTestDocx(t, map[string]string{
+		PartDocument:            doc,
+		PartDocumentRels:        rels,
+		PartStyles:              styles,
+		"word/theme/theme1.xml": testFontTheme,
+	}))
+
+	require.Equal(t, []string{"Consolas", "Major Latin", "Minor EA", "Minor Latin", "宋体", "等线 Light"}, pkg.UsedFonts())
+}
+
+func TestEmbedFont(t *testing.T) {
+	table := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:fonts xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:font w:name="Acme"><w:panose1 w:val="02020603050405020304"/><w:sig w:usb0="0"/></w:font></w:fonts>`
+	pkg := reopen(t, writeTestDocx(t, map[string]string{PartFontTable: table}))
+	regular := bytes.Repeat([]byte{0xAB}, 64)
+	bold := bytes.Repeat([]byte{0xCD}, 64)
+
+	require.NoError(t, pkg.EmbedFont("Acme", []FontFile{{Style: FontRegular, Data: regular}, {Style: FontBold, Data: bold}}))
+	require.NoError(t, pkg.EmbedFont("宋体", []FontFile{{Style: FontRegular, Data: regular}}))
+	require.NoError(t, pkg.Save())
+	pkg = reopen(t, pkg.Path())
+
+	got := part(t, pkg, PartFontTable)
+	key := fontKey(regular)
+	require.Contains(t, got, `xmlns:r="`+NamespaceR+`"`)
+	require.Contains(t, got, `<w:sig w:usb0="0"/><w:embedRegular r:id="rId1" w:fontKey="`+key+`"/><w:embedBold r:id="rId2" w:fontKey="`+fontKey(bold)+`"/></w:font>`)
+	require.Contains(t, got, `<w:font w:name="宋体"><w:embedRegular r:id="rId3" w:fontKey="`+key+`"/></w:font>`)
+
+	rels := pkg.Relationships(RelsPartFor(PartFontTable))
+	require.Len(t, rels, 3)
+	require.Equal(t, "fonts/font1.odttf", rels[0].Target)
+	require.Equal(t, "fonts/font3.odttf", rels[2].Target)
+
+	stored, ok := pkg.Part("word/fonts/font1.odttf")
+	require.True(t, ok)
+	require.NotEqual(t, regular[:32], stored[:32])
+	require.Equal(t, regular[32:], stored[32:])
+	require.Equal(t, regular, obfuscateFont(stored, key))
+
+	require.Contains(t, part(t, pkg, PartContentTypes), `<Default Extension="odttf" ContentType="`+ContentTypeFont+`"/>`)
+	require.Contains(t, part(t, pkg, PartSettings), "<w:embedTrueTypeFonts/>")
+}
+
+func TestEmbedFontCreatesFontTable(t *testing.T) {
+	pkg := reopen(t, writeTestDocx(t, nil))
+	require.NoError(t, pkg.EmbedFont("Acme", []FontFile{{Style: FontItalic, Data: []byte("font")}}))
+	require.Contains(t, part(t, pkg, PartFontTable), `<w:font w:name="Acme"><w:embedItalic r:id="rId1"`)
+	require.Contains(t, part(t, pkg, PartDocumentRels), `Target="fontTable.xml"`)
+	require.Contains(t, part(t, pkg, PartContentTypes), ContentTypeFontTable)
+}
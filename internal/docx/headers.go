@@ -0,0 +1,154 @@
+package docx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 页眉页脚类型，对应 w:headerReference/w:footerReference 的 w:type。
+const (
+	HeaderFooterDefault = "default"
+	HeaderFooterFirst   = "first"
+	HeaderFooterEven    = "even"
+)
+
+// Run 是页眉页脚中的一段内容：Text 为文本，Field 非空时为域代码（如 PAGE、NUMPAGES）。
+type Run struct {
+	Text  string
+	Field string
+}
+
+// HeaderFooter 描述一个页眉或页脚。Segments 为 1 段时居中；2 段时左右对齐；3 段时左中右对齐。
+type HeaderFooter struct {
+	Footer   bool
+	Type     string
+	Segments [][]Run
+	// SkipSections 为开头不设置页眉页脚的节数（如封面节）。
+	SkipSections int
+}
+
+// SetHeaderFooter 新建页眉/页脚部件并让所有节引用它，替换同类型的原有引用。
+// first 类型会为各节打开“首页不同”，even 类型会打开文档级“奇偶页不同”。
+func (p *Package) SetHeaderFooter(hf HeaderFooter) error {
+	if !p.Has(PartDocument) {
+		return errMissingPart(PartDocument)
+	}
+	kind := headerFooterKind(hf.Footer)
+	typ := hf.Type
+	if typ == "" {
+		typ = HeaderFooterDefault
+	}
+
+	paragraph := headerFooterParagraph(hf.Segments, p.StyleID(kind), p.TextWidth())
+	id := p.addHeaderFooterPart(hf.Footer, paragraph)
+
+	refName := "w:" + kind + "Reference"
+	ref := fmt.Sprintf(`<%s w:type="%s" r:id="%s"/>`, refName, typ, id)
+	typeAttr := regexp.MustCompile(`w:type="` + regexp.QuoteMeta(typ) + `"`)
+	err := p.EditSections(func(index int, sectPr string) string {
+		if index < hf.SkipSections {
+			return sectPr
+		}
+		sectPr = RemoveElement(sectPr, refName, func(el string) bool { return typeAttr.MatchString(el) })
+		sectPr = insertAfterOpen(sectPr, "w:sectPr", ref)
+		if typ == HeaderFooterFirst {
+			sectPr = SetSectionChild(sectPr, "w:titlePg", "<w:titlePg/>")
+		}
+		return sectPr
+	})
+	if err != nil {
+		return err
+	}
+	if typ == HeaderFooterEven {
+		p.SetSetting("w:evenAndOddHeaders", "<w:evenAndOddHeaders/>")
+	}
+	return nil
+}
+
+func headerFooterKind(footer bool) string {
+	if footer {
+		return "footer"
+	}
+	return "header"
+}
+
+// addHeaderFooterPart 新建页眉/页脚部件并登记关系与内容类型，返回关系 Id。
+func (p *Package) addHeaderFooterPart(footer bool, body string) string {
+	kind, root, relType, contentType := "header", "w:hdr", RelTypeHeader, ContentTypeHeader
+	if footer {
+		kind, root, relType, contentType = "footer", "w:ftr", RelTypeFooter, ContentTypeFooter
+	}
+	partName := p.nextPartName("word/" + kind)
+	p.SetPart(partName, []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<%s xmlns:w="%s" xmlns:r="%s">%s</%s>`, root, NamespaceW, NamespaceR, body, root)))
+	p.EnsureOverride(partName, contentType)
+	p.EnsureDocumentNamespace("r", NamespaceR)
+	return p.AddRelationship(PartDocumentRels, relType, strings.TrimPrefix(partName, "word/"))
+}
+
+// nextPartName 返回 prefix1.xml、prefix2.xml… 中第一个未被占用的部件名。
+func (p *Package) nextPartName(prefix string) string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s%d.xml", prefix, i)
+		if !p.Has(name) {
+			return name
+		}
+	}
+}
+
+func headerFooterParagraph(segments [][]Run, styleID string, textWidth int) string {
+	var pPr strings.Builder
+	pPr.WriteString("<w:pPr>")
+	if styleID != "" {
+		pPr.WriteString(`<w:pStyle w:val="` + escapeXML(styleID) + `"/>`)
+	}
+	// 显式声明制表位，不依赖模板中“页眉/页脚”样式的定义。
+	switch len(segments) {
+	case 2:
+		pPr.WriteString(fmt.Sprintf(`<w:tabs><w:tab w:val="clear" w:pos="%d"/><w:tab w:val="right" w:pos="%d"/></w:tabs>`, textWidth/2, textWidth))
+	case 3:
+		pPr.WriteString(fmt.Sprintf(`<w:tabs><w:tab w:val="center" w:pos="%d"/><w:tab w:val="right" w:pos="%d"/></w:tabs>`, textWidth/2, textWidth))
+	default:
+		pPr.WriteString(`<w:jc w:val="center"/>`)
+	}
+	pPr.WriteString("</w:pPr>")
+
+	var b strings.Builder
+	b.WriteString("<w:p>")
+	b.WriteString(pPr.String())
+	for i, seg := range segments {
+		if i > 0 {
+			b.WriteString("<w:r><w:tab/></w:r>")
+		}
+		for _, run := range seg {
+			if run.Field != "" {
+				b.WriteString(FieldRuns(run.Field, run.Text))
+				continue
+			}
+			b.WriteString(TextRun(run.Text, ""))
+		}
+	}
+	b.WriteString("</w:p>")
+	return b.String()
+}
+
+// TextRun 生成一个文本 run；rPr 为可选的 w:rPr 内容。
+func TextRun(text, rPr string) string {
+	if text == "" {
+		return ""
+	}
+	if rPr != "" {
+		rPr = "<w:rPr>" + rPr + "</w:rPr>"
+	}
+	return `<w:r>` + rPr + `<w:t xml:space="preserve">` + EscapeText(text) + `</w:t></w:r>`
+}
+
+// FieldRuns 生成复杂域（begin/instrText/separate/结果/end），placeholder 为 Word 更新域前显示的结果。
+func FieldRuns(instr, placeholder string) string {
+	return `<w:r><w:fldChar w:fldCharType="begin"/></w:r>` +
+		`<w:r><w:instrText xml:space="preserve"> ` + EscapeText(instr) + ` </w:instrText></w:r>` +
+		`<w:r><w:fldChar w:fldCharType="separate"/></w:r>` +
+		TextRun(placeholder, "") +
+		`<w:r><w:fldChar w:fldCharType="end"/></w:r>`
+}
@@ -0,0 +1,69 @@
+package docx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetHeaderFooterDefaultFirstAndEven(t *testing.T) {
+	path := writeTestDocx(t, nil)
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.SetHeaderFooter(HeaderFooter{Segments: [][]Run{{{Text: "Spec & Co"}}}}))
+	require.NoError(t, pkg.SetHeaderFooter(HeaderFooter{Footer: true, Type: HeaderFooterFirst, Segments: [][]Run{
+		{{Text: "left"}},
+		{{Text: "Page "}, {Field: "PAGE", Text: "1"}, {Text: " of "}, {Field: "NUMPAGES", Text: "1"}},
+	}}))
+	require.NoError(t, pkg.SetHeaderFooter(HeaderFooter{Type: HeaderFooterEven, Segments: [][]Run{{{Text: "a"}}, {{Text: "b"}}, {{Text: "c"}}}}))
+	require.NoError(t, pkg.Save())
+
+	pkg = reopen(t, path)
+	header := part(t, pkg, "word/header1.xml")
+	require.Contains(t, header, "<w:hdr ")
+	require.Contains(t, header, `<w:jc w:val="center"/>`)
+	require.Contains(t, header, "Spec &amp; Co")
+
+	footer := part(t, pkg, "word/footer1.xml")
+	require.Contains(t, footer, "<w:ftr ")
+	require.Contains(t, footer, `<w:tab w:val="right" w:pos="9360"/>`)
+	require.Contains(t, footer, `<w:instrText xml:space="preserve"> PAGE </w:instrText>`)
+	require.Contains(t, footer, `<w:instrText xml:space="preserve"> NUMPAGES </w:instrText>`)
+	require.Equal(t, 1, strings.Count(footer, "<w:tab/>"))
+
+	require.Equal(t, 2, strings.Count(part(t, pkg, "word/header2.xml"), "<w:tab/>"))
+
+	doc := part(t, pkg, PartDocument)
+	require.Contains(t, doc, `<w:sectPr><w:headerReference w:type="even" r:id="rId3"/><w:footerReference w:type="first" r:id="rId2"/><w:headerReference w:type="default" r:id="rId1"/><w:pgSz`)
+	require.Contains(t, doc, `<w:titlePg/></w:sectPr>`)
+
+	rels := part(t, pkg, PartDocumentRels)
+	require.Contains(t, rels, `Id="rId1" Type="`+RelTypeHeader+`" Target="header1.xml"`)
+	require.Contains(t, rels, `Target="footer1.xml"`)
+	require.Contains(t, rels, `Target="settings.xml"`)
+
+	types := part(t, pkg, PartContentTypes)
+	require.Contains(t, types, `<Override PartName="/word/header2.xml" ContentType="`+ContentTypeHeader+`"/>`)
+	require.Contains(t, types, `<Override PartName="/word/footer1.xml" ContentType="`+ContentTypeFooter+`"/>`)
+	require.Contains(t, part(t, pkg, PartSettings), "<w:evenAndOddHeaders/>")
+}
+
+func TestSetHeaderFooterReplacesSameTypeReference(t *testing.T) {
+	doc := strings.Replace(testDocument, "<w:sectPr>", `<w:sectPr><w:headerReference w:type="default" r:id="rId9"/><w:headerReference w:type="first" r:id="rId8"/>`, 1)
+	path := writeTestDocx(t, map[string]string{PartDocument: doc, "word/header1.xml": "<w:hdr/>"})
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.SetHeaderFooter(HeaderFooter{Segments: [][]Run{{{Text: "x"}}}}))
+
+	got := part(t, pkg, PartDocument)
+	require.NotContains(t, got, `r:id="rId9"`)
+	require.Contains(t, got, `r:id="rId8"`)
+	require.True(t, pkg.Has("word/header2.xml"))
+}
+
+func TestSetOrderedChildKeepsSchemaOrder(t *testing.T) {
+	sect := `<w:sectPr><w:pgSz w:w="1"/><w:cols w:space="425"/><w:docGrid w:type="lines"/></w:sectPr>`
+	got := SetSectionChild(sect, "w:titlePg", "<w:titlePg/>")
+	require.Equal(t, `<w:sectPr><w:pgSz w:w="1"/><w:cols w:space="425"/><w:titlePg/><w:docGrid w:type="lines"/></w:sectPr>`, got)
+	require.Equal(t, got, SetSectionChild(got, "w:titlePg", "<w:titlePg/>"))
+	require.Equal(t, `<w:sectPr><w:titlePg/></w:sectPr>`, SetSectionChild(`<w:sectPr/>`, "w:titlePg", "<w:titlePg/>"))
+}
@@ -0,0 +1,126 @@
+package docx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	OrientationPortrait  = "portrait"
+	OrientationLandscape = "landscape"
+)
+
+// LandscapeSectionBreakParagraph 结束一个横向节的分节符段落；页面设置由 FinishSections 补齐。
+const LandscapeSectionBreakParagraph = `<w:p><w:pPr><w:sectPr><w:pgSz w:orient="landscape"/></w:sectPr></w:pPr></w:p>`
+
+// PageBreakParagraph 是只承载分页符的段落。
+const PageBreakParagraph = `<w:p><w:r><w:br w:type="page"/></w:r></w:p>`
+
+// paperSizes 是常用纸张的纵向宽高（twip）。
+var paperSizes = map[string][2]int{
+	"a3":     {16838, 23811},
+	"a4":     {11906, 16838},
+	"a5":     {8391, 11906},
+	"b5":     {9978, 14173},
+	"letter": {12240, 15840},
+	"legal":  {12240, 20160},
+}
+
+var (
+	pgMarTagRe = regexp.MustCompile(`<w:pgMar(?:\s[^>]*?)?\s*/>`)
+	pgSzHRe    = regexp.MustCompile(`<w:pgSz\s[^>]*w:h="(\d+)"`)
+)
+
+// PaperSize 返回纸张名（不区分大小写，如 A4、Letter）对应的纵向宽高（twip）。
+func PaperSize(name string) (width, height int, ok bool) {
+	size, ok := paperSizes[strings.ToLower(strings.TrimSpace(name))]
+	return size[0], size[1], ok
+}
+
+// PaperNames 返回支持的纸张名，用于错误提示。
+func PaperNames() []string {
+	return []string{"A3", "A4", "A5", "B5", "Letter", "Legal"}
+}
+
+// PageMargins 是上、右、下、左页边距（twip）。
+type PageMargins struct {
+	Top, Right, Bottom, Left int
+}
+
+// PageSetup 是写入每一节的页面设置；零值字段保持参考模板的设置。
+type PageSetup struct {
+	// Width 与 Height 为纸张的纵向宽高（twip）。
+	Width, Height int
+	Margins       *PageMargins
+	// Orientation 为 portrait 或 landscape。
+	Orientation string
+}
+
+// FinishSections 用最后一节补齐正文中插入的分节符（页面设置与页眉页脚引用），再把 setup 写入每一节。
+// 以 LandscapeSectionBreakParagraph 结束的节始终为横向；lastLandscape 为 true 时最后一节也为横向。
+// 第一节之后的节不再设置首页不同与起始页码，页码接续前一节。
+func (p *Package) FinishSections(setup PageSetup, lastLandscape bool) error {
+	sects := p.sections()
+	last := sects[len(sects)-1]
+	return p.EditSections(func(index int, sectPr string) string {
+		isLast := index == len(sects)-1
+		landscape := isLast && lastLandscape
+		if !isLast && !pgSzWRe.MatchString(sectPr) {
+			// 分节符占位：只带方向标记，其余设置取自最后一节。
+			landscape = strings.Contains(sectPr, `w:orient="landscape"`)
+			sectPr = last
+		}
+		if index > 0 {
+			sectPr = RemoveElement(sectPr, "w:titlePg", nil)
+			sectPr = RemoveElement(sectPr, "w:pgNumType", nil)
+		}
+		s := setup
+		if landscape {
+			s.Orientation = OrientationLandscape
+		}
+		return ApplyPageSetup(sectPr, s)
+	})
+}
+
+// ApplyPageSetup 把纸张、页边距与方向写入 sectPr，保留页眉页脚距离等其余属性。
+func ApplyPageSetup(sectPr string, s PageSetup) string {
+	width := atoiMatch(pgSzWRe, sectPr, 11906)
+	height := atoiMatch(pgSzHRe, sectPr, 16838)
+	orientation := OrientationPortrait
+	if strings.Contains(sectPr, `w:orient="landscape"`) {
+		orientation = OrientationLandscape
+	}
+	if s.Width > 0 && s.Height > 0 {
+		width, height = s.Width, s.Height
+	}
+	if s.Orientation != "" {
+		orientation = s.Orientation
+	}
+	if (orientation == OrientationLandscape) != (width > height) {
+		width, height = height, width
+	}
+	pgSz := fmt.Sprintf(`<w:pgSz w:w="%d" w:h="%d"/>`, width, height)
+	if orientation == OrientationLandscape {
+		pgSz = fmt.Sprintf(`<w:pgSz w:w="%d" w:h="%d" w:orient="landscape"/>`, width, height)
+	}
+	sectPr = SetSectionChild(sectPr, "w:pgSz", pgSz)
+	if s.Margins != nil {
+		sectPr = SetSectionChild(sectPr, "w:pgMar", pageMarginsElement(pgMarTagRe.FindString(sectPr), *s.Margins))
+	}
+	return sectPr
+}
+
+// pageMarginsElement 用 m 替换 pgMar 的四边距，保留 header、footer、gutter。
+func pageMarginsElement(old string, m PageMargins) string {
+	a := attrs(old)
+	extra := ""
+	for _, name := range []string{"w:header", "w:footer", "w:gutter"} {
+		v, ok := a[name]
+		if !ok {
+			v = map[string]string{"w:header": "851", "w:footer": "992", "w:gutter": "0"}[name]
+		}
+		extra += fmt.Sprintf(` %s="%s"`, name, v)
+	}
+	return fmt.Sprintf(`<w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d"%s/>`, m.Top, m.Right, m.Bottom, m.Left, extra)
+}
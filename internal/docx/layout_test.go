@@ -0,0 +1,40 @@
+package docx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinishSectionsFillsBreaksAndAppliesSetup(t *testing.T) {
+	doc := strings.Replace(testDocument, "<w:body>", "<w:body>"+SectionBreakParagraph+LandscapeSectionBreakParagraph, 1)
+	doc = strings.Replace(doc, "<w:sectPr><w:pgSz w:w=", `<w:sectPr><w:headerReference w:type="default" r:id="rId5"/><w:pgSz w:w=`, 1)
+	doc = strings.Replace(doc, "</w:sectPr></w:body>", "<w:pgNumType w:start=\"1\"/><w:titlePg/></w:sectPr></w:body>", 1)
+	path := writeTestDocx(t, map[string]string{PartDocument: doc})
+	pkg := reopen(t, path)
+
+	w, h, ok := PaperSize("A4")
+	require.True(t, ok)
+	setup := PageSetup{Width: w, Height: h, Margins: &PageMargins{Top: 1000, Right: 900, Bottom: 1000, Left: 900}}
+	require.NoError(t, pkg.FinishSections(setup, false))
+
+	sects := sectPrRe.FindAllString(part(t, pkg, PartDocument), -1)
+	require.Len(t, sects, 3)
+	margins := `<w:pgMar w:top="1000" w:right="900" w:bottom="1000" w:left="900" w:header="720" w:footer="720" w:gutter="0"/>`
+	// 第一节保留首页设置；插入的节复制页眉引用。
+	require.Equal(t, `<w:sectPr><w:headerReference w:type="default" r:id="rId5"/><w:pgSz w:w="11906" w:h="16838"/>`+margins+`<w:pgNumType w:start="1"/><w:titlePg/></w:sectPr>`, sects[0])
+	require.Equal(t, `<w:sectPr><w:headerReference w:type="default" r:id="rId5"/><w:pgSz w:w="16838" w:h="11906" w:orient="landscape"/>`+margins+`</w:sectPr>`, sects[1])
+	require.Equal(t, `<w:sectPr><w:headerReference w:type="default" r:id="rId5"/><w:pgSz w:w="11906" w:h="16838"/>`+margins+`</w:sectPr>`, sects[2])
+}
+
+func TestApplyPageSetupKeepsOrientationAndLastLandscape(t *testing.T) {
+	landscape := `<w:sectPr><w:pgSz w:w="15840" w:h="12240" w:orient="landscape"/></w:sectPr>`
+	require.Equal(t, `<w:sectPr><w:pgSz w:w="16838" w:h="11906" w:orient="landscape"/></w:sectPr>`, ApplyPageSetup(landscape, PageSetup{Width: 11906, Height: 16838}))
+	require.Equal(t, `<w:sectPr><w:pgSz w:w="12240" w:h="15840"/></w:sectPr>`, ApplyPageSetup(landscape, PageSetup{Orientation: OrientationPortrait}))
+
+	path := writeTestDocx(t, nil)
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.FinishSections(PageSetup{}, true))
+	require.Contains(t, part(t, pkg, PartDocument), `<w:pgSz w:w="15840" w:h="12240" w:orient="landscape"/><w:pgMar w:top="1440"`)
+}
@@ -0,0 +1,126 @@
+package docx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	PartFootnotes = "word/footnotes.xml"
+	PartEndnotes  = "word/endnotes.xml"
+
+	RelTypeEndnotes     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/endnotes"
+	ContentTypeEndnotes = "application/vnd.openxmlformats-officedocument.wordprocessingml.endnotes+xml"
+)
+
+// 脚注/尾注编号重新开始的位置，对应 w:numRestart 的取值。
+const (
+	NoteRestartContinuous = "continuous"
+	NoteRestartEachSect   = "eachSect"
+	NoteRestartEachPage   = "eachPage"
+)
+
+var (
+	footnoteRe     = regexp.MustCompile(`(?s)<w:footnote(?:\s[^>]*)?>.*?</w:footnote>`)
+	notesRootRe    = regexp.MustCompile(`<w:footnotes(?:\s[^>]*)?>`)
+	noteTypeAttrRe = regexp.MustCompile(`^<w:footnote\s[^>]*w:type=`)
+)
+
+// endnoteSeparators 是尾注分隔线与延续分隔线，由 settings 中的 w:endnotePr 引用。
+const endnoteSeparators = `<w:endnote w:type="separator" w:id="-1"><w:p><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:r><w:separator/></w:r></w:p></w:endnote>` +
+	`<w:endnote w:type="continuationSeparator" w:id="0"><w:p><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:r><w:continuationSeparator/></w:r></w:p></w:endnote>`
+
+// NoteNumbering 是写入每一节 w:footnotePr/w:endnotePr 的编号设置；空字段保持 Word 默认。
+type NoteNumbering struct {
+	// Format 为 w:numFmt 的取值，如 decimal、lowerRoman、upperLetter、chicago。
+	Format string
+	// Restart 为 NoteRestartContinuous、NoteRestartEachSect 或 NoteRestartEachPage（仅脚注）。
+	Restart string
+}
+
+// ConvertFootnotesToEndnotes 把 pandoc 生成的脚注全部改为尾注，返回转换的条数。
+// 分隔线等带 w:type 的脚注留在 footnotes.xml 中；脚注样式在参考模板有对应尾注样式时一并替换。
+func (p *Package) ConvertFootnotesToEndnotes() (int, error) {
+	data, ok := p.Part(PartFootnotes)
+	if !ok {
+		return 0, nil
+	}
+	doc, ok := p.Part(PartDocument)
+	if !ok {
+		return 0, errMissingPart(PartDocument)
+	}
+	footnotes := string(data)
+	styles := strings.NewReplacer()
+	if textID, refID := p.StyleID("endnote text"), p.StyleID("endnote reference"); textID != "" && refID != "" {
+		styles = strings.NewReplacer(
+			`w:val="`+p.noteStyleID("footnote text", "FootnoteText")+`"`, `w:val="`+textID+`"`,
+			`w:val="`+p.noteStyleID("footnote reference", "FootnoteReference")+`"`, `w:val="`+refID+`"`,
+		)
+	}
+	toEndnote := strings.NewReplacer("<w:footnote ", "<w:endnote ", "<w:footnote>", "<w:endnote>", "</w:footnote>", "</w:endnote>", "<w:footnoteRef/>", "<w:endnoteRef/>")
+
+	var endnotes strings.Builder
+	count := 0
+	footnotes = footnoteRe.ReplaceAllStringFunc(footnotes, func(note string) string {
+		if noteTypeAttrRe.MatchString(note) {
+			return note
+		}
+		endnotes.WriteString(styles.Replace(toEndnote.Replace(note)))
+		count++
+		return ""
+	})
+	if count == 0 {
+		return 0, nil
+	}
+	p.SetPart(PartFootnotes, []byte(footnotes))
+
+	existing, ok := p.Part(PartEndnotes)
+	if ok {
+		p.SetPart(PartEndnotes, []byte(insertBeforeClose(string(existing), "w:endnotes", endnotes.String())))
+	} else {
+		// 沿用 footnotes.xml 根元素的命名空间声明，注释中的公式、图片等元素无需再补声明。
+		root := strings.Replace(notesRootRe.FindString(footnotes), "<w:footnotes", "<w:endnotes", 1)
+		if root == "" {
+			root = fmt.Sprintf(`<w:endnotes xmlns:w="%s" xmlns:r="%s">`, NamespaceW, NamespaceR)
+		}
+		p.SetPart(PartEndnotes, []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+root+endnoteSeparators+endnotes.String()+"</w:endnotes>"))
+		p.SetSetting("w:endnotePr", `<w:endnotePr><w:endnote w:id="-1"/><w:endnote w:id="0"/></w:endnotePr>`)
+	}
+	// 注释中的超链接与图片沿用脚注部件的关系 Id。
+	if rels, ok := p.Part(RelsPartFor(PartFootnotes)); ok && !p.Has(RelsPartFor(PartEndnotes)) {
+		p.SetPart(RelsPartFor(PartEndnotes), rels)
+	}
+	p.EnsureOverride(PartEndnotes, ContentTypeEndnotes)
+	p.AddRelationship(PartDocumentRels, RelTypeEndnotes, "endnotes.xml")
+
+	p.SetPart(PartDocument, []byte(styles.Replace(strings.ReplaceAll(string(doc), "<w:footnoteReference ", "<w:endnoteReference "))))
+	return count, nil
+}
+
+// noteStyleID 返回脚注样式的 styleId；参考模板中没有时为 pandoc 使用的默认 id。
+func (p *Package) noteStyleID(name, fallback string) string {
+	if id := p.StyleID(name); id != "" {
+		return id
+	}
+	return fallback
+}
+
+// SetNoteNumbering 把编号格式与重新编号位置写入每一节；endnotes 为 true 时设置尾注，否则设置脚注。
+func (p *Package) SetNoteNumbering(endnotes bool, n NoteNumbering) error {
+	name := "w:footnotePr"
+	if endnotes {
+		name = "w:endnotePr"
+	}
+	element := "<" + name + ">"
+	if n.Format != "" {
+		element += `<w:numFmt w:val="` + escapeXML(n.Format) + `"/>`
+	}
+	if n.Restart != "" {
+		element += `<w:numRestart w:val="` + escapeXML(n.Restart) + `"/>`
+	}
+	element += "</" + name + ">"
+	return p.EditSections(func(index int, sectPr string) string {
+		return SetSectionChild(sectPr, name, element)
+	})
+}
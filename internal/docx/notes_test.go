@@ -0,0 +1,78 @@
+package docx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testFootnotes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:footnotes xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<w:footnote w:type="continuationSeparator" w:id="-1"><w:p><w:r><w:continuationSeparator/></w:r></w:p></w:footnote>` +
+	`<w:footnote w:type="separator" w:id="0"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>` +
+	`<w:footnote w:id="20"><w:p><w:pPr><w:pStyle w:val="FootnoteText"/></w:pPr><w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteRef/></w:r><w:r><w:t>one</w:t></w:r></w:p>` +
+	`<w:p><w:pPr><w:pStyle w:val="FootnoteText"/></w:pPr><w:hyperlink r:id="rId1"><w:r><w:t>two</w:t></w:r></w:hyperlink></w:p></w:footnote></w:footnotes>`
+
+const testNoteStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:style w:type="paragraph" w:styleId="EndnoteText"><w:name w:val="endnote text"/></w:style><w:style w:type="character" w:styleId="EndnoteReference"><w:name w:val="endnote reference"/></w:style></w:styles>`
+
+func TestConvertFootnotesToEndnotes(t *testing.T) {
+	ref := `<w:p><w:r><w:t>see</w:t></w:r><w:r><w:rPr><w:rStyle w:val="FootnoteReference"/></w:rPr><w:footnoteReference w:id="20"/></w:r></w:p>`
+	doc := strings.Replace(testDocument, "<w:body>", "<w:body>"+ref, 1)
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="` + RelTypeHyperlink + `" Target="https://example.com" TargetMode="External"/></Relationships>`
+	pkg := reopen(t, writeTestDocx(t, map[string]string{
+		PartDocument:               doc,
+		PartFootnotes:              testFootnotes,
+		RelsPartFor(PartFootnotes): rels,
+		PartStyles:                 testNoteStyles,
+	}))
+
+	n, err := pkg.ConvertFootnotesToEndnotes()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NoError(t, pkg.Save())
+	pkg = reopen(t, pkg.Path())
+
+	got := part(t, pkg, PartDocument)
+	require.Contains(t, got, `<w:rStyle w:val="EndnoteReference"/></w:rPr><w:endnoteReference w:id="20"/>`)
+	require.NotContains(t, got, "footnoteReference")
+
+	footnotes := part(t, pkg, PartFootnotes)
+	require.Contains(t, footnotes, `w:type="separator"`)
+	require.NotContains(t, footnotes, `w:id="20"`)
+
+	endnotes := part(t, pkg, PartEndnotes)
+	require.Contains(t, endnotes, `<w:endnotes xmlns:w=`)
+	require.Contains(t, endnotes, `<w:endnote w:type="separator" w:id="-1">`)
+	require.Contains(t, endnotes, `<w:endnote w:id="20"><w:p><w:pPr><w:pStyle w:val="EndnoteText"/></w:pPr><w:r><w:rPr><w:rStyle w:val="EndnoteReference"/></w:rPr><w:endnoteRef/></w:r>`)
+	require.Contains(t, endnotes, `<w:hyperlink r:id="rId1">`)
+	require.Contains(t, part(t, pkg, RelsPartFor(PartEndnotes)), "https://example.com")
+	require.Contains(t, part(t, pkg, PartDocumentRels), `Target="endnotes.xml"`)
+	require.Contains(t, part(t, pkg, PartContentTypes), ContentTypeEndnotes)
+	require.Contains(t, part(t, pkg, PartSettings), `<w:endnotePr><w:endnote w:id="-1"/><w:endnote w:id="0"/></w:endnotePr>`)
+}
+
+func TestConvertFootnotesToEndnotesWithoutNotes(t *testing.T) {
+	pkg := reopen(t, writeTestDocx(t, nil))
+	n, err := pkg.ConvertFootnotesToEndnotes()
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.False(t, pkg.Has(PartEndnotes))
+}
+
+func TestSetNoteNumbering(t *testing.T) {
+	doc := strings.Replace(testDocument, "<w:body>", "<w:body><w:p><w:pPr><w:sectPr><w:type w:val=\"nextPage\"/></w:sectPr></w:pPr></w:p>", 1)
+	pkg := reopen(t, writeTestDocx(t, map[string]string{PartDocument: doc}))
+
+	require.NoError(t, pkg.SetNoteNumbering(false, NoteNumbering{Format: "lowerRoman", Restart: NoteRestartEachPage}))
+	got := part(t, pkg, PartDocument)
+	require.Equal(t, 2, strings.Count(got, `<w:footnotePr><w:numFmt w:val="lowerRoman"/><w:numRestart w:val="eachPage"/></w:footnotePr>`))
+	require.Contains(t, got, `<w:sectPr><w:footnotePr>`)
+	require.Contains(t, got, `</w:footnotePr><w:type w:val="nextPage"/>`)
+
+	require.NoError(t, pkg.SetNoteNumbering(true, NoteNumbering{Restart: NoteRestartEachSect}))
+	got = part(t, pkg, PartDocument)
+	require.Contains(t, got, `</w:footnotePr><w:endnotePr><w:numRestart w:val="eachSect"/></w:endnotePr>`)
+}
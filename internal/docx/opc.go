@@ -0,0 +1,199 @@
+package docx
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	RelTypeOfficeDocument = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+	RelTypeCoreProps      = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	RelTypeAppProps       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	RelTypeCustomProps    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+	RelTypeSettings       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings"
+	RelTypeHeader         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/header"
+	RelTypeFooter         = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer"
+
+	ContentTypeCoreProps   = "application/vnd.openxmlformats-package.core-properties+xml"
+	ContentTypeAppProps    = "application/vnd.openxmlformats-officedocument.extended-properties+xml"
+	ContentTypeCustomProps = "application/vnd.openxmlformats-officedocument.custom-properties+xml"
+	ContentTypeSettings    = "application/vnd.openxmlformats-officedocument.wordprocessingml.settings+xml"
+	ContentTypeHeader      = "application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"
+	ContentTypeFooter      = "application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"
+)
+
+const emptyRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"></Relationships>`
+
+var (
+	relationshipRe = regexp.MustCompile(`<Relationship\s[^>]*?/?>`)
+	attrRe         = regexp.MustCompile(`([\w:]+)\s*=\s*"([^"]*)"`)
+)
+
+// Relationship 是 .rels 部件中的一条关系。
+type Relationship struct {
+	ID         string
+	Type       string
+	Target     string
+	TargetMode string
+}
+
+func attrs(tag string) map[string]string {
+	out := map[string]string{}
+	for _, m := range attrRe.FindAllStringSubmatch(tag, -1) {
+		out[m[1]] = unescapeXML(m[2])
+	}
+	return out
+}
+
+// Relationships 解析指定 .rels 部件；部件不存在时返回空列表。
+func (p *Package) Relationships(relsPart string) []Relationship {
+	data, ok := p.Part(relsPart)
+	if !ok {
+		return nil
+	}
+	out := make([]Relationship, 0)
+	for _, tag := range relationshipRe.FindAllString(string(data), -1) {
+		a := attrs(tag)
+		out = append(out, Relationship{ID: a["Id"], Type: a["Type"], Target: a["Target"], TargetMode: a["TargetMode"]})
+	}
+	return out
+}
+
+// AddRelationship 追加关系并返回其 Id；已存在相同 Type+Target 时直接复用。
+func (p *Package) AddRelationship(relsPart, relType, target string) string {
+	return p.addRelationship(relsPart, relType, target, "")
+}
+
+// AddExternalRelationship 追加 TargetMode="External" 的关系（如外部超链接）并返回其 Id。
+func (p *Package) AddExternalRelationship(relsPart, relType, target string) string {
+	return p.addRelationship(relsPart, relType, target, "External")
+}
+
+func (p *Package) addRelationship(relsPart, relType, target, mode string) string {
+	rels := p.Relationships(relsPart)
+	maxID := 0
+	for _, r := range rels {
+		if r.Type == relType && r.Target == target && r.TargetMode == mode {
+			return r.ID
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(r.ID, "rId")); err == nil && n > maxID {
+			maxID = n
+		}
+	}
+	id := fmt.Sprintf("rId%d", maxID+1)
+	data, ok := p.Part(relsPart)
+	if !ok {
+		data = []byte(emptyRelationships)
+	}
+	rel := fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s"/>`, id, escapeXML(relType), escapeXML(target))
+	if mode != "" {
+		rel = fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s" TargetMode="%s"/>`, id, escapeXML(relType), escapeXML(target), mode)
+	}
+	p.SetPart(relsPart, []byte(insertBeforeClose(string(data), "Relationships", rel)))
+	return id
+}
+
+// RelsPartFor 返回部件对应的 .rels 部件名，如 word/document.xml -> word/_rels/document.xml.rels。
+func RelsPartFor(part string) string {
+	dir, file := path.Split(part)
+	return dir + "_rels/" + file + ".rels"
+}
+
+// ResolveTarget 把关系中的相对 Target 解析为包内部件名。
+func ResolveTarget(sourcePart, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(path.Clean(target), "/")
+	}
+	return strings.TrimPrefix(path.Clean(path.Join(path.Dir(sourcePart), target)), "/")
+}
+
+// EnsureOverride 确保 [Content_Types].xml 中存在部件的 Override 声明。
+func (p *Package) EnsureOverride(partName, contentType string) {
+	data, ok := p.Part(PartContentTypes)
+	if !ok {
+		return
+	}
+	content := string(data)
+	name := "/" + strings.TrimPrefix(partName, "/")
+	if strings.Contains(content, `PartName="`+name+`"`) {
+		return
+	}
+	override := fmt.Sprintf(`<Override PartName="%s" ContentType="%s"/>`, escapeXML(name), escapeXML(contentType))
+	p.SetPart(PartContentTypes, []byte(insertBeforeClose(content, "Types", override)))
+}
+
+// EnsureDefault 确保 [Content_Types].xml 中存在扩展名的 Default 声明。
+func (p *Package) EnsureDefault(ext, contentType string) {
+	data, ok := p.Part(PartContentTypes)
+	if !ok {
+		return
+	}
+	content := string(data)
+	ext = strings.TrimPrefix(strings.ToLower(ext), ".")
+	if regexp.MustCompile(`(?i)<Default\s[^>]*Extension="` + regexp.QuoteMeta(ext) + `"`).MatchString(content) {
+		return
+	}
+	def := fmt.Sprintf(`<Default Extension="%s" ContentType="%s"/>`, escapeXML(ext), escapeXML(contentType))
+	p.SetPart(PartContentTypes, []byte(insertBeforeClose(content, "Types", def)))
+}
+
+func insertBeforeClose(content, root, fragment string) string {
+	closeTag := "</" + root + ">"
+	idx := strings.LastIndex(content, closeTag)
+	if idx < 0 {
+		selfClose := regexp.MustCompile(`<` + regexp.QuoteMeta(root) + `(\s[^>]*)?/>`)
+		if loc := selfClose.FindStringIndex(content); loc != nil {
+			open := strings.TrimSuffix(content[loc[0]:loc[1]], "/>") + ">"
+			return content[:loc[0]] + open + fragment + closeTag + content[loc[1]:]
+		}
+		return content + fragment
+	}
+	return content[:idx] + fragment + content[idx:]
+}
+
+// insertAfterOpen 把 fragment 插入为根元素的第一个子元素。
+func insertAfterOpen(content, root, fragment string) string {
+	loc := regexp.MustCompile(`<` + regexp.QuoteMeta(root) + `(\s[^>]*)?>`).FindStringIndex(content)
+	if loc == nil || strings.HasSuffix(content[loc[0]:loc[1]], "/>") {
+		return insertBeforeClose(content, root, fragment)
+	}
+	return content[:loc[1]] + fragment + content[loc[1]:]
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			b.WriteString("&quot;")
+		case '\'':
+			b.WriteString("&apos;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func unescapeXML(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	r := strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&amp;", "&")
+	return r.Replace(s)
+}
+
+// EscapeText 转义写入 XML 文本节点或属性值的内容。
+func EscapeText(s string) string {
+	return escapeXML(s)
+}
@@ -0,0 +1,189 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	PartContentTypes = "[Content_Types].xml"
+	PartRootRels     = "_rels/.rels"
+	PartDocument     = "word/document.xml"
+	PartDocumentRels = "word/_rels/document.xml.rels"
+	PartStyles       = "word/styles.xml"
+	PartSettings     = "word/settings.xml"
+	PartCoreProps    = "docProps/core.xml"
+	PartAppProps     = "docProps/app.xml"
+	PartCustomProps  = "docProps/custom.xml"
+)
+
+type entry struct {
+	name     string
+	data     []byte
+	method   uint16
+	modified time.Time
+}
+
+// Package 是内存中的 docx（OPC zip）包，支持按部件读写后整体回写。
+type Package struct {
+	path    string
+	entries []*entry
+	index   map[string]*entry
+}
+
+func errMissingPart(name string) error {
+	return fmt.Errorf("docx 缺少部件：%s", name)
+}
+
+func Open(path string) (*Package, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 docx 失败：%w", err)
+	}
+	pkg, err := Read(data)
+	if err != nil {
+		return nil, err
+	}
+	pkg.path = path
+	return pkg, nil
+}
+
+func Read(data []byte) (*Package, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("解析 docx 压缩包失败：%w", err)
+	}
+	pkg := &Package{index: make(map[string]*entry, len(zr.File))}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("读取 docx 部件失败（%s）：%w", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取 docx 部件失败（%s）：%w", f.Name, err)
+		}
+		e := &entry{name: f.Name, data: content, method: f.Method, modified: f.Modified}
+		pkg.entries = append(pkg.entries, e)
+		pkg.index[f.Name] = e
+	}
+	return pkg, nil
+}
+
+func (p *Package) Path() string {
+	return p.path
+}
+
+func (p *Package) Has(name string) bool {
+	_, ok := p.index[name]
+	return ok
+}
+
+func (p *Package) Part(name string) ([]byte, bool) {
+	e, ok := p.index[name]
+	if !ok {
+		return nil, false
+	}
+	return e.data, true
+}
+
+func (p *Package) PartNames() []string {
+	names := make([]string, 0, len(p.entries))
+	for _, e := range p.entries {
+		names = append(names, e.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Package) SetPart(name string, data []byte) {
+	if e, ok := p.index[name]; ok {
+		e.data = data
+		return
+	}
+	e := &entry{name: name, data: data, method: zip.Deflate}
+	p.entries = append(p.entries, e)
+	p.index[name] = e
+}
+
+func (p *Package) RemovePart(name string) {
+	if _, ok := p.index[name]; !ok {
+		return
+	}
+	delete(p.index, name)
+	kept := p.entries[:0]
+	for _, e := range p.entries {
+		if e.name != name {
+			kept = append(kept, e)
+		}
+	}
+	p.entries = kept
+}
+
+func (p *Package) Bytes() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	// [Content_Types].xml 按惯例放在第一位，部分消费方依赖这一顺序。
+	ordered := make([]*entry, 0, len(p.entries))
+	if e, ok := p.index[PartContentTypes]; ok {
+		ordered = append(ordered, e)
+	}
+	for _, e := range p.entries {
+		if e.name != PartContentTypes {
+			ordered = append(ordered, e)
+		}
+	}
+	for _, e := range ordered {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: e.method, Modified: e.modified})
+		if err != nil {
+			return nil, fmt.Errorf("写入 docx 部件失败（%s）：%w", e.name, err)
+		}
+		if _, err := w.Write(e.data); err != nil {
+			return nil, fmt.Errorf("写入 docx 部件失败（%s）：%w", e.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("写入 docx 失败：%w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Save 先写临时文件再替换原文件，避免中途失败留下半个 docx。
+func (p *Package) Save() error {
+	if p.path == "" {
+		return fmt.Errorf("docx 未关联文件路径")
+	}
+	data, err := p.Bytes()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p.path), ".syl-md2doc-*.docx")
+	if err != nil {
+		return fmt.Errorf("创建临时 docx 失败：%w", err)
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("写入临时 docx 失败：%w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("写入临时 docx 失败：%w", err)
+	}
+	if err := os.Rename(tmp, p.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("替换 docx 失败：%w", err)
+	}
+	return nil
+}
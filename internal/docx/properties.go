@@ -0,0 +1,208 @@
+package docx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const customPropsFmtID = "{D5CDD505-2E9C-101B-9397-08002B2CF9AE}"
+
+var coreNamespaces = map[string]string{
+	"cp":      "http://schemas.openxmlformats.org/package/2006/metadata/core-properties",
+	"dc":      "http://purl.org/dc/elements/1.1/",
+	"dcterms": "http://purl.org/dc/terms/",
+}
+
+// coreFields 把属性键映射到 docProps/core.xml 中的元素。
+var coreFields = map[string]string{
+	"title":            "dc:title",
+	"subject":          "dc:subject",
+	"author":           "dc:creator",
+	"creator":          "dc:creator",
+	"keywords":         "cp:keywords",
+	"description":      "dc:description",
+	"comments":         "dc:description",
+	"category":         "cp:category",
+	"status":           "cp:contentStatus",
+	"content_status":   "cp:contentStatus",
+	"last_modified_by": "cp:lastModifiedBy",
+	"language":         "dc:language",
+	"identifier":       "dc:identifier",
+	"version":          "cp:version",
+	"revision":         "cp:revision",
+}
+
+// appFields 把属性键映射到 docProps/app.xml 中的元素。
+var appFields = map[string]string{
+	"company": "Company",
+	"manager": "Manager",
+}
+
+const emptyCoreProps = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"></cp:coreProperties>`
+
+const emptyAppProps = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"></Properties>`
+
+// NormalizePropertyKey 统一属性键写法：小写、短横线转下划线。
+func NormalizePropertyKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
+
+// SetProperties 写入文档属性：已知键写入 core.xml / app.xml，其余写入 custom.xml。
+func (p *Package) SetProperties(props map[string]string) error {
+	if len(props) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	core := map[string]string{}
+	app := map[string]string{}
+	custom := map[string]string{}
+	for _, k := range keys {
+		norm := NormalizePropertyKey(k)
+		switch {
+		case coreFields[norm] != "":
+			core[coreFields[norm]] = props[k]
+		case appFields[norm] != "":
+			app[appFields[norm]] = props[k]
+		default:
+			custom[strings.TrimSpace(k)] = props[k]
+		}
+	}
+	if len(core) > 0 {
+		p.setCoreProperties(core)
+	}
+	if len(app) > 0 {
+		p.setAppProperties(app)
+	}
+	if len(custom) > 0 {
+		if err := p.setCustomProperties(custom); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Package) setCoreProperties(fields map[string]string) {
+	data, ok := p.Part(PartCoreProps)
+	if !ok {
+		data = []byte(emptyCoreProps)
+		p.AddRelationship(PartRootRels, RelTypeCoreProps, PartCoreProps)
+		p.EnsureOverride(PartCoreProps, ContentTypeCoreProps)
+	}
+	content := string(data)
+	for prefix, ns := range coreNamespaces {
+		content = ensureNamespace(content, "cp:coreProperties", prefix, ns)
+	}
+	for _, name := range sortedKeys(fields) {
+		content = setElement(content, "cp:coreProperties", name, escapeXML(fields[name]))
+	}
+	p.SetPart(PartCoreProps, []byte(content))
+}
+
+func (p *Package) setAppProperties(fields map[string]string) {
+	data, ok := p.Part(PartAppProps)
+	if !ok {
+		data = []byte(emptyAppProps)
+		p.AddRelationship(PartRootRels, RelTypeAppProps, PartAppProps)
+		p.EnsureOverride(PartAppProps, ContentTypeAppProps)
+	}
+	content := string(data)
+	for _, name := range sortedKeys(fields) {
+		content = setElement(content, "Properties", name, escapeXML(fields[name]))
+	}
+	p.SetPart(PartAppProps, []byte(content))
+}
+
+type customProperty struct {
+	Name  string `xml:"name,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+type customProperties struct {
+	Properties []customProperty `xml:"property"`
+}
+
+func (p *Package) setCustomProperties(fields map[string]string) error {
+	existing := customProperties{}
+	if data, ok := p.Part(PartCustomProps); ok {
+		if err := xml.Unmarshal(data, &existing); err != nil {
+			return fmt.Errorf("解析 docProps/custom.xml 失败：%w", err)
+		}
+	}
+
+	merged := make([]customProperty, 0, len(existing.Properties)+len(fields))
+	seen := map[string]bool{}
+	for _, prop := range existing.Properties {
+		if v, ok := fields[prop.Name]; ok {
+			prop.Inner = "<vt:lpwstr>" + escapeXML(v) + "</vt:lpwstr>"
+			seen[prop.Name] = true
+		}
+		merged = append(merged, prop)
+	}
+	for _, name := range sortedKeys(fields) {
+		if seen[name] {
+			continue
+		}
+		merged = append(merged, customProperty{Name: name, Inner: "<vt:lpwstr>" + escapeXML(fields[name]) + "</vt:lpwstr>"})
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">`)
+	for i, prop := range merged {
+		// pid 必须从 2 开始连续编号。
+		fmt.Fprintf(&b, `<property fmtid="%s" pid="%d" name="%s">%s</property>`, customPropsFmtID, i+2, escapeXML(prop.Name), normalizeVTPrefix(prop.Inner))
+	}
+	b.WriteString(`</Properties>`)
+	p.SetPart(PartCustomProps, []byte(b.String()))
+	p.AddRelationship(PartRootRels, RelTypeCustomProps, PartCustomProps)
+	p.EnsureOverride(PartCustomProps, ContentTypeCustomProps)
+	return nil
+}
+
+var vtPrefixRe = regexp.MustCompile(`<(/?)[\w]+:`)
+
+// normalizeVTPrefix 把已有属性值的命名空间前缀统一为 vt，保证与重写后的根元素声明一致。
+func normalizeVTPrefix(inner string) string {
+	return vtPrefixRe.ReplaceAllString(inner, "<${1}vt:")
+}
+
+// setElement 替换根元素下的同名子元素；不存在时追加到根元素末尾。
+func setElement(content, root, name, escapedValue string) string {
+	re := regexp.MustCompile(`(?s)<` + regexp.QuoteMeta(name) + `(\s[^>]*)?/>|<` + regexp.QuoteMeta(name) + `(\s[^>]*)?>.*?</` + regexp.QuoteMeta(name) + `>`)
+	element := "<" + name + ">" + escapedValue + "</" + name + ">"
+	if loc := re.FindStringIndex(content); loc != nil {
+		return content[:loc[0]] + element + content[loc[1]:]
+	}
+	return insertBeforeClose(content, root, element)
+}
+
+func ensureNamespace(content, root, prefix, uri string) string {
+	if strings.Contains(content, "xmlns:"+prefix+"=") {
+		return content
+	}
+	idx := strings.Index(content, "<"+root)
+	if idx < 0 {
+		return content
+	}
+	insertAt := idx + len("<"+root)
+	return content[:insertAt] + fmt.Sprintf(` xmlns:%s="%s"`, prefix, uri) + content[insertAt:]
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,61 @@
+package docx
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPropertiesCoreAppAndCustom(t *testing.T) {
+	path := writeTestDocx(t, nil)
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.SetProperties(map[string]string{
+		"title":          "Spec <v2>",
+		"Author":         "Alice",
+		"company":        "ACME",
+		"DocumentNumber": "DOC-001",
+		"Classification": "Internal",
+	}))
+	require.NoError(t, pkg.Save())
+
+	pkg = reopen(t, path)
+	core := part(t, pkg, PartCoreProps)
+	require.Contains(t, core, "<dc:title>Spec &lt;v2&gt;</dc:title>")
+	require.Contains(t, core, "<dc:creator>Alice</dc:creator>")
+	require.NotContains(t, core, "old")
+
+	app := part(t, pkg, PartAppProps)
+	require.Contains(t, app, "<Company>ACME</Company>")
+
+	custom := part(t, pkg, PartCustomProps)
+	require.Contains(t, custom, `pid="2" name="Classification"><vt:lpwstr>Internal</vt:lpwstr>`)
+	require.Contains(t, custom, `pid="3" name="DocumentNumber"><vt:lpwstr>DOC-001</vt:lpwstr>`)
+	require.NoError(t, xml.Unmarshal([]byte(custom), new(any)))
+
+	types := part(t, pkg, PartContentTypes)
+	require.Contains(t, types, `PartName="/docProps/custom.xml"`)
+	require.Contains(t, types, `PartName="/docProps/app.xml"`)
+	rels := part(t, pkg, PartRootRels)
+	require.Contains(t, rels, `Id="rId3"`)
+	require.Contains(t, rels, RelTypeCustomProps)
+}
+
+func TestSetPropertiesMergesExistingCustom(t *testing.T) {
+	existing := `<?xml version="1.0" encoding="UTF-8"?><Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"><property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="2" name="Keep"><vt:lpwstr>yes</vt:lpwstr></property><property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="3" name="Replace"><vt:lpwstr>old</vt:lpwstr></property></Properties>`
+	path := writeTestDocx(t, map[string]string{PartCustomProps: existing})
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.SetProperties(map[string]string{"Replace": "new", "Added": "1"}))
+
+	custom := part(t, pkg, PartCustomProps)
+	require.Contains(t, custom, `name="Keep"><vt:lpwstr>yes</vt:lpwstr>`)
+	require.Contains(t, custom, `name="Replace"><vt:lpwstr>new</vt:lpwstr>`)
+	require.Contains(t, custom, `pid="4" name="Added"`)
+}
+
+func TestSetPropertiesCreatesMissingCore(t *testing.T) {
+	path := writeTestDocx(t, map[string]string{PartCoreProps: ""})
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.SetProperties(map[string]string{"subject": "S"}))
+	require.Contains(t, part(t, pkg, PartCoreProps), "<dc:subject>S</dc:subject>")
+}
@@ -0,0 +1,209 @@
+package docx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PandocTableStyle 是 pandoc 为 Markdown 表格设置的表格样式 id。
+const PandocTableStyle = "Table"
+
+var (
+	tblPrOrder = []string{
+		"w:tblStyle", "w:tblpPr", "w:tblOverlap", "w:bidiVisual", "w:tblStyleRowBandSize", "w:tblStyleColBandSize",
+		"w:tblW", "w:jc", "w:tblCellSpacing", "w:tblInd", "w:tblBorders", "w:shd", "w:tblLayout", "w:tblCellMar",
+		"w:tblLook", "w:tblCaption", "w:tblDescription",
+	}
+	trPrOrder = []string{
+		"w:cnfStyle", "w:divId", "w:gridBefore", "w:gridAfter", "w:wBefore", "w:wAfter", "w:cantSplit",
+		"w:trHeight", "w:tblHeader", "w:tblCellSpacing", "w:jc", "w:hidden",
+	}
+	tcPrOrder = []string{
+		"w:cnfStyle", "w:tcW", "w:gridSpan", "w:hMerge", "w:vMerge", "w:tcBorders", "w:shd", "w:noWrap",
+		"w:tcMar", "w:textDirection", "w:tcFitText", "w:vAlign", "w:hideMark",
+	}
+)
+
+var (
+	tableTagRe       = regexp.MustCompile(`<w:tbl>|<w:tbl\s[^>]*>|</w:tbl>`)
+	tableRowRe       = regexp.MustCompile(`(?s)<w:tr(?:\s[^>]*)?>.*?</w:tr>`)
+	tableCellRe      = regexp.MustCompile(`(?s)<w:tc(?:\s[^>]*)?>.*?</w:tc>`)
+	tableGridRe      = regexp.MustCompile(`(?s)<w:tblGrid(?:\s[^>]*)?>.*?</w:tblGrid>|<w:tblGrid\s*/>`)
+	tableStyleRe     = regexp.MustCompile(`<w:tblStyle\s+w:val="([^"]*)"\s*/>`)
+	tableWidthMarkRe = regexp.MustCompile(`<w:bookmarkStart\s+w:id="(\d+)"\s+w:name="(` + TableWidthMarkerPrefix + `\d+)"\s*/>`)
+)
+
+// TableWidthMarkerPrefix 是标记表格列宽提示的隐藏书签名前缀，书签放在表头第一个单元格中。
+const TableWidthMarkerPrefix = "_TblW"
+
+// TableFormat 是写入 pandoc 生成的表格（样式为 Table）的格式；零值不做修改。
+type TableFormat struct {
+	// StyleID 为替换 pandoc 默认 Table 样式的表格样式 id。
+	StyleID string
+	// RepeatHeader 让表头行在跨页时重复。
+	RepeatHeader bool
+	// Banded 打开条带行；表格样式未定义条带格式时直接为奇数数据行加 BandFill 底纹。
+	Banded   bool
+	BandFill string
+	// Widths 按表格标记（表头单元格中的隐藏书签名）给出各列的相对宽度。
+	Widths map[string][]float64
+}
+
+// FormatTables 按 f 修改正文中 pandoc 生成的表格，并移除列宽标记书签。
+func (p *Package) FormatTables(f TableFormat) error {
+	data, ok := p.Part(PartDocument)
+	if !ok {
+		return errMissingPart(PartDocument)
+	}
+	content := string(data)
+	styleID := f.StyleID
+	banding := f.Banded && !p.styleHasBanding(styleID)
+	textWidth := p.TextWidth()
+
+	spans := tableSpans(content)
+	// 从后往前修改，前面表格的位置不受影响；嵌套时内层表格先处理。
+	for i := len(spans) - 1; i >= 0; i-- {
+		start, end := spans[i][0], spans[i][1]
+		table := content[start:end]
+		m := tableStyleRe.FindStringSubmatch(table)
+		if m == nil || m[1] != PandocTableStyle {
+			continue
+		}
+		var widths []float64
+		if mark := tableWidthMarkRe.FindStringSubmatch(table); mark != nil {
+			widths = f.Widths[mark[2]]
+			table = removeBookmark(table, mark[0], mark[1])
+		}
+		table = formatTable(table, f, styleID, banding, widths, textWidth)
+		content = content[:start] + table + content[end:]
+	}
+	p.SetPart(PartDocument, []byte(content))
+	return nil
+}
+
+func formatTable(table string, f TableFormat, styleID string, banding bool, widths []float64, textWidth int) string {
+	tblPr := elementRe("w:tblPr").FindString(table)
+	newPr := tblPr
+	if styleID != "" {
+		newPr = setOrderedChild(newPr, "w:tblPr", "w:tblStyle", `<w:tblStyle w:val="`+escapeXML(styleID)+`"/>`, tblPrOrder)
+	}
+	if styleID != "" || f.Banded {
+		noHBand := "1"
+		if f.Banded {
+			noHBand = "0"
+		}
+		look := `<w:tblLook w:val="04A0" w:firstRow="1" w:lastRow="0" w:firstColumn="0" w:lastColumn="0" w:noHBand="` + noHBand + `" w:noVBand="1"/>`
+		newPr = setOrderedChild(newPr, "w:tblPr", "w:tblLook", look, tblPrOrder)
+	}
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	if total > 0 {
+		newPr = setOrderedChild(newPr, "w:tblPr", "w:tblW", `<w:tblW w:w="5000" w:type="pct"/>`, tblPrOrder)
+		var grid strings.Builder
+		grid.WriteString("<w:tblGrid>")
+		for _, w := range widths {
+			fmt.Fprintf(&grid, `<w:gridCol w:w="%d"/>`, int(float64(textWidth)*w/total))
+		}
+		grid.WriteString("</w:tblGrid>")
+		if loc := tableGridRe.FindStringIndex(table); loc != nil {
+			table = table[:loc[0]] + grid.String() + table[loc[1]:]
+		}
+	}
+	table = strings.Replace(table, tblPr, newPr, 1)
+
+	row := 0
+	return tableRowRe.ReplaceAllStringFunc(table, func(tr string) string {
+		defer func() { row++ }()
+		if row == 0 && f.RepeatHeader {
+			tr = setRowProperty(tr, "w:tblHeader", "<w:tblHeader/>")
+		}
+		shade := banding && row > 0 && (row-1)%2 == 0
+		if !shade && total == 0 {
+			return tr
+		}
+		col := 0
+		return tableCellRe.ReplaceAllStringFunc(tr, func(tc string) string {
+			defer func() { col++ }()
+			if total > 0 && col < len(widths) {
+				tc = setCellProperty(tc, "w:tcW", fmt.Sprintf(`<w:tcW w:w="%d" w:type="pct"/>`, int(5000*widths[col]/total)))
+			}
+			if shade {
+				tc = setCellProperty(tc, "w:shd", `<w:shd w:val="clear" w:color="auto" w:fill="`+f.BandFill+`"/>`)
+			}
+			return tc
+		})
+	})
+}
+
+// tableSpans 返回每个表格（含嵌套表格）在 content 中的起止位置，按起始位置排序。
+func tableSpans(content string) [][2]int {
+	var spans [][2]int
+	var stack []int
+	for _, loc := range tableTagRe.FindAllStringIndex(content, -1) {
+		if strings.HasPrefix(content[loc[0]:], "</") {
+			if len(stack) == 0 {
+				continue
+			}
+			start := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			spans = append(spans, [2]int{start, loc[1]})
+			continue
+		}
+		stack = append(stack, loc[0])
+	}
+	for i := 1; i < len(spans); i++ {
+		for j := i; j > 0 && spans[j][0] < spans[j-1][0]; j-- {
+			spans[j], spans[j-1] = spans[j-1], spans[j]
+		}
+	}
+	return spans
+}
+
+func setRowProperty(tr, name, element string) string {
+	if !strings.Contains(tr, "<w:trPr") {
+		open := tr[:strings.Index(tr, ">")+1]
+		return open + "<w:trPr>" + element + "</w:trPr>" + tr[len(open):]
+	}
+	trPr := elementRe("w:trPr").FindString(tr)
+	return strings.Replace(tr, trPr, setOrderedChild(expandEmpty(trPr, "w:trPr"), "w:trPr", name, element, trPrOrder), 1)
+}
+
+func setCellProperty(tc, name, element string) string {
+	if !strings.Contains(tc, "<w:tcPr") {
+		open := tc[:strings.Index(tc, ">")+1]
+		return open + "<w:tcPr>" + element + "</w:tcPr>" + tc[len(open):]
+	}
+	tcPr := elementRe("w:tcPr").FindString(tc)
+	return strings.Replace(tc, tcPr, setOrderedChild(expandEmpty(tcPr, "w:tcPr"), "w:tcPr", name, element, tcPrOrder), 1)
+}
+
+// expandEmpty 把自闭合的 <name/> 展开为 <name></name>，以便插入子元素。
+func expandEmpty(element, name string) string {
+	if strings.HasSuffix(element, "/>") {
+		return strings.TrimSuffix(strings.TrimSuffix(element, "/>"), " ") + "></" + name + ">"
+	}
+	return element
+}
+
+func removeBookmark(content, start, id string) string {
+	content = strings.Replace(content, start, "", 1)
+	return regexp.MustCompile(`<w:bookmarkEnd\s+w:id="`+regexp.QuoteMeta(id)+`"\s*/>`).ReplaceAllString(content, "")
+}
+
+// styleHasBanding 判断表格样式是否定义了条带行格式。
+func (p *Package) styleHasBanding(styleID string) bool {
+	if styleID == "" {
+		return false
+	}
+	data, _ := p.Part(PartStyles)
+	for _, style := range styleTagRe.FindAllString(string(data), -1) {
+		open := style[:strings.Index(style, ">")+1]
+		if attrs(open)["w:styleId"] == styleID {
+			return strings.Contains(style, `w:type="band1Horz"`)
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package docx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testTableStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:style w:type="table" w:styleId="GridTable4"><w:name w:val="Grid Table 4"/><w:tblStylePr w:type="band1Horz"><w:tcPr><w:shd w:val="clear" w:fill="DDDDDD"/></w:tcPr></w:tblStylePr></w:style><w:style w:type="table" w:styleId="Plain"><w:name w:val="Plain"/></w:style></w:styles>`
+
+// pandocTable 仿照 pandoc 输出的表格，表头第一个单元格可带列宽标记。
+func pandocTable(marker string) string {
+	cell := func(text string) string {
+		return `<w:tc><w:tcPr><w:tcW w:w="0" w:type="auto"/></w:tcPr><w:p><w:r><w:t>` + text + `</w:t></w:r></w:p></w:tc>`
+	}
+	return `<w:tbl><w:tblPr><w:tblStyle w:val="Table" /><w:tblW w:type="auto" w:w="0" /><w:tblLook w:firstRow="1" w:val="0420" /></w:tblPr>` +
+		`<w:tblGrid><w:gridCol /><w:gridCol /></w:tblGrid>` +
+		`<w:tr><w:trPr><w:tblHeader w:val="true" /></w:trPr><w:tc><w:p>` + marker + `<w:r><w:t>a</w:t></w:r></w:p></w:tc>` + cell("b") + `</w:tr>` +
+		`<w:tr>` + cell("1") + cell("2") + `</w:tr><w:tr>` + cell("3") + cell("4") + `</w:tr><w:tr>` + cell("5") + cell("6") + `</w:tr></w:tbl>`
+}
+
+func TestFormatTablesAppliesStyleHeaderBandingAndWidths(t *testing.T) {
+	box := `<w:tbl><w:tblPr><w:tblW w:w="5000" w:type="pct"/></w:tblPr><w:tblGrid><w:gridCol/></w:tblGrid><w:tr><w:tc>` + pandocTable("") + `<w:p/></w:tc></w:tr></w:tbl>`
+	marker := `<w:bookmarkStart w:id="200001" w:name="_TblW1"/><w:bookmarkEnd w:id="200001"/>`
+	doc := strings.Replace(testDocument, "<w:body>", "<w:body>"+pandocTable(marker)+box, 1)
+	pkg := reopen(t, writeTestDocx(t, map[string]string{PartDocument: doc, PartStyles: testTableStyles}))
+
+	require.NoError(t, pkg.FormatTables(TableFormat{
+		StyleID:      "Plain",
+		RepeatHeader: true,
+		Banded:       true,
+		BandFill:     "F2F2F2",
+		Widths:       map[string][]float64{"_TblW1": {1, 3}},
+	}))
+	got := part(t, pkg, PartDocument)
+	require.NotContains(t, got, "_TblW1")
+	require.NotContains(t, got, `w:val="Table"`)
+	require.Equal(t, 2, strings.Count(got, `<w:tblStyle w:val="Plain"/><w:tblW`))
+	require.Equal(t, 2, strings.Count(got, `w:noHBand="0" w:noVBand="1"/></w:tblPr>`))
+	require.Equal(t, 2, strings.Count(got, `<w:trPr><w:tblHeader/></w:trPr>`))
+	// 样式没有条带格式：第 1、3 个数据行加底纹。
+	require.Equal(t, 8, strings.Count(got, `w:fill="F2F2F2"`))
+	require.Contains(t, got, `<w:tc><w:tcPr><w:tcW w:w="1250" w:type="pct"/></w:tcPr><w:p><w:r><w:t>3</w:t>`)
+
+	// 只有带标记的表格设置列宽（版心 9360 twip 按 1:3 分配）；外层提示框表格保持不变。
+	require.Contains(t, got, `<w:tblW w:w="5000" w:type="pct"/><w:tblLook`)
+	require.Contains(t, got, `<w:tblGrid><w:gridCol w:w="2340"/><w:gridCol w:w="7020"/></w:tblGrid>`)
+	require.Contains(t, got, `<w:tcW w:w="1250" w:type="pct"/>`)
+	require.Contains(t, got, `<w:tcW w:w="3750" w:type="pct"/>`)
+	require.Contains(t, got, `<w:tbl><w:tblPr><w:tblW w:w="5000" w:type="pct"/></w:tblPr><w:tblGrid><w:gridCol/></w:tblGrid><w:tr><w:tc><w:tbl>`)
+}
+
+func TestFormatTablesUsesStyleBanding(t *testing.T) {
+	doc := strings.Replace(testDocument, "<w:body>", "<w:body>"+pandocTable(""), 1)
+	pkg := reopen(t, writeTestDocx(t, map[string]string{PartDocument: doc, PartStyles: testTableStyles}))
+	require.NoError(t, pkg.FormatTables(TableFormat{StyleID: "GridTable4", Banded: true, BandFill: "F2F2F2"}))
+	got := part(t, pkg, PartDocument)
+	require.Contains(t, got, `<w:tblStyle w:val="GridTable4"/>`)
+	require.NotContains(t, got, "F2F2F2")
+	// 未要求重复表头时保留 pandoc 原有的表头设置。
+	require.Contains(t, got, `<w:tblHeader w:val="true" />`)
+}
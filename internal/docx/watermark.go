@@ -0,0 +1,202 @@
+package docx
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+const (
+	NamespaceV = "urn:schemas-microsoft-com:vml"
+	NamespaceO = "urn:schemas-microsoft-com:office:office"
+)
+
+// watermarkShapeType 是 Word 插入文字水印时使用的艺术字形状定义。
+const watermarkShapeType = `<v:shapetype id="_x0000_t136" coordsize="21600,21600" o:spt="136" adj="10800" path="m@7,l@8,m@5,21600l@6,21600e">` +
+	`<v:formulas><v:f eqn="sum #0 0 10800"/><v:f eqn="prod #0 2 1"/><v:f eqn="sum 21600 0 @1"/><v:f eqn="sum 0 0 @2"/>` +
+	`<v:f eqn="sum 21600 0 @3"/><v:f eqn="if @0 @3 0"/><v:f eqn="if @0 21600 @1"/><v:f eqn="if @0 0 @2"/>` +
+	`<v:f eqn="if @0 @4 21600"/><v:f eqn="mid @5 @6"/><v:f eqn="mid @8 @5"/><v:f eqn="mid @7 @8"/>` +
+	`<v:f eqn="mid @6 @7"/><v:f eqn="sum @6 0 @5"/></v:formulas>` +
+	`<v:path textpathok="t" o:connecttype="custom" o:connectlocs="@9,0;@10,10800;@11,21600;@12,10800" o:connectangles="270,180,90,0"/>` +
+	`<v:textpath on="t" fitshape="t"/><v:handles><v:h position="#0,bottomRight" xrange="6629,14971"/></v:handles>` +
+	`<o:lock v:ext="edit" text="t" shapetype="t"/></v:shapetype>`
+
+var (
+	hfRefRe   = regexp.MustCompile(`<w:(header|footer)Reference\s[^>]*/>`)
+	titlePgOn = regexp.MustCompile(`<w:titlePg(?:\s+w:val="(?:1|true|on)")?\s*/>`)
+	evenOddOn = regexp.MustCompile(`<w:evenAndOddHeaders(?:\s+w:val="(?:1|true|on)")?\s*/>`)
+)
+
+// Watermark 是文字水印。Angle 为逆时针旋转角度（度），Opacity 取 (0,1]，Color 为 #RRGGBB 或 VML 颜色名。
+type Watermark struct {
+	Text    string
+	Font    string
+	Opacity float64
+	Angle   float64
+	Color   string
+}
+
+// MaterializeHeaderFooters 让每一节显式引用实际生效的页眉（或页脚）：缺少的引用按 Word 的继承规则沿用前一节，
+// 第一节缺少时新建空白部件。返回所有被引用的部件名（去重、按出现顺序）。
+func (p *Package) MaterializeHeaderFooters(footer bool) ([]string, error) {
+	if !p.Has(PartDocument) {
+		return nil, errMissingPart(PartDocument)
+	}
+	kind := headerFooterKind(footer)
+	settings, _ := p.Part(PartSettings)
+	evenOdd := evenOddOn.Match(settings)
+
+	sections := p.sections()
+	needed := make([]map[string]string, len(sections))
+	inherited := map[string]string{}
+	for i, sect := range sections {
+		refs := map[string]string{}
+		for _, tag := range hfRefRe.FindAllString(sect, -1) {
+			a := attrs(tag)
+			if strings.HasPrefix(tag, "<w:"+kind) {
+				refs[a["w:type"]] = a["r:id"]
+			}
+		}
+		types := []string{HeaderFooterDefault}
+		if titlePgOn.MatchString(sect) {
+			types = append(types, HeaderFooterFirst)
+		}
+		if evenOdd {
+			types = append(types, HeaderFooterEven)
+		}
+		missing := map[string]string{}
+		for _, typ := range types {
+			if refs[typ] != "" {
+				continue
+			}
+			id := inherited[typ]
+			if id == "" {
+				id = p.addHeaderFooterPart(footer, "<w:p/>")
+				inherited[typ] = id
+			}
+			missing[typ] = id
+		}
+		for typ, id := range refs {
+			inherited[typ] = id
+		}
+		needed[i] = missing
+	}
+
+	refName := "w:" + kind + "Reference"
+	err := p.EditSections(func(index int, sectPr string) string {
+		for _, typ := range []string{HeaderFooterEven, HeaderFooterFirst, HeaderFooterDefault} {
+			if id, ok := needed[index][typ]; ok {
+				sectPr = insertAfterOpen(sectPr, "w:sectPr", fmt.Sprintf(`<%s w:type="%s" r:id="%s"/>`, refName, typ, id))
+			}
+		}
+		return sectPr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]string{}
+	for _, r := range p.Relationships(PartDocumentRels) {
+		byID[r.ID] = ResolveTarget(PartDocument, r.Target)
+	}
+	parts := make([]string, 0)
+	seen := map[string]bool{}
+	for _, sect := range p.sections() {
+		for _, tag := range hfRefRe.FindAllString(sect, -1) {
+			if !strings.HasPrefix(tag, "<"+refName) {
+				continue
+			}
+			part := byID[attrs(tag)["r:id"]]
+			if part != "" && !seen[part] && p.Has(part) {
+				seen[part] = true
+				parts = append(parts, part)
+			}
+		}
+	}
+	return parts, nil
+}
+
+func (p *Package) sections() []string {
+	data, _ := p.Part(PartDocument)
+	sects := sectPrRe.FindAllString(string(data), -1)
+	if len(sects) == 0 {
+		return []string{"<w:sectPr/>"}
+	}
+	return sects
+}
+
+// AddWatermark 在每一节实际生效的所有页眉中插入文字水印。
+func (p *Package) AddWatermark(w Watermark) error {
+	parts, err := p.MaterializeHeaderFooters(false)
+	if err != nil {
+		return err
+	}
+	widthPt := float64(p.TextWidth()) / 20
+	for i, part := range parts {
+		data, _ := p.Part(part)
+		content := string(data)
+		content = ensureNamespace(content, "w:hdr", "v", NamespaceV)
+		content = ensureNamespace(content, "w:hdr", "o", NamespaceO)
+		run := watermarkRun(w, i+1, widthPt)
+		if loc := paragraphRe.FindStringIndex(content); loc != nil {
+			para := content[loc[0]:loc[1]]
+			content = content[:loc[0]] + insertBeforeClose(para, "w:p", run) + content[loc[1]:]
+		} else {
+			content = insertBeforeClose(content, "w:hdr", "<w:p>"+run+"</w:p>")
+		}
+		p.SetPart(part, []byte(content))
+	}
+	return nil
+}
+
+func watermarkRun(w Watermark, n int, widthPt float64) string {
+	// 艺术字会拉伸到形状大小，按文本宽度（全角字符计 2）估算高宽比以免字形变形。
+	units := 0
+	for _, r := range w.Text {
+		if r < 0x2E80 {
+			units++
+		} else {
+			units += 2
+		}
+	}
+	aspect := math.Max(float64(units)*0.55, 1)
+	heightPt := widthPt / aspect
+	rotation := math.Mod(360-w.Angle, 360)
+	if rotation < 0 {
+		rotation += 360
+	}
+	font := w.Font
+	if font == "" {
+		font = "Calibri"
+	}
+	shape := fmt.Sprintf(`<v:shape id="PowerPlusWaterMarkObject%d" o:spid="_x0000_s%d" type="#_x0000_t136" `+
+		`style="position:absolute;margin-left:0;margin-top:0;width:%.1fpt;height:%.1fpt;rotation:%g;z-index:-251654144;`+
+		`mso-position-horizontal:center;mso-position-horizontal-relative:margin;mso-position-vertical:center;mso-position-vertical-relative:margin" `+
+		`o:allowincell="f" fillcolor="%s" stroked="f"><v:fill opacity="%g"/>`+
+		`<v:textpath style="font-family:&quot;%s&quot;;font-size:1pt" string="%s"/></v:shape>`,
+		n, 2048+n, widthPt, heightPt, rotation, escapeXML(w.Color), w.Opacity, escapeXML(font), escapeXML(w.Text))
+	return `<w:r><w:rPr><w:noProof/></w:rPr><w:pict>` + watermarkShapeType + shape + `</w:pict></w:r>`
+}
+
+// AddMarking 在每一节实际生效的所有页眉顶部与页脚底部插入居中的标识段落（如密级）。
+func (p *Package) AddMarking(text, rPr string) error {
+	para := `<w:p><w:pPr><w:jc w:val="center"/></w:pPr>` + TextRun(text, rPr) + `</w:p>`
+	for _, footer := range []bool{false, true} {
+		parts, err := p.MaterializeHeaderFooters(footer)
+		if err != nil {
+			return err
+		}
+		for _, part := range parts {
+			data, _ := p.Part(part)
+			content := string(data)
+			if footer {
+				content = insertBeforeClose(content, "w:ftr", para)
+			} else {
+				content = insertAfterOpen(content, "w:hdr", para)
+			}
+			p.SetPart(part, []byte(content))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package docx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaterializeHeaderFootersFollowsInheritance(t *testing.T) {
+	doc := strings.Replace(testDocument, "<w:body>", `<w:body><w:p><w:pPr><w:sectPr><w:titlePg/></w:sectPr></w:pPr></w:p>`, 1)
+	doc = strings.Replace(doc, `<w:sectPr><w:pgSz`, `<w:sectPr><w:headerReference w:type="default" r:id="rId5"/><w:pgSz`, 1)
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId5" Type="` + RelTypeHeader + `" Target="header1.xml"/></Relationships>`
+	pkg := reopen(t, writeTestDocx(t, map[string]string{
+		PartDocument:        doc,
+		PartDocumentRels:    rels,
+		"word/header1.xml":  `<w:hdr xmlns:w="` + NamespaceW + `"><w:p/></w:hdr>`,
+		"word/settings.xml": `<w:settings xmlns:w="` + NamespaceW + `"><w:evenAndOddHeaders/></w:settings>`,
+	}))
+
+	parts, err := pkg.MaterializeHeaderFooters(false)
+	require.NoError(t, err)
+	// 第一节缺少 default/first/even 三种页眉，各新建一个；第二节的 even 沿用第一节。
+	require.Equal(t, []string{"word/header2.xml", "word/header3.xml", "word/header4.xml", "word/header1.xml"}, parts)
+	sects := pkg.sections()
+	require.Len(t, sects, 2)
+	require.Equal(t, 3, strings.Count(sects[0], "w:headerReference"))
+	require.Contains(t, sects[1], `<w:headerReference w:type="even" r:id="rId8"/><w:headerReference w:type="default" r:id="rId5"/>`)
+	require.NotContains(t, sects[1], `w:type="first"`)
+}
+
+func TestAddWatermarkAndMarking(t *testing.T) {
+	path := writeTestDocx(t, nil)
+	pkg := reopen(t, path)
+	require.NoError(t, pkg.AddMarking("CONFIDENTIAL", "<w:b/>"))
+	require.NoError(t, pkg.AddWatermark(Watermark{Text: "DRAFT", Opacity: 0.3, Angle: 45, Color: "#FF0000"}))
+	require.NoError(t, pkg.Save())
+
+	pkg = reopen(t, path)
+	header := part(t, pkg, "word/header1.xml")
+	require.Contains(t, header, `xmlns:v="`+NamespaceV+`"`)
+	require.Contains(t, header, `xmlns:o="`+NamespaceO+`"`)
+	require.True(t, strings.HasPrefix(header[strings.Index(header, "<w:p>"):], `<w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">CONFIDENTIAL</w:t></w:r><w:r><w:rPr><w:noProof/></w:rPr><w:pict><v:shapetype`))
+	require.Contains(t, header, `rotation:315;`)
+	require.Contains(t, header, `fillcolor="#FF0000" stroked="f"><v:fill opacity="0.3"/>`)
+	require.Contains(t, header, `string="DRAFT"`)
+	require.Contains(t, header, `width:468.0pt;height:170.2pt`)
+
+	footer := part(t, pkg, "word/footer1.xml")
+	require.Contains(t, footer, `<w:p/><w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">CONFIDENTIAL</w:t></w:r></w:p></w:ftr>`)
+	require.NotContains(t, footer, "v:shape")
+}
@@ -0,0 +1,184 @@
+package docx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	NamespaceW = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	NamespaceR = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+// sectPrOrder 是 w:sectPr 子元素在 schema 中的顺序（页眉页脚引用之后）。
+var sectPrOrder = []string{
+	"w:footnotePr", "w:endnotePr", "w:type", "w:pgSz", "w:pgMar", "w:paperSrc", "w:pgBorders",
+	"w:lnNumType", "w:pgNumType", "w:cols", "w:formProt", "w:vAlign", "w:noEndnote", "w:titlePg",
+	"w:textDirection", "w:bidi", "w:rtlGutter", "w:docGrid", "w:printerSettings", "w:sectPrChange",
+}
+
+// settingsOrder 是 w:settings 子元素在 schema 中的顺序。
+var settingsOrder = []string{
+	"w:writeProtection", "w:view", "w:zoom", "w:removePersonalInformation", "w:removeDateAndTime",
+	"w:doNotDisplayPageBoundaries", "w:displayBackgroundShape", "w:printPostScriptOverText",
+	"w:printFractionalCharacterWidth", "w:printFormsData", "w:embedTrueTypeFonts", "w:embedSystemFonts",
+	"w:saveSubsetFonts", "w:saveFormsData", "w:mirrorMargins", "w:alignBordersAndEdges",
+	"w:bordersDoNotSurroundHeader", "w:bordersDoNotSurroundFooter", "w:gutterAtTop", "w:hideSpellingErrors",
+	"w:hideGrammaticalErrors", "w:activeWritingStyle", "w:proofState", "w:formsDesign", "w:attachedTemplate",
+	"w:linkStyles", "w:stylePaneFormatFilter", "w:stylePaneSortMethod", "w:documentType", "w:mailMerge",
+	"w:revisionView", "w:trackRevisions", "w:doNotTrackMoves", "w:doNotTrackFormatting", "w:documentProtection",
+	"w:autoFormatOverride", "w:styleLockTheme", "w:styleLockQFSet", "w:defaultTabStop", "w:autoHyphenation",
+	"w:consecutiveHyphenLimit", "w:hyphenationZone", "w:doNotHyphenateCaps", "w:showEnvelope",
+	"w:summaryLength", "w:clickAndTypeStyle", "w:defaultTableStyle", "w:evenAndOddHeaders",
+	"w:bookFoldRevPrinting", "w:bookFoldPrinting", "w:bookFoldPrintingSheets",
+	"w:drawingGridHorizontalSpacing", "w:drawingGridVerticalSpacing", "w:displayHorizontalDrawingGridEvery",
+	"w:displayVerticalDrawingGridEvery", "w:doNotUseMarginsForDrawingGridOrigin",
+	"w:drawingGridHorizontalOrigin", "w:drawingGridVerticalOrigin", "w:doNotShadeFormData",
+	"w:noPunctuationKerning", "w:characterSpacingControl", "w:printTwoOnOne", "w:strictFirstAndLastChars",
+	"w:noLineBreaksAfter", "w:noLineBreaksBefore", "w:savePreviewPicture", "w:doNotValidateAgainstSchema",
+	"w:saveInvalidXml", "w:ignoreMixedContent", "w:alwaysShowPlaceholderText", "w:doNotDemarcateInvalidXml",
+	"w:saveXmlDataOnly", "w:useXSLTWhenSaving", "w:saveThroughXslt", "w:showXMLTags",
+	"w:alwaysMergeEmptyNamespace", "w:updateFields", "w:hdrShapeDefaults", "w:footnotePr", "w:endnotePr",
+	"w:compat", "w:docVars", "w:rsids", "m:mathPr", "w:attachedSchema", "w:themeFontLang",
+	"w:clrSchemeMapping", "w:doNotIncludeSubdocsInStats", "w:doNotAutoCompressPictures", "w:forceUpgrade",
+	"w:captions", "w:readModeInkLockDown", "w:smartTagType", "sl:schemaLibrary", "w:shapeDefaults",
+	"w:doNotEmbedSmartTags", "w:decimalSymbol", "w:listSeparator",
+}
+
+const emptySettings = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:settings xmlns:w="` + NamespaceW + `"></w:settings>`
+
+var (
+	sectPrRe   = regexp.MustCompile(`(?s)<w:sectPr(?:\s[^>]*)?/>|<w:sectPr(?:\s[^>]*)?>.*?</w:sectPr>`)
+	pgSzWRe    = regexp.MustCompile(`<w:pgSz\s[^>]*w:w="(\d+)"`)
+	pgMarLRe   = regexp.MustCompile(`<w:pgMar\s[^>]*w:left="(\d+)"`)
+	pgMarRRe   = regexp.MustCompile(`<w:pgMar\s[^>]*w:right="(\d+)"`)
+	styleTagRe = regexp.MustCompile(`(?s)<w:style\s[^>]*>.*?</w:style>`)
+)
+
+// elementRe 匹配名为 name 的元素（自闭合或带内容，不处理同名嵌套）。
+func elementRe(name string) *regexp.Regexp {
+	q := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`(?s)<` + q + `(?:\s[^>]*)?/>|<` + q + `(?:\s[^>]*)?>.*?</` + q + `>`)
+}
+
+// setOrderedChild 在 parent 元素内替换或按 schema 顺序插入子元素 element（其名为 name）。
+func setOrderedChild(content, parent, name, element string, order []string) string {
+	if loc := elementRe(name).FindStringIndex(content); loc != nil {
+		return content[:loc[0]] + element + content[loc[1]:]
+	}
+	after := false
+	for _, follower := range order {
+		if follower == name {
+			after = true
+			continue
+		}
+		if !after {
+			continue
+		}
+		if loc := regexp.MustCompile(`<` + regexp.QuoteMeta(follower) + `[\s/>]`).FindStringIndex(content); loc != nil {
+			return content[:loc[0]] + element + content[loc[0]:]
+		}
+	}
+	return insertBeforeClose(content, parent, element)
+}
+
+// EditSections 依次改写 document.xml 中所有 w:sectPr（含段落内的分节符），index 为节序号（从 0 开始）。
+func (p *Package) EditSections(fn func(index int, sectPr string) string) error {
+	data, ok := p.Part(PartDocument)
+	if !ok {
+		return errMissingPart(PartDocument)
+	}
+	content := string(data)
+	if !sectPrRe.MatchString(content) {
+		content = insertBeforeClose(content, "w:body", "<w:sectPr></w:sectPr>")
+	}
+	index := 0
+	content = sectPrRe.ReplaceAllStringFunc(content, func(sectPr string) string {
+		out := fn(index, sectPr)
+		index++
+		return out
+	})
+	p.SetPart(PartDocument, []byte(content))
+	return nil
+}
+
+// SetSectionChild 在 sectPr 中设置子元素，保持 schema 顺序。
+func SetSectionChild(sectPr, name, element string) string {
+	return setOrderedChild(sectPr, "w:sectPr", name, element, sectPrOrder)
+}
+
+// RemoveElement 删除 content 中所有名为 name 且满足 match 的元素。
+func RemoveElement(content, name string, match func(element string) bool) string {
+	return elementRe(name).ReplaceAllStringFunc(content, func(el string) string {
+		if match == nil || match(el) {
+			return ""
+		}
+		return el
+	})
+}
+
+// SetSetting 在 settings.xml 中设置（替换或按顺序插入）一个元素；settings.xml 缺失时创建。
+func (p *Package) SetSetting(name, element string) {
+	data, ok := p.Part(PartSettings)
+	if !ok {
+		data = []byte(emptySettings)
+		p.EnsureOverride(PartSettings, ContentTypeSettings)
+		p.AddRelationship(PartDocumentRels, RelTypeSettings, "settings.xml")
+	}
+	p.SetPart(PartSettings, []byte(setOrderedChild(string(data), "w:settings", name, element, settingsOrder)))
+}
+
+// TextWidth 返回正文最后一节的版心宽度（twip）；无法解析时返回 A4 默认值。
+func (p *Package) TextWidth() int {
+	data, _ := p.Part(PartDocument)
+	sects := sectPrRe.FindAllString(string(data), -1)
+	if len(sects) == 0 {
+		return 8306
+	}
+	last := sects[len(sects)-1]
+	width := atoiMatch(pgSzWRe, last, 11906)
+	return width - atoiMatch(pgMarLRe, last, 1800) - atoiMatch(pgMarRRe, last, 1800)
+}
+
+// StyleID 按样式显示名（不区分大小写）查找 styleId；模板中的 styleId 可能被本地化，不能直接假定。
+func (p *Package) StyleID(name string) string {
+	data, ok := p.Part(PartStyles)
+	if !ok {
+		return ""
+	}
+	want := strings.ToLower(name)
+	for _, style := range styleTagRe.FindAllString(string(data), -1) {
+		open := style[:strings.Index(style, ">")+1]
+		id := attrs(open)["w:styleId"]
+		if strings.EqualFold(id, name) {
+			return id
+		}
+		if m := regexp.MustCompile(`<w:name\s+w:val="([^"]*)"`).FindStringSubmatch(style); m != nil && strings.ToLower(unescapeXML(m[1])) == want {
+			return id
+		}
+	}
+	return ""
+}
+
+// EnsureDocumentNamespace 确保 document.xml 根元素声明了 prefix 命名空间。
+func (p *Package) EnsureDocumentNamespace(prefix, uri string) {
+	data, ok := p.Part(PartDocument)
+	if !ok {
+		return
+	}
+	p.SetPart(PartDocument, []byte(ensureNamespace(string(data), "w:document", prefix, uri)))
+}
+
+func atoiMatch(re *regexp.Regexp, s string, fallback int) int {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return fallback
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,298 @@
+// Package fonts 扫描字体目录，按字体族名查找 TrueType/OpenType 字体文件。
+package fonts
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Face 是字体文件中的一个字体；TTC 集合中的每个字体单独一项。
+type Face struct {
+	Path string
+	// Index 为 TTC 集合中的序号，单个字体文件为 0。
+	Index int
+	// Names 为字体族名与完整名（含各语言的本地化名称，如“宋体”与 SimSun）。
+	Names  []string
+	Bold   bool
+	Italic bool
+	// Embeddable 表示 OS/2 fsType 未声明受限许可，允许嵌入文档。
+	Embeddable bool
+	// TrueType 表示轮廓为 TrueType；CFF 轮廓（OTTO）的 OpenType 字体无法嵌入 Word。
+	TrueType bool
+}
+
+// Index 是若干目录中全部字体的索引。
+type Index struct {
+	faces []Face
+}
+
+var (
+	cacheMu sync.Mutex
+	// cache 按目录缓存扫描结果，同一进程内每个目录只扫描一次。
+	cache = map[string][]Face{}
+)
+
+// Scan 递归扫描 dirs 中的 .ttf、.otf、.ttc 文件；不存在或无法读取的目录与文件被忽略。
+func Scan(dirs ...string) *Index {
+	idx := &Index{}
+	for _, dir := range dirs {
+		idx.faces = append(idx.faces, scanDir(dir)...)
+	}
+	return idx
+}
+
+func scanDir(dir string) []Face {
+	dir = filepath.Clean(dir)
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if faces, ok := cache[dir]; ok {
+		return faces
+	}
+	faces := make([]Face, 0)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ttf", ".otf", ".ttc":
+			if found, err := ParseFile(path); err == nil {
+				faces = append(faces, found...)
+			}
+		}
+		return nil
+	})
+	cache[dir] = faces
+	return faces
+}
+
+// Lookup 返回族名或完整名为 name（不区分大小写）的全部字体，按路径排序。
+func (x *Index) Lookup(name string) []Face {
+	want := strings.ToLower(strings.TrimSpace(name))
+	out := make([]Face, 0)
+	for _, f := range x.faces {
+		for _, n := range f.Names {
+			if strings.ToLower(n) == want {
+				out = append(out, f)
+				break
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// SystemDirs 返回当前平台的系统与用户字体目录。
+func SystemDirs() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		dirs := []string{filepath.Join(os.Getenv("WINDIR"), "Fonts")}
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			dirs = append(dirs, filepath.Join(local, "Microsoft", "Windows", "Fonts"))
+		}
+		return dirs
+	case "darwin":
+		return []string{"/System/Library/Fonts", "/Library/Fonts", filepath.Join(home, "Library", "Fonts")}
+	}
+	return []string{"/usr/share/fonts", "/usr/local/share/fonts", filepath.Join(home, ".fonts"), filepath.Join(home, ".local", "share", "fonts")}
+}
+
+var errNotFont = errors.New("不是 TrueType/OpenType 字体")
+
+// ParseFile 读取字体文件的名称、样式与嵌入许可。
+func ParseFile(path string) ([]Face, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := faceOffsets(data)
+	if err != nil {
+		return nil, err
+	}
+	faces := make([]Face, 0, len(offsets))
+	for i, off := range offsets {
+		f, err := parseFace(data, off)
+		if err != nil {
+			return nil, err
+		}
+		f.Path, f.Index = path, i
+		faces = append(faces, f)
+	}
+	return faces, nil
+}
+
+// Data 返回可单独使用的字体数据；TTC 集合中的字体会被抽取为独立的字体文件。
+func (f Face) Data() ([]byte, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := faceOffsets(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.Index >= len(offsets) {
+		return nil, errNotFont
+	}
+	if string(data[:4]) != "ttcf" {
+		return data, nil
+	}
+	return extractFace(data, offsets[f.Index])
+}
+
+func faceOffsets(data []byte) ([]int, error) {
+	if len(data) < 12 {
+		return nil, errNotFont
+	}
+	switch tag := string(data[:4]); tag {
+	case "ttcf":
+		n := int(binary.BigEndian.Uint32(data[8:]))
+		if n <= 0 || len(data) < 12+4*n {
+			return nil, errNotFont
+		}
+		out := make([]int, n)
+		for i := range out {
+			out[i] = int(binary.BigEndian.Uint32(data[12+4*i:]))
+		}
+		return out, nil
+	case "\x00\x01\x00\x00", "OTTO", "true":
+		return []int{0}, nil
+	}
+	return nil, errNotFont
+}
+
+type tableRecord struct {
+	tag            string
+	offset, length int
+	checksum       uint32
+}
+
+func tables(data []byte, off int) ([]tableRecord, error) {
+	if off+12 > len(data) {
+		return nil, errNotFont
+	}
+	n := int(binary.BigEndian.Uint16(data[off+4:]))
+	if off+12+16*n > len(data) {
+		return nil, errNotFont
+	}
+	out := make([]tableRecord, n)
+	for i := range out {
+		rec := data[off+12+16*i:]
+		out[i] = tableRecord{
+			tag:      string(rec[:4]),
+			checksum: binary.BigEndian.Uint32(rec[4:]),
+			offset:   int(binary.BigEndian.Uint32(rec[8:])),
+			length:   int(binary.BigEndian.Uint32(rec[12:])),
+		}
+		if out[i].offset+out[i].length > len(data) {
+			return nil, errNotFont
+		}
+	}
+	return out, nil
+}
+
+func parseFace(data []byte, off int) (Face, error) {
+	recs, err := tables(data, off)
+	if err != nil {
+		return Face{}, err
+	}
+	f := Face{Embeddable: true, TrueType: string(data[off:off+4]) != "OTTO"}
+	for _, t := range recs {
+		table := data[t.offset : t.offset+t.length]
+		switch t.tag {
+		case "name":
+			f.Names = parseNames(table)
+		case "head":
+			if len(table) >= 46 {
+				style := binary.BigEndian.Uint16(table[44:])
+				f.Bold, f.Italic = style&1 != 0, style&2 != 0
+			}
+		case "OS/2":
+			// fsType 低 4 位为 2 表示受限许可，不允许嵌入。
+			if len(table) >= 10 && binary.BigEndian.Uint16(table[8:])&0x000F == 0x0002 {
+				f.Embeddable = false
+			}
+		}
+	}
+	if len(f.Names) == 0 {
+		return Face{}, errNotFont
+	}
+	return f, nil
+}
+
+// parseNames 读取 name 表中的族名（1、16）与完整名（4），去重。
+func parseNames(table []byte) []string {
+	if len(table) < 6 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	storage := int(binary.BigEndian.Uint16(table[4:]))
+	seen := map[string]bool{}
+	var names []string
+	for i := 0; i < count && 6+12*(i+1) <= len(table); i++ {
+		rec := table[6+12*i:]
+		platform := binary.BigEndian.Uint16(rec[0:])
+		encoding := binary.BigEndian.Uint16(rec[2:])
+		id := binary.BigEndian.Uint16(rec[6:])
+		length := int(binary.BigEndian.Uint16(rec[8:]))
+		start := storage + int(binary.BigEndian.Uint16(rec[10:]))
+		if id != 1 && id != 4 && id != 16 || start+length > len(table) {
+			continue
+		}
+		raw := table[start : start+length]
+		var name string
+		switch {
+		case platform == 0 || platform == 3:
+			name = decodeUTF16(raw)
+		case platform == 1 && encoding == 0:
+			name = string(raw)
+		default:
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// extractFace 把 TTC 集合中位于 off 的字体复制为独立的字体文件，表数据按 4 字节对齐。
+func extractFace(data []byte, off int) ([]byte, error) {
+	recs, err := tables(data, off)
+	if err != nil {
+		return nil, err
+	}
+	header := 12 + 16*len(recs)
+	out := make([]byte, header, header+len(data)/2)
+	copy(out, data[off:off+12])
+	for i, t := range recs {
+		rec := out[12+16*i:]
+		copy(rec, t.tag)
+		binary.BigEndian.PutUint32(rec[4:], t.checksum)
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(t.length))
+		out = append(out, data[t.offset:t.offset+t.length]...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,134 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTable struct {
+	tag  string
+	data []byte
+}
+
+// testFace 生成只含 name、head、OS/2 表的最小字体；names 按 Windows 平台写入族名（nameID 1）。
+func testFace(names []string, macStyle, fsType uint16) []testTable {
+	var storage []byte
+	records := make([]byte, 0)
+	for _, n := range names {
+		u := utf16.Encode([]rune(n))
+		rec := make([]byte, 12)
+		binary.BigEndian.PutUint16(rec[0:], 3)
+		binary.BigEndian.PutUint16(rec[2:], 1)
+		binary.BigEndian.PutUint16(rec[4:], 0x0409)
+		binary.BigEndian.PutUint16(rec[6:], 1)
+		binary.BigEndian.PutUint16(rec[8:], uint16(2*len(u)))
+		binary.BigEndian.PutUint16(rec[10:], uint16(len(storage)))
+		records = append(records, rec...)
+		for _, c := range u {
+			storage = binary.BigEndian.AppendUint16(storage, c)
+		}
+	}
+	name := make([]byte, 6)
+	binary.BigEndian.PutUint16(name[2:], uint16(len(names)))
+	binary.BigEndian.PutUint16(name[4:], uint16(6+len(records)))
+	name = append(append(name, records...), storage...)
+
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[44:], macStyle)
+	os2 := make([]byte, 10)
+	binary.BigEndian.PutUint16(os2[8:], fsType)
+	return []testTable{{"OS/2", os2}, {"head", head}, {"name", name}}
+}
+
+// buildFont 把一个或多个字体写成 .ttf（单个）或 .ttc（多个）文件内容。
+func buildFont(version string, faces ...[]testTable) []byte {
+	header := 0
+	if len(faces) > 1 {
+		header = 12 + 4*len(faces)
+	}
+	dirs := header
+	for _, f := range faces {
+		dirs += 12 + 16*len(f)
+	}
+	out := make([]byte, dirs)
+	if len(faces) > 1 {
+		copy(out, "ttcf")
+		binary.BigEndian.PutUint32(out[4:], 0x00010000)
+		binary.BigEndian.PutUint32(out[8:], uint32(len(faces)))
+	}
+	at := header
+	for i, f := range faces {
+		if len(faces) > 1 {
+			binary.BigEndian.PutUint32(out[12+4*i:], uint32(at))
+		}
+		copy(out[at:], version)
+		binary.BigEndian.PutUint16(out[at+4:], uint16(len(f)))
+		for j, t := range f {
+			rec := out[at+12+16*j:]
+			copy(rec, t.tag)
+			binary.BigEndian.PutUint32(rec[8:], uint32(len(out)))
+			binary.BigEndian.PutUint32(rec[12:], uint32(len(t.data)))
+			out = append(out, t.data...)
+			for len(out)%4 != 0 {
+				out = append(out, 0)
+			}
+		}
+		at += 12 + 16*len(f)
+	}
+	return out
+}
+
+func TestScanAndLookup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.ttf"), buildFont("\x00\x01\x00\x00", testFace([]string{"Acme Sans"}, 0, 0)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "a-bi.ttf"), buildFont("\x00\x01\x00\x00", testFace([]string{"Acme Sans"}, 3, 2)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.otf"), buildFont("OTTO", testFace([]string{"Acme Serif"}, 0, 0)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "song.ttc"), buildFont("\x00\x01\x00\x00",
+		testFace([]string{"SimSun", "宋体"}, 0, 0), testFace([]string{"NSimSun", "新宋体"}, 0, 0)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.ttf"), []byte("nope"), 0o644))
+
+	idx := Scan(dir, filepath.Join(dir, "missing"))
+
+	sans := idx.Lookup("acme sans")
+	require.Len(t, sans, 2)
+	require.Equal(t, filepath.Join(dir, "a.ttf"), sans[0].Path)
+	require.True(t, sans[0].Embeddable && sans[0].TrueType)
+	require.False(t, sans[0].Bold || sans[0].Italic)
+	require.True(t, sans[1].Bold && sans[1].Italic)
+	require.False(t, sans[1].Embeddable)
+
+	serif := idx.Lookup("Acme Serif")
+	require.Len(t, serif, 1)
+	require.False(t, serif[0].TrueType)
+
+	song := idx.Lookup("新宋体")
+	require.Len(t, song, 1)
+	require.Equal(t, 1, song[0].Index)
+	require.Equal(t, []string{"NSimSun", "新宋体"}, song[0].Names)
+	require.Empty(t, idx.Lookup("Missing"))
+}
+
+func TestFaceDataExtractsCollectionMember(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "song.ttc")
+	require.NoError(t, os.WriteFile(path, buildFont("\x00\x01\x00\x00",
+		testFace([]string{"SimSun"}, 0, 0), testFace([]string{"NSimSun"}, 1, 0)), 0o644))
+
+	data, err := Face{Path: path, Index: 1}.Data()
+	require.NoError(t, err)
+	require.Equal(t, "\x00\x01\x00\x00", string(data[:4]))
+
+	single := filepath.Join(dir, "nsimsun.ttf")
+	require.NoError(t, os.WriteFile(single, data, 0o644))
+	faces, err := ParseFile(single)
+	require.NoError(t, err)
+	require.Len(t, faces, 1)
+	require.Equal(t, []string{"NSimSun"}, faces[0].Names)
+	require.True(t, faces[0].Bold)
+}
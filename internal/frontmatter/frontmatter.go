@@ -0,0 +1,115 @@
+package frontmatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document 是拆分 YAML front matter 后的 Markdown。
+type Document struct {
+	Meta map[string]any
+	Body string
+	// BodyLine 是正文第一行在原文件中的行号（从 1 开始），用于把诊断映射回源文件。
+	BodyLine int
+}
+
+// Split 识别文件开头以 --- 包围的 YAML front matter；仅当内容能解析为映射时才视为 front matter，
+// 否则原样返回（开头的 --- 也可能只是分隔线）。
+func Split(content string) Document {
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	normalized = strings.TrimPrefix(normalized, "\ufeff")
+	doc := Document{Body: content, BodyLine: 1}
+	if !strings.HasPrefix(normalized, "---\n") {
+		return doc
+	}
+	lines := strings.Split(normalized, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimRight(lines[i], " \t")
+		if trimmed == "---" || trimmed == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return doc
+	}
+	meta := map[string]any{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "\n")), &meta); err != nil || len(meta) == 0 {
+		return doc
+	}
+	doc.Meta = meta
+	doc.Body = strings.Join(lines[end+1:], "\n")
+	doc.BodyLine = end + 2
+	return doc
+}
+
+// String 把标量或标量列表格式化为字符串；列表按 sep 连接。
+func String(v any, sep string) (string, bool) {
+	switch val := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return val, true
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			s, ok := String(item, sep)
+			if !ok {
+				return "", false
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, sep), true
+	case map[string]any:
+		return "", false
+	case time.Time:
+		// YAML 中未加引号的日期会被解析为时间；没有时刻部分时按日期输出。
+		if val.Equal(val.Truncate(24*time.Hour)) && val.Location() == time.UTC {
+			return val.Format("2006-01-02"), true
+		}
+		return val.Format(time.RFC3339), true
+	default:
+		return fmt.Sprint(val), true
+	}
+}
+
+// Lookup 按点号路径读取嵌套字段，如 "properties.company"。
+func Lookup(meta map[string]any, path string) (any, bool) {
+	var cur any = meta
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// StringMap 读取映射字段并把其中的标量值转为字符串，非标量值忽略。
+func StringMap(meta map[string]any, key string) map[string]string {
+	raw, ok := meta[key].(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(raw))
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if s, ok := String(raw[k], ", "); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
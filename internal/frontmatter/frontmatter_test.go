@@ -0,0 +1,34 @@
+package frontmatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	doc := Split("---\ntitle: Spec\nauthor: [Alice, Bob]\nproperties:\n  company: ACME\n---\n# Body\n")
+	require.Equal(t, "Spec", doc.Meta["title"])
+	require.Equal(t, "# Body\n", doc.Body)
+	require.Equal(t, 7, doc.BodyLine)
+
+	author, ok := String(doc.Meta["author"], "; ")
+	require.True(t, ok)
+	require.Equal(t, "Alice; Bob", author)
+	require.Equal(t, map[string]string{"company": "ACME"}, StringMap(doc.Meta, "properties"))
+
+	v, ok := Lookup(doc.Meta, "properties.company")
+	require.True(t, ok)
+	require.Equal(t, "ACME", v)
+}
+
+func TestSplitKeepsThematicBreak(t *testing.T) {
+	in := "---\nnot yaml: [\n---\ntext\n"
+	doc := Split(in)
+	require.Nil(t, doc.Meta)
+	require.Equal(t, in, doc.Body)
+	require.Equal(t, 1, doc.BodyLine)
+
+	doc = Split("# no front matter\n")
+	require.Nil(t, doc.Meta)
+}
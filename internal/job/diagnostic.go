@@ -0,0 +1,25 @@
+package job
+
+const (
+	SeverityError = "error"
+	SeverityWarn  = "warn"
+)
+
+// Diagnostic 是定位到源文件行号的结构化问题（lint、预处理、pandoc 告警解析等共用）。
+type Diagnostic struct {
+	Source   string
+	Line     int
+	Severity string
+	Code     string
+	Message  string
+}
+
+func CountErrors(diags []Diagnostic) int {
+	n := 0
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,34 @@
+package job
+
+import "errors"
+
+// TransientError 标记可重试的临时性失败（进程被信号终止、资源耗尽、临时文件冲突等）。
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string {
+	if e == nil || e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *TransientError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TransientError{Err: err}
+}
+
+func IsTransient(err error) bool {
+	var te *TransientError
+	return errors.As(err, &te)
+}
@@ -3,10 +3,28 @@ package job
 type Task struct {
 	SourcePath string
 	TargetPath string
+	// Targets 是本批次源文件到目标 docx 的映射（同批任务共享、只读），用于改写文档间链接；为空时只处理指向本文件的链接。
+	Targets map[string]string
 }
 
 type Result struct {
 	Task     Task
 	Warnings []string
+	// Diagnostics 是转换过程中定位到源文件行号的结构化告警（如未解析的引用键）。
+	Diagnostics []Diagnostic
+	// Includes 是文档通过包含指令引用的文件（绝对路径），即源文件之外的全部依赖。
+	Includes []string
+	// Fonts 是输出文档引用的字体及查找结果，仅在 verbose 或嵌入字体时填写。
+	Fonts    []FontUsage
 	Error    error
+	Attempts int
+	NotRun   bool
+}
+
+// FontUsage 是输出文档引用的一个字体及其查找结果。
+type FontUsage struct {
+	Name string
+	// Path 为找到的字体文件；为空表示字体目录与系统中都没有该字体，打开文档时会被替换。
+	Path     string
+	Embedded bool
 }
@@ -0,0 +1,260 @@
+package lint
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const (
+	RuleUnclosedFence = "unclosed-fence"
+	RuleBrokenLink    = "broken-link"
+	RuleMissingImage  = "missing-image"
+	RuleHeadingJump   = "heading-jump"
+	RuleTableColumns  = "table-columns"
+	RuleReadFailed    = "read-failed"
+)
+
+var (
+	inlineLinkRe    = regexp.MustCompile(`(!?)\[[^\]]*\]\(\s*(<[^>]*>|[^)\s]+)(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	refDefinitionRe = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*(<[^>]*>|\S+)`)
+	htmlImageRe     = regexp.MustCompile(`(?i)<img\s[^>]*src\s*=\s*["']([^"']+)["']`)
+	atxHeadingRe    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]|$)`)
+	delimiterRowRe  = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	schemeRe        = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// CheckFile 读取并检查单个 Markdown 文件；读取失败时以 error 级诊断返回。
+func CheckFile(path string) []job.Diagnostic {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []job.Diagnostic{{
+			Source:   path,
+			Severity: job.SeverityError,
+			Code:     RuleReadFailed,
+			Message:  fmt.Sprintf("读取 Markdown 源文件失败：%v", err),
+		}}
+	}
+	return Check(path, string(content))
+}
+
+// Check 对 Markdown 内容执行全部规则；相对链接与图片按 path 所在目录解析。
+func Check(path, content string) []job.Diagnostic {
+	l := &linter{path: path, baseDir: filepath.Dir(path)}
+	l.run(strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n"))
+	return l.diags
+}
+
+type linter struct {
+	path    string
+	baseDir string
+	diags   []job.Diagnostic
+}
+
+func (l *linter) add(line int, severity, rule, format string, args ...any) {
+	l.diags = append(l.diags, job.Diagnostic{
+		Source:   l.path,
+		Line:     line,
+		Severity: severity,
+		Code:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) run(lines []string) {
+	inFence := false
+	fenceChar := byte(0)
+	fenceLen := 0
+	fenceLine := 0
+	lastHeading := 0
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if ch, n, info, ok := fenceOpen(line); ok {
+			if !inFence {
+				inFence, fenceChar, fenceLen, fenceLine = true, ch, n, lineNo
+				continue
+			}
+			if ch == fenceChar && n >= fenceLen && strings.TrimSpace(info) == "" {
+				inFence = false
+				continue
+			}
+		}
+		if inFence {
+			continue
+		}
+
+		if m := atxHeadingRe.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			if lastHeading > 0 && level > lastHeading+1 {
+				l.add(lineNo, job.SeverityWarn, RuleHeadingJump, "标题层级从 H%d 跳到 H%d", lastHeading, level)
+			}
+			lastHeading = level
+		}
+
+		if strings.Contains(trimmed, "|") && i+1 < len(lines) && delimiterRowRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			i = l.checkTable(lines, i)
+			continue
+		}
+
+		l.checkLinks(lineNo, line)
+	}
+
+	if inFence {
+		l.add(fenceLine, job.SeverityError, RuleUnclosedFence, "代码块围栏 %s 未闭合", strings.Repeat(string(fenceChar), fenceLen))
+	}
+}
+
+// checkTable 校验表头、分隔行与数据行的列数，返回表格最后一行的下标。
+func (l *linter) checkTable(lines []string, start int) int {
+	header := countCells(lines[start])
+	delim := countCells(lines[start+1])
+	l.checkLinks(start+1, lines[start])
+	if header != delim {
+		l.add(start+2, job.SeverityError, RuleTableColumns, "表格分隔行有 %d 列，表头有 %d 列，pandoc 将不会识别为表格", delim, header)
+	}
+	end := start + 1
+	for j := start + 2; j < len(lines); j++ {
+		row := strings.TrimSpace(lines[j])
+		if row == "" || !strings.Contains(row, "|") {
+			break
+		}
+		end = j
+		if n := countCells(lines[j]); n != header {
+			l.add(j+1, job.SeverityWarn, RuleTableColumns, "表格行有 %d 列，表头有 %d 列", n, header)
+		}
+		l.checkLinks(j+1, lines[j])
+	}
+	return end
+}
+
+func (l *linter) checkLinks(lineNo int, line string) {
+	text := stripCodeSpans(line)
+	for _, m := range inlineLinkRe.FindAllStringSubmatch(text, -1) {
+		if m[1] == "!" {
+			l.checkTarget(lineNo, m[2], true)
+			continue
+		}
+		l.checkTarget(lineNo, m[2], false)
+	}
+	if m := refDefinitionRe.FindStringSubmatch(text); m != nil {
+		l.checkTarget(lineNo, m[1], false)
+	}
+	for _, m := range htmlImageRe.FindAllStringSubmatch(text, -1) {
+		l.checkTarget(lineNo, m[1], true)
+	}
+}
+
+func (l *linter) checkTarget(lineNo int, raw string, image bool) {
+	target := localTarget(raw)
+	if target == "" {
+		return
+	}
+	abs := target
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(l.baseDir, abs)
+	}
+	if _, err := os.Stat(abs); err == nil {
+		return
+	}
+	if image {
+		l.add(lineNo, job.SeverityError, RuleMissingImage, "图片不存在：%s", target)
+		return
+	}
+	l.add(lineNo, job.SeverityWarn, RuleBrokenLink, "链接目标不存在：%s", target)
+}
+
+// localTarget 把链接目标归一为本地文件路径；外部 URL、纯锚点返回空串。
+func localTarget(raw string) string {
+	target := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(raw, "<"), ">"))
+	if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "//") || schemeRe.MatchString(target) {
+		return ""
+	}
+	if idx := strings.IndexAny(target, "#?"); idx >= 0 {
+		target = target[:idx]
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	return filepath.FromSlash(target)
+}
+
+func fenceOpen(line string) (byte, int, string, bool) {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent > 3 {
+		return 0, 0, "", false
+	}
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return 0, 0, "", false
+	}
+	first := trimmed[0]
+	n := 0
+	for n < len(trimmed) && trimmed[n] == first {
+		n++
+	}
+	if n < 3 {
+		return 0, 0, "", false
+	}
+	info := trimmed[n:]
+	if first == '`' && strings.Contains(info, "`") {
+		return 0, 0, "", false
+	}
+	return first, n, info, true
+}
+
+func countCells(row string) int {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = strings.TrimSuffix(row, "|")
+	}
+	count := 1
+	for i := 0; i < len(row); i++ {
+		if row[i] == '\\' {
+			i++
+			continue
+		}
+		if row[i] == '|' {
+			count++
+		}
+	}
+	return count
+}
+
+// stripCodeSpans 用空格替换行内代码，避免把代码里的链接语法与竖线当成正文。
+func stripCodeSpans(line string) string {
+	if !strings.Contains(line, "`") {
+		return line
+	}
+	b := []byte(line)
+	for i := 0; i < len(b); {
+		if b[i] != '`' {
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(b) && b[i+n] == '`' {
+			n++
+		}
+		closeAt := strings.Index(string(b[i+n:]), strings.Repeat("`", n))
+		if closeAt < 0 {
+			i += n
+			continue
+		}
+		end := i + n + closeAt + n
+		for k := i; k < end; k++ {
+			b[k] = ' '
+		}
+		i = end
+	}
+	return string(b)
+}
@@ -0,0 +1,69 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func codes(diags []job.Diagnostic) []string {
+	out := make([]string, 0, len(diags))
+	for _, d := range diags {
+		out = append(out, d.Code)
+	}
+	return out
+}
+
+func TestCheckUnclosedFence(t *testing.T) {
+	diags := Check("/tmp/a.md", "# a\n\n```go\nx := 1\n")
+	require.Len(t, diags, 1)
+	require.Equal(t, RuleUnclosedFence, diags[0].Code)
+	require.Equal(t, 3, diags[0].Line)
+	require.Equal(t, job.SeverityError, diags[0].Severity)
+}
+
+func TestCheckFenceWithInfoStringDoesNotClose(t *testing.T) {
+	diags := Check("/tmp/a.md", "```\n```go\n```\n")
+	require.Empty(t, diags)
+}
+
+func TestCheckHeadingJump(t *testing.T) {
+	diags := Check("/tmp/a.md", "# a\n\n### c\n\n## b\n\n```\n# not heading\n#### x\n```\n")
+	require.Equal(t, []string{RuleHeadingJump}, codes(diags))
+	require.Equal(t, 3, diags[0].Line)
+}
+
+func TestCheckLinksAndImages(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "ok.md"), []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "ok.png"), []byte("x"), 0o644))
+
+	content := "[ok](ok.md#sec) [web](https://example.com) [anchor](#top)\n" +
+		"![ok](ok.png) ![lost](img/lost.png)\n" +
+		"see [gone](gone.md) and `[code](nope.md)`\n" +
+		"<img src=\"missing.png\">\n"
+	diags := Check(filepath.Join(tmp, "a.md"), content)
+	require.Equal(t, []string{RuleMissingImage, RuleBrokenLink, RuleMissingImage}, codes(diags))
+	require.Equal(t, 2, diags[0].Line)
+	require.Equal(t, 3, diags[1].Line)
+	require.Equal(t, 4, diags[2].Line)
+}
+
+func TestCheckTableColumns(t *testing.T) {
+	content := "| a | b |\n|---|---|\n| 1 | 2 |\n| 1 | 2 | 3 |\n| x \\| y | z |\n\n| a | b |\n|---|\n"
+	diags := Check("/tmp/a.md", content)
+	require.Equal(t, []string{RuleTableColumns, RuleTableColumns}, codes(diags))
+	require.Equal(t, 4, diags[0].Line)
+	require.Equal(t, job.SeverityWarn, diags[0].Severity)
+	require.Equal(t, 8, diags[1].Line)
+	require.Equal(t, job.SeverityError, diags[1].Severity)
+}
+
+func TestCheckFileMissing(t *testing.T) {
+	diags := CheckFile(filepath.Join(t.TempDir(), "missing.md"))
+	require.Len(t, diags, 1)
+	require.Equal(t, RuleReadFailed, diags[0].Code)
+}
@@ -9,8 +9,8 @@ import (
 	"strings"
 	"time"
 
-	"syl-md2doc/internal/input"
-	"syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/input"
+	"github.com/hooziwang/syl-md2doc/internal/job"
 )
 
 type Options struct {
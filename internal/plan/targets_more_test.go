@@ -4,8 +4,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/hooziwang/syl-md2doc/internal/input"
 	"github.com/stretchr/testify/require"
-	"syl-md2doc/internal/input"
 )
 
 func TestBuildTargetsSingleInputOutputFile(t *testing.T) {
@@ -6,8 +6,8 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/hooziwang/syl-md2doc/internal/input"
 	"github.com/stretchr/testify/require"
-	"syl-md2doc/internal/input"
 )
 
 func TestBuildTargetsDirInputPreserveRelativePath(t *testing.T) {
@@ -2,13 +2,35 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
-	"syl-md2doc/internal/convert"
-	"syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/hooziwang/syl-md2doc/internal/job"
 )
 
+const defaultMaxBackoff = 30 * time.Second
+
+var sleepContext = func(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func Run(ctx context.Context, jobs int, tasks []job.Task, c convert.Converter) Summary {
+	return RunWithPolicy(ctx, jobs, tasks, c, Policy{})
+}
+
+func RunWithPolicy(ctx context.Context, jobs int, tasks []job.Task, c convert.Converter, policy Policy) Summary {
 	if jobs < 1 {
 		jobs = 1
 	}
@@ -21,43 +43,123 @@ func Run(ctx context.Context, jobs int, tasks []job.Task, c convert.Converter) S
 		res job.Result
 	}
 
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	workCh := make(chan int)
 	resultCh := make(chan indexedResult, len(tasks))
 	wg := sync.WaitGroup{}
 
+	var mu sync.Mutex
+	failures := 0
+	stopped := false
+
 	for i := 0; i < jobs; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for idx := range workCh {
-				res := c.Convert(ctx, tasks[idx])
+				if runCtx.Err() != nil {
+					resultCh <- indexedResult{idx: idx, res: job.Result{Task: tasks[idx], NotRun: true}}
+					continue
+				}
+				res := convertWithRetry(runCtx, c, tasks[idx], policy)
+				mu.Lock()
+				switch {
+				case res.Error != nil && stopped && errors.Is(res.Error, context.Canceled):
+					// 因达到失败阈值被取消的任务不算失败，记为未执行。
+					res = job.Result{Task: tasks[idx], NotRun: true, Attempts: res.Attempts}
+				case res.Error != nil:
+					failures++
+					if policy.MaxFailures > 0 && failures >= policy.MaxFailures && !stopped {
+						stopped = true
+						cancel()
+					}
+				}
+				mu.Unlock()
 				resultCh <- indexedResult{idx: idx, res: res}
 			}
 		}()
 	}
 
 	go func() {
+		defer func() {
+			close(workCh)
+			wg.Wait()
+			close(resultCh)
+		}()
 		for i := range tasks {
-			workCh <- i
+			select {
+			case workCh <- i:
+			case <-runCtx.Done():
+				return
+			}
 		}
-		close(workCh)
-		wg.Wait()
-		close(resultCh)
 	}()
 
 	results := make([]job.Result, len(tasks))
+	done := make([]bool, len(tasks))
 	for item := range resultCh {
 		results[item.idx] = item.res
+		done[item.idx] = true
+	}
+	for i := range results {
+		if !done[i] {
+			results[i] = job.Result{Task: tasks[i], NotRun: true}
+		}
 	}
 
-	summary := Summary{Total: len(tasks), Results: results}
+	summary := Summary{Total: len(tasks), Results: results, Stopped: stopped}
 	for _, r := range results {
 		summary.WarningCount += len(r.Warnings)
-		if r.Error != nil {
+		if r.Attempts > 1 {
+			summary.RetryCount += r.Attempts - 1
+		}
+		switch {
+		case r.NotRun:
+			summary.NotRunCount++
+		case r.Error != nil:
 			summary.FailureCount++
-			continue
+		default:
+			summary.SuccessCount++
 		}
-		summary.SuccessCount++
 	}
 	return summary
 }
+
+func convertWithRetry(ctx context.Context, c convert.Converter, task job.Task, policy Policy) job.Result {
+	attempt := 1
+	for {
+		res := c.Convert(ctx, task)
+		res.Attempts = attempt
+		if res.Error == nil || attempt > policy.Retries || !job.IsTransient(res.Error) || ctx.Err() != nil {
+			return res
+		}
+		if err := sleepContext(ctx, backoffDelay(policy, attempt)); err != nil {
+			return res
+		}
+		attempt++
+	}
+}
+
+// backoffDelay 按指数退避计算第 attempt 次失败后的等待时长。
+func backoffDelay(policy Policy, attempt int) time.Duration {
+	if policy.Backoff <= 0 {
+		return 0
+	}
+	maxDelay := policy.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxBackoff
+	}
+	delay := policy.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
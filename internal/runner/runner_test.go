@@ -3,12 +3,13 @@ package runner
 import (
 	"context"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/hooziwang/syl-md2doc/internal/job"
 	"github.com/stretchr/testify/require"
-	"syl-md2doc/internal/job"
 )
 
 type fakeConverter struct {
@@ -52,3 +53,104 @@ func TestRunnerRespectJobs(t *testing.T) {
 	_ = Run(context.Background(), 2, tasks, c)
 	require.LessOrEqual(t, int(c.maxActive), 2)
 }
+
+type flakyConverter struct {
+	mu       sync.Mutex
+	calls    map[string]int
+	failures int
+	err      func(error) error
+}
+
+func (f *flakyConverter) Convert(ctx context.Context, task job.Task) job.Result {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls == nil {
+		f.calls = map[string]int{}
+	}
+	f.calls[task.SourcePath]++
+	if f.calls[task.SourcePath] <= f.failures {
+		return job.Result{Task: task, Error: f.err(fmt.Errorf("out of memory"))}
+	}
+	return job.Result{Task: task}
+}
+
+func TestRunnerRetryTransientFailure(t *testing.T) {
+	oldSleep := sleepContext
+	delays := make([]time.Duration, 0)
+	sleepContext = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	defer func() { sleepContext = oldSleep }()
+
+	c := &flakyConverter{failures: 2, err: job.Transient}
+	s := RunWithPolicy(context.Background(), 1, []job.Task{{SourcePath: "a"}}, c, Policy{Retries: 3, Backoff: 100 * time.Millisecond})
+	require.Equal(t, 1, s.SuccessCount)
+	require.Equal(t, 2, s.RetryCount)
+	require.Equal(t, 3, s.Results[0].Attempts)
+	require.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, delays)
+}
+
+func TestRunnerRetryExhausted(t *testing.T) {
+	oldSleep := sleepContext
+	sleepContext = func(ctx context.Context, d time.Duration) error { return nil }
+	defer func() { sleepContext = oldSleep }()
+
+	c := &flakyConverter{failures: 5, err: job.Transient}
+	s := RunWithPolicy(context.Background(), 1, []job.Task{{SourcePath: "a"}}, c, Policy{Retries: 1})
+	require.Equal(t, 1, s.FailureCount)
+	require.Equal(t, 2, s.Results[0].Attempts)
+}
+
+func TestRunnerNoRetryForPermanentFailure(t *testing.T) {
+	c := &flakyConverter{failures: 5, err: func(err error) error { return err }}
+	s := RunWithPolicy(context.Background(), 1, []job.Task{{SourcePath: "a"}}, c, Policy{Retries: 3})
+	require.Equal(t, 1, s.FailureCount)
+	require.Equal(t, 0, s.RetryCount)
+	require.Equal(t, 1, s.Results[0].Attempts)
+}
+
+func TestBackoffDelayCapped(t *testing.T) {
+	p := Policy{Backoff: time.Second, MaxBackoff: 3 * time.Second}
+	require.Equal(t, time.Second, backoffDelay(p, 1))
+	require.Equal(t, 2*time.Second, backoffDelay(p, 2))
+	require.Equal(t, 3*time.Second, backoffDelay(p, 3))
+	require.Equal(t, time.Duration(0), backoffDelay(Policy{}, 2))
+}
+
+type blockingConverter struct {
+	started chan struct{}
+}
+
+func (b *blockingConverter) Convert(ctx context.Context, task job.Task) job.Result {
+	if task.SourcePath == "bad" {
+		<-b.started
+		return job.Result{Task: task, Error: fmt.Errorf("bad")}
+	}
+	b.started <- struct{}{}
+	<-ctx.Done()
+	return job.Result{Task: task, Error: fmt.Errorf("转换已取消：%w", ctx.Err())}
+}
+
+func TestRunnerFailFastCancelsInFlightAndSkipsRest(t *testing.T) {
+	c := &blockingConverter{started: make(chan struct{})}
+	tasks := []job.Task{{SourcePath: "slow"}, {SourcePath: "bad"}, {SourcePath: "c"}, {SourcePath: "d"}}
+	s := RunWithPolicy(context.Background(), 2, tasks, c, Policy{MaxFailures: 1})
+	require.True(t, s.Stopped)
+	require.Equal(t, 1, s.FailureCount)
+	require.Equal(t, 0, s.SuccessCount)
+	require.Equal(t, 3, s.NotRunCount)
+	require.True(t, s.Results[0].NotRun)
+	require.False(t, s.Results[1].NotRun)
+	require.True(t, s.Results[3].NotRun)
+}
+
+func TestRunnerMaxFailuresAllowsBelowThreshold(t *testing.T) {
+	c := &fakeConverter{}
+	tasks := []job.Task{{SourcePath: "a"}, {SourcePath: "bad"}, {SourcePath: "c"}}
+	s := RunWithPolicy(context.Background(), 1, tasks, c, Policy{MaxFailures: 2})
+	require.False(t, s.Stopped)
+	require.Equal(t, 2, s.SuccessCount)
+	require.Equal(t, 1, s.FailureCount)
+	require.Equal(t, 0, s.NotRunCount)
+}
@@ -1,11 +1,27 @@
 package runner
 
-import "syl-md2doc/internal/job"
+import (
+	"time"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+// Policy 控制任务的重试与中止行为：重试仅对被标记为临时性失败的任务生效；
+// MaxFailures > 0 时，失败数达到阈值后不再派发新任务并取消进行中的任务。
+type Policy struct {
+	Retries     int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	MaxFailures int
+}
 
 type Summary struct {
 	Total        int
 	SuccessCount int
 	FailureCount int
 	WarningCount int
+	RetryCount   int
+	NotRunCount  int
+	Stopped      bool
 	Results      []job.Result
 }
@@ -0,0 +1,483 @@
+// Package validate 检查生成的 docx 的包结构与关键 OOXML 约束，提前发现 Word 打开时会提示“无法读取的内容”的问题。
+package validate
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hooziwang/syl-md2doc/internal/docx"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const (
+	RuleReadFailed    = "read-failed"
+	RuleZip           = "docx-zip"
+	RuleContentTypes  = "docx-content-types"
+	RuleRelationships = "docx-relationships"
+	RuleXML           = "docx-xml"
+	RuleSchema        = "docx-schema"
+)
+
+// mainDocumentTypes 是 officeDocument 关系目标允许的内容类型。
+var mainDocumentTypes = []string{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.template.main+xml",
+	"application/vnd.ms-word.document.macroEnabled.main+xml",
+	"application/vnd.ms-word.template.macroEnabledTemplate.main+xml",
+}
+
+// storyRoots 是包含正文内容（段落、表格）的部件根元素。
+var storyRoots = map[string]bool{"document": true, "hdr": true, "ftr": true, "footnotes": true, "endnotes": true, "comments": true}
+
+// invalidParagraphParents 是不能直接包含 w:p 的元素。
+var invalidParagraphParents = map[string]bool{
+	"p": true, "r": true, "hyperlink": true, "tbl": true, "tr": true, "pPr": true, "rPr": true,
+	"sectPr": true, "fldSimple": true, "smartTag": true, "t": true,
+}
+
+// invalidRunParents 是不能直接包含 w:r 的元素。
+var invalidRunParents = map[string]bool{"r": true, "body": true, "tbl": true, "tr": true, "tc": true, "t": true}
+
+// CheckFile 读取并检查单个 docx；读取失败时以 error 级诊断返回。
+func CheckFile(path string) []job.Diagnostic {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []job.Diagnostic{{
+			Source:   path,
+			Severity: job.SeverityError,
+			Code:     RuleReadFailed,
+			Message:  fmt.Sprintf("读取 docx 失败：%v", err),
+		}}
+	}
+	return Check(path, data)
+}
+
+// Check 检查 docx 内容：zip 完整性、内容类型、关系目标、XML 格式与关键结构约束。
+func Check(path string, data []byte) []job.Diagnostic {
+	c := &checker{path: path, parts: map[string][]byte{}, rels: map[string]map[string]bool{}, notes: map[string]map[string]bool{}}
+	if !c.readZip(data) {
+		return c.diags
+	}
+	c.checkContentTypes()
+	c.checkRelationships()
+	c.checkXML()
+	c.checkNoteReferences()
+	return c.diags
+}
+
+type checker struct {
+	path  string
+	parts map[string][]byte
+	names []string
+	// rels 为各部件（包本身为空串）定义的关系 Id。
+	rels map[string]map[string]bool
+	// notes 记录 footnotes.xml / endnotes.xml 中定义的注释 id；refs 为正文中的注释引用。
+	notes map[string]map[string]bool
+	refs  []noteRef
+	diags []job.Diagnostic
+}
+
+type noteRef struct {
+	part, kind, id string
+}
+
+func (c *checker) add(severity, rule, part, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if part != "" {
+		msg = part + "：" + msg
+	}
+	c.diags = append(c.diags, job.Diagnostic{Source: c.path, Severity: severity, Code: rule, Message: msg})
+}
+
+func (c *checker) readZip(data []byte) bool {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		c.add(job.SeverityError, RuleZip, "", "不是有效的 zip 文件：%v", err)
+		return false
+	}
+	seen := map[string]bool{}
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		// OPC 部件名不区分大小写。
+		key := strings.ToLower(f.Name)
+		if seen[key] {
+			c.add(job.SeverityError, RuleZip, f.Name, "zip 中存在重复的部件")
+			continue
+		}
+		seen[key] = true
+		rc, err := f.Open()
+		if err == nil {
+			var content []byte
+			content, err = io.ReadAll(rc)
+			rc.Close()
+			c.parts[f.Name] = content
+		}
+		if err != nil {
+			c.add(job.SeverityError, RuleZip, f.Name, "zip 条目损坏：%v", err)
+			continue
+		}
+		c.names = append(c.names, f.Name)
+	}
+	sort.Strings(c.names)
+	return true
+}
+
+type contentTypes struct {
+	Defaults []struct {
+		Extension   string `xml:"Extension,attr"`
+		ContentType string `xml:"ContentType,attr"`
+	} `xml:"Default"`
+	Overrides []struct {
+		PartName    string `xml:"PartName,attr"`
+		ContentType string `xml:"ContentType,attr"`
+	} `xml:"Override"`
+}
+
+func (c *checker) checkContentTypes() {
+	data, ok := c.parts[docx.PartContentTypes]
+	if !ok {
+		c.add(job.SeverityError, RuleContentTypes, "", "缺少 [Content_Types].xml")
+		return
+	}
+	var ct contentTypes
+	if err := xml.Unmarshal(data, &ct); err != nil {
+		// 格式错误由 XML 检查报告。
+		return
+	}
+	defaults := map[string]string{}
+	for _, d := range ct.Defaults {
+		defaults[strings.ToLower(d.Extension)] = d.ContentType
+	}
+	overrides := map[string]string{}
+	for _, o := range ct.Overrides {
+		name := strings.TrimPrefix(o.PartName, "/")
+		overrides[strings.ToLower(name)] = o.ContentType
+		if !c.has(name) {
+			c.add(job.SeverityWarn, RuleContentTypes, docx.PartContentTypes, "Override 指向不存在的部件：%s", o.PartName)
+		}
+	}
+	for _, name := range c.names {
+		if name == docx.PartContentTypes {
+			continue
+		}
+		if _, ok := overrides[strings.ToLower(name)]; ok {
+			continue
+		}
+		if _, ok := defaults[strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))]; !ok {
+			c.add(job.SeverityError, RuleContentTypes, name, "部件没有声明内容类型（缺少 Default 或 Override）")
+		}
+	}
+	for _, rel := range c.relationships(docx.PartRootRels) {
+		if rel.Type != docx.RelTypeOfficeDocument {
+			continue
+		}
+		target := docx.ResolveTarget("", rel.Target)
+		got, ok := overrides[strings.ToLower(target)]
+		if !ok {
+			got = defaults[strings.ToLower(strings.TrimPrefix(path.Ext(target), "."))]
+		}
+		if c.has(target) && !contains(mainDocumentTypes, got) {
+			c.add(job.SeverityError, RuleContentTypes, target, "主文档的内容类型不正确：%s", got)
+		}
+	}
+}
+
+type relationship struct {
+	ID         string `xml:"Id,attr"`
+	Type       string `xml:"Type,attr"`
+	Target     string `xml:"Target,attr"`
+	TargetMode string `xml:"TargetMode,attr"`
+}
+
+func (c *checker) relationships(part string) []relationship {
+	var rels struct {
+		Items []relationship `xml:"Relationship"`
+	}
+	_ = xml.Unmarshal(c.parts[part], &rels)
+	return rels.Items
+}
+
+func (c *checker) checkRelationships() {
+	if !c.has(docx.PartRootRels) {
+		c.add(job.SeverityError, RuleRelationships, "", "缺少 _rels/.rels")
+	}
+	hasDocument := false
+	for _, name := range c.names {
+		if !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		dir, file := path.Split(name)
+		if path.Base(dir) != "_rels" {
+			continue
+		}
+		source := path.Join(path.Dir(strings.TrimSuffix(dir, "/")), strings.TrimSuffix(file, ".rels"))
+		if name == docx.PartRootRels {
+			source = ""
+		} else if !c.has(source) {
+			c.add(job.SeverityWarn, RuleRelationships, name, "关系部件对应的部件不存在：%s", source)
+		}
+		ids := map[string]bool{}
+		for _, rel := range c.relationships(name) {
+			switch {
+			case rel.ID == "":
+				c.add(job.SeverityError, RuleRelationships, name, "关系缺少 Id（Target=%s）", rel.Target)
+			case ids[rel.ID]:
+				c.add(job.SeverityError, RuleRelationships, name, "关系 Id 重复：%s", rel.ID)
+			}
+			ids[rel.ID] = true
+			if source == "" && rel.Type == docx.RelTypeOfficeDocument {
+				hasDocument = true
+			}
+			if strings.EqualFold(rel.TargetMode, "External") {
+				continue
+			}
+			target := docx.ResolveTarget(source, strings.SplitN(rel.Target, "#", 2)[0])
+			if rel.Target == "" || !c.has(target) {
+				c.add(job.SeverityError, RuleRelationships, name, "关系 %s 的目标不存在：%s", rel.ID, rel.Target)
+			}
+		}
+		c.rels[source] = ids
+	}
+	if c.has(docx.PartRootRels) && !hasDocument {
+		c.add(job.SeverityError, RuleRelationships, docx.PartRootRels, "缺少指向主文档的 officeDocument 关系")
+	}
+}
+
+// frame 是遍历 XML 时一个打开的元素。
+type frame struct {
+	name     xml.Name
+	children int
+	// last 为最近一个子元素的本地名（仅 WordprocessingML 元素）；paragraphs 统计可作为单元格结尾的子元素。
+	last       string
+	paragraphs int
+	afterSect  bool
+}
+
+func (c *checker) checkXML() {
+	for _, name := range c.names {
+		ext := strings.ToLower(path.Ext(name))
+		if ext != ".xml" && ext != ".rels" {
+			continue
+		}
+		c.walk(name)
+	}
+}
+
+// walk 逐个读取部件的 XML 记号：报告格式错误与未声明的命名空间前缀，并在正文类部件中检查结构约束。
+func (c *checker) walk(part string) {
+	dec := xml.NewDecoder(bytes.NewReader(c.parts[part]))
+	var stack []*frame
+	story := false
+	undeclared := map[string]bool{}
+	bookmarks := map[string]bool{}
+	rels := c.rels[part]
+	w := func(n xml.Name) string {
+		if n.Space == docx.NamespaceW {
+			return n.Local
+		}
+		return ""
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntax *xml.SyntaxError
+			if errors.As(err, &syntax) {
+				c.add(job.SeverityError, RuleXML, part, "XML 格式错误（第 %d 行）：%s", syntax.Line, syntax.Msg)
+			} else {
+				c.add(job.SeverityError, RuleXML, part, "XML 格式错误：%v", err)
+			}
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			for _, n := range append([]xml.Name{t.Name}, attrNames(t.Attr)...) {
+				if n.Space != "" && !strings.Contains(n.Space, ":") && n.Space != "xmlns" && !undeclared[n.Space] {
+					undeclared[n.Space] = true
+					c.add(job.SeverityError, RuleXML, part, "使用了未声明的命名空间前缀：%s", n.Space)
+				}
+			}
+			if len(stack) == 0 {
+				story = storyRoots[w(t.Name)]
+				if w(t.Name) == "footnotes" || w(t.Name) == "endnotes" {
+					c.notes[w(t.Name)] = map[string]bool{}
+				}
+			}
+			for _, a := range t.Attr {
+				if a.Name.Space == docx.NamespaceR && a.Value != "" && !rels[a.Value] {
+					c.add(job.SeverityError, RuleRelationships, part, "%s 的 r:%s 引用了未定义的关系 Id：%s", t.Name.Local, a.Name.Local, a.Value)
+				}
+			}
+			if story {
+				c.checkElement(part, stack, t, bookmarks)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children++
+				if local := w(t.Name); local != "" {
+					parent.last = local
+					if local == "p" || local == "sdt" || local == "customXml" {
+						parent.paragraphs++
+					}
+				}
+			}
+			stack = append(stack, &frame{name: t.Name})
+		case xml.EndElement:
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if story {
+				c.checkClosed(part, f)
+			}
+		}
+	}
+}
+
+func attrNames(attrs []xml.Attr) []xml.Name {
+	out := make([]xml.Name, 0, len(attrs))
+	for _, a := range attrs {
+		out = append(out, a.Name)
+	}
+	return out
+}
+
+// checkElement 检查一个 WordprocessingML 元素在父元素中的位置。
+func (c *checker) checkElement(part string, stack []*frame, t xml.StartElement, bookmarks map[string]bool) {
+	if t.Name.Space != docx.NamespaceW || len(stack) == 0 {
+		return
+	}
+	parent := stack[len(stack)-1]
+	parentName := ""
+	if parent.name.Space == docx.NamespaceW {
+		parentName = parent.name.Local
+	}
+	attr := func(local string) string {
+		for _, a := range t.Attr {
+			if a.Name.Space == docx.NamespaceW && a.Name.Local == local {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	if parentName == "body" && parent.afterSect {
+		c.add(job.SeverityError, RuleSchema, part, "w:sectPr 必须是 w:body 的最后一个子元素，其后出现了 w:%s", t.Name.Local)
+	}
+	switch t.Name.Local {
+	case "p":
+		if invalidParagraphParents[parentName] {
+			c.add(job.SeverityError, RuleSchema, part, "段落（w:p）不能位于 w:%s 中", parentName)
+		}
+	case "r":
+		if invalidRunParents[parentName] {
+			c.add(job.SeverityError, RuleSchema, part, "文本块（w:r）不能直接位于 w:%s 中", parentName)
+		}
+	case "t":
+		if parentName != "r" {
+			c.add(job.SeverityError, RuleSchema, part, "文字（w:t）必须位于 w:r 中，实际位于 %s", parent.name.Local)
+		}
+	case "pPr", "rPr":
+		if (parentName == "p" && t.Name.Local == "pPr" || parentName == "r" && t.Name.Local == "rPr") && parent.children > 0 {
+			c.add(job.SeverityError, RuleSchema, part, "w:%s 必须是 w:%s 的第一个子元素", t.Name.Local, parentName)
+		}
+	case "sectPr":
+		if parentName == "body" {
+			parent.afterSect = true
+		}
+	case "bookmarkStart":
+		if id := attr("id"); id != "" {
+			if bookmarks[id] {
+				c.add(job.SeverityWarn, RuleSchema, part, "书签 id 重复：%s", id)
+			}
+			bookmarks[id] = true
+		}
+	case "footnote", "endnote":
+		if notes := c.notes[parentName]; notes != nil {
+			notes[attr("id")] = true
+		}
+	case "footnoteReference", "endnoteReference":
+		c.refs = append(c.refs, noteRef{part: part, kind: strings.TrimSuffix(t.Name.Local, "Reference"), id: attr("id")})
+	}
+}
+
+// checkClosed 在元素结束时检查其子元素是否完整。
+func (c *checker) checkClosed(part string, f *frame) {
+	if f.name.Space != docx.NamespaceW {
+		return
+	}
+	switch f.name.Local {
+	case "tbl":
+		if f.last == "" || !hasRows(f) {
+			c.add(job.SeverityError, RuleSchema, part, "表格（w:tbl）没有行")
+		}
+	case "tr":
+		if f.last == "" || f.last == "trPr" || f.last == "tblPrEx" {
+			c.add(job.SeverityError, RuleSchema, part, "表格行（w:tr）没有单元格")
+		}
+	case "tc":
+		if f.paragraphs == 0 || f.last == "tbl" {
+			c.add(job.SeverityError, RuleSchema, part, "表格单元格（w:tc）必须以段落结尾")
+		}
+	}
+}
+
+// hasRows 判断表格的最后一个子元素是否为行（或包裹行的内容控件）。
+func hasRows(f *frame) bool {
+	switch f.last {
+	case "tr", "sdt", "customXml", "bookmarkEnd", "bookmarkStart", "proofErr", "permStart", "permEnd":
+		return true
+	}
+	return false
+}
+
+// checkNoteReferences 确认正文中的脚注、尾注引用都在对应部件中有定义。
+func (c *checker) checkNoteReferences() {
+	for _, ref := range c.refs {
+		notes := c.notes[ref.kind+"s"]
+		if notes == nil {
+			c.add(job.SeverityError, RuleSchema, ref.part, "引用了%s %s，但文档中没有 %ss.xml", noteLabel(ref.kind), ref.id, ref.kind)
+			continue
+		}
+		if !notes[ref.id] {
+			c.add(job.SeverityError, RuleSchema, ref.part, "%s %s 在 %ss.xml 中不存在", noteLabel(ref.kind), ref.id, ref.kind)
+		}
+	}
+}
+
+func noteLabel(kind string) string {
+	if kind == "endnote" {
+		return "尾注"
+	}
+	return "脚注"
+}
+
+func (c *checker) has(name string) bool {
+	if _, ok := c.parts[name]; ok {
+		return true
+	}
+	for n := range c.parts {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package validate
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`
+	testRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`
+	testDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId9" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com" TargetMode="External"/></Relationships>`
+)
+
+// testDocx 生成不压缩的最小 docx；body 为 w:body 的内容，extra 中的空串表示删除该部件。
+func testDocx(t *testing.T, body string, extra map[string]string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"[Content_Types].xml":          testContentTypes,
+		"_rels/.rels":                  testRootRels,
+		"word/_rels/document.xml.rels": testDocumentRels,
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:body>` + body + `</w:body></w:document>`,
+	}
+	for name, content := range extra {
+		if content == "" {
+			delete(parts, name)
+			continue
+		}
+		parts[name] = content
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func codes(diags []job.Diagnostic) []string {
+	out := make([]string, 0, len(diags))
+	for _, d := range diags {
+		out = append(out, d.Code)
+	}
+	return out
+}
+
+const validBody = `<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t>a</w:t></w:r><w:hyperlink r:id="rId9"><w:r><w:t>b</w:t></w:r></w:hyperlink></w:p>` +
+	`<w:tbl><w:tblPr/><w:tblGrid><w:gridCol/></w:tblGrid><w:tr><w:tc><w:p/></w:tc></w:tr></w:tbl><w:sectPr/>`
+
+func TestCheckValidDocx(t *testing.T) {
+	require.Empty(t, Check("a.docx", testDocx(t, validBody, nil)))
+}
+
+func TestCheckFileReferenceTemplate(t *testing.T) {
+	require.Empty(t, CheckFile(filepath.Join("..", "convert", "templates", "default-reference.docx")))
+}
+
+func TestCheckFileMissing(t *testing.T) {
+	diags := CheckFile(filepath.Join(t.TempDir(), "missing.docx"))
+	require.Equal(t, []string{RuleReadFailed}, codes(diags))
+}
+
+func TestCheckNotZip(t *testing.T) {
+	diags := Check("a.docx", []byte("not a zip"))
+	require.Equal(t, []string{RuleZip}, codes(diags))
+	require.Equal(t, job.SeverityError, diags[0].Severity)
+}
+
+func TestCheckCorruptedEntry(t *testing.T) {
+	data := testDocx(t, strings.Repeat("<w:p><w:r><w:t>abc</w:t></w:r></w:p>", 20), nil)
+	at := bytes.Index(data, []byte("<w:body>"))
+	require.Positive(t, at)
+	data[at+1] = 'x'
+	diags := Check("a.docx", data)
+	require.Contains(t, codes(diags), RuleZip)
+	require.Contains(t, diags[0].Message, "zip 条目损坏")
+}
+
+func TestCheckMalformedXML(t *testing.T) {
+	diags := Check("a.docx", testDocx(t, `<w:p><w:r><w:t>a</w:r></w:p>`, nil))
+	require.Equal(t, []string{RuleXML}, codes(diags))
+	require.Contains(t, diags[0].Message, "word/document.xml：XML 格式错误（第 2 行）")
+}
+
+func TestCheckUndeclaredPrefix(t *testing.T) {
+	diags := Check("a.docx", testDocx(t, `<w:p><w:r><w14:t>a</w14:t></w:r></w:p>`, nil))
+	require.Equal(t, []string{RuleXML}, codes(diags))
+	require.Contains(t, diags[0].Message, "未声明的命名空间前缀：w14")
+}
+
+func TestCheckContentTypes(t *testing.T) {
+	types := strings.Replace(testContentTypes, `<Default Extension="xml" ContentType="application/xml"/>`, "", 1)
+	types = strings.Replace(types, "</Types>", `<Override PartName="/word/gone.xml" ContentType="application/xml"/></Types>`, 1)
+	diags := Check("a.docx", testDocx(t, "", map[string]string{
+		"[Content_Types].xml": types,
+		"word/styles.xml":     `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"/>`,
+	}))
+	require.Equal(t, []string{RuleContentTypes, RuleContentTypes}, codes(diags))
+	require.Equal(t, job.SeverityWarn, diags[0].Severity)
+	require.Contains(t, diags[0].Message, "/word/gone.xml")
+	require.Equal(t, "word/styles.xml：部件没有声明内容类型（缺少 Default 或 Override）", diags[1].Message)
+}
+
+func TestCheckMainDocumentContentType(t *testing.T) {
+	types := strings.Replace(testContentTypes, "wordprocessingml.document.main+xml", "wordprocessingml.styles+xml", 1)
+	diags := Check("a.docx", testDocx(t, "", map[string]string{"[Content_Types].xml": types}))
+	require.Equal(t, []string{RuleContentTypes}, codes(diags))
+	require.Contains(t, diags[0].Message, "主文档的内容类型不正确")
+}
+
+func TestCheckRelationships(t *testing.T) {
+	rels := strings.Replace(testDocumentRels, "</Relationships>",
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/missing.png"/>`+
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="/word/document.xml"/></Relationships>`, 1)
+	diags := Check("a.docx", testDocx(t, `<w:p><w:hyperlink r:id="rId7"/></w:p>`, map[string]string{"word/_rels/document.xml.rels": rels}))
+	require.Equal(t, []string{RuleRelationships, RuleRelationships, RuleRelationships}, codes(diags))
+	require.Contains(t, diags[0].Message, "关系 rId2 的目标不存在：media/missing.png")
+	require.Contains(t, diags[1].Message, "关系 Id 重复：rId2")
+	require.Contains(t, diags[2].Message, "word/document.xml：hyperlink 的 r:id 引用了未定义的关系 Id：rId7")
+}
+
+func TestCheckMissingOfficeDocument(t *testing.T) {
+	rels := strings.Replace(testRootRels, "officeDocument/2006/relationships/officeDocument", "package/2006/relationships/metadata/thumbnail", 1)
+	diags := Check("a.docx", testDocx(t, "", map[string]string{"_rels/.rels": rels}))
+	require.Equal(t, []string{RuleRelationships}, codes(diags))
+	require.Contains(t, diags[0].Message, "缺少指向主文档的 officeDocument 关系")
+}
+
+func TestCheckSchema(t *testing.T) {
+	body := `<w:p><w:r><w:t>a</w:t></w:r><w:p/></w:p>` +
+		`<w:p><w:r><w:r/></w:r><w:t>b</w:t><w:pPr/></w:p>` +
+		`<w:tbl><w:tblPr/><w:tblGrid/></w:tbl>` +
+		`<w:tbl><w:tr><w:tc><w:tcPr/></w:tc></w:tr><w:tr/></w:tbl>` +
+		`<w:sectPr/><w:p/>`
+	diags := Check("a.docx", testDocx(t, body, nil))
+	messages := make([]string, 0, len(diags))
+	for _, d := range diags {
+		require.Equal(t, RuleSchema, d.Code)
+		messages = append(messages, strings.TrimPrefix(d.Message, "word/document.xml："))
+	}
+	require.Equal(t, []string{
+		"段落（w:p）不能位于 w:p 中",
+		"文本块（w:r）不能直接位于 w:r 中",
+		"文字（w:t）必须位于 w:r 中，实际位于 p",
+		"w:pPr 必须是 w:p 的第一个子元素",
+		"表格（w:tbl）没有行",
+		"表格单元格（w:tc）必须以段落结尾",
+		"表格行（w:tr）没有单元格",
+		"w:sectPr 必须是 w:body 的最后一个子元素，其后出现了 w:p",
+	}, messages)
+}
+
+func TestCheckNoteReferencesAndBookmarks(t *testing.T) {
+	notes := `<w:footnotes xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:footnote w:id="1"><w:p/></w:footnote></w:footnotes>`
+	body := `<w:p><w:bookmarkStart w:id="5" w:name="a"/><w:bookmarkStart w:id="5" w:name="b"/>` +
+		`<w:r><w:footnoteReference w:id="1"/></w:r><w:r><w:footnoteReference w:id="2"/></w:r><w:r><w:endnoteReference w:id="1"/></w:r></w:p>`
+	diags := Check("a.docx", testDocx(t, body, map[string]string{"word/footnotes.xml": notes}))
+	require.Equal(t, []string{RuleSchema, RuleSchema, RuleSchema}, codes(diags))
+	require.Equal(t, job.SeverityWarn, diags[0].Severity)
+	require.Contains(t, diags[0].Message, "书签 id 重复：5")
+	require.Contains(t, diags[1].Message, "脚注 2 在 footnotes.xml 中不存在")
+	require.Contains(t, diags[2].Message, "引用了尾注 1，但文档中没有 endnotes.xml")
+	require.Equal(t, 2, job.CountErrors(diags))
+}
+
+func TestCheckFileReadsFromDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.docx")
+	require.NoError(t, os.WriteFile(path, testDocx(t, validBody, nil), 0o644))
+	require.Empty(t, CheckFile(path))
+}
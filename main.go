@@ -3,7 +3,7 @@ package main
 import (
 	"os"
 
-	"syl-md2doc/cmd"
+	"github.com/hooziwang/syl-md2doc/cmd"
 )
 
 func main() {
@@ -0,0 +1,176 @@
+// Package md2doc 提供可嵌入的 Markdown -> docx 转换 API，行为与 syl-md2doc 命令行一致。
+//
+// 默认使用 pandoc 完成转换；可通过 WithConverter 替换为自定义实现。
+package md2doc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hooziwang/syl-md2doc/internal/app"
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/hooziwang/syl-md2doc/internal/validate"
+)
+
+// ConvertBatch 批量转换文件或目录，与命令行直跑等价；单个文件失败不会中断其余文件。
+func ConvertBatch(ctx context.Context, inputs []string, opts ...Option) (Result, error) {
+	cfg := newConfig(opts)
+	res, err := app.RunContext(ctx, cfg.appOptions(inputs))
+	if err != nil {
+		return Result{}, err
+	}
+	return fromAppResult(res), nil
+}
+
+// Convert 把 r 中的 Markdown 转换为 docx 写入 w。
+// Markdown 中的相对资源路径与包含指令按 WithResourceDir（缺省为 WithWorkDir 或当前目录）解析；
+// 未指定 WithIncludeRoot 时，包含的片段也只能位于该目录之内。
+func Convert(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (Result, error) {
+	cfg := newConfig(opts)
+	workDir, err := os.MkdirTemp("", "syl-md2doc-lib-*")
+	if err != nil {
+		return Result{}, fmt.Errorf("创建临时目录失败：%w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(workDir)
+	}()
+
+	src := filepath.Join(workDir, "document.md")
+	f, err := os.Create(src)
+	if err != nil {
+		return Result{}, fmt.Errorf("创建临时 Markdown 文件失败：%w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return Result{}, fmt.Errorf("读取 Markdown 失败：%w", err)
+	}
+	if err := f.Close(); err != nil {
+		return Result{}, fmt.Errorf("写入临时 Markdown 文件失败：%w", err)
+	}
+
+	if cfg.resourceDir == "" {
+		cfg.resourceDir = cfg.workDir
+		if cfg.resourceDir == "" {
+			if wd, err := os.Getwd(); err == nil {
+				cfg.resourceDir = wd
+			}
+		}
+	}
+	cfg.includeBase = cfg.resourceDir
+	cfg.outputArg = filepath.Join(workDir, "out", "document.docx")
+	appOpts := cfg.appOptions([]string{src})
+	res, err := app.RunContext(ctx, appOpts)
+	if err != nil {
+		return Result{}, err
+	}
+	out := fromAppResult(res)
+	if len(res.OutputPaths) != 1 {
+		if len(res.Failures) > 0 {
+			return out, fmt.Errorf("%w：%s", ErrNoOutput, res.Failures[0].Reason)
+		}
+		return out, ErrNoOutput
+	}
+
+	docx, err := os.Open(res.OutputPaths[0])
+	if err != nil {
+		return out, fmt.Errorf("读取 docx 失败：%w", err)
+	}
+	defer func() {
+		_ = docx.Close()
+	}()
+	if _, err := io.Copy(w, docx); err != nil {
+		return out, fmt.Errorf("写出 docx 失败：%w", err)
+	}
+	// 产物已写入 w，临时路径在返回后即失效。
+	out.OutputPaths = nil
+	return out, nil
+}
+
+// ValidateDocx 校验已有 docx 的结构（同 syl-md2doc validate），返回的诊断中 Source 为 path，Line 为 0。
+func ValidateDocx(path string) []Diagnostic {
+	diags := validate.CheckFile(path)
+	out := make([]Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		out = append(out, Diagnostic(d))
+	}
+	return out
+}
+
+func (c config) appOptions(inputs []string) app.Options {
+	opts := app.Options{
+		Inputs:         inputs,
+		OutputArg:      c.outputArg,
+		Jobs:           c.jobs,
+		ReferenceDocx:  c.referenceDocx,
+		PandocPath:     c.pandocPath,
+		ResourcePath:   c.resourceDir,
+		CWD:            c.workDir,
+		Verbose:        c.verbose,
+		Retries:        c.retries,
+		RetryBackoff:   c.retryBackoff,
+		MaxFailures:    c.maxFailures,
+		Lint:           c.lint,
+		LintBlock:      c.lintBlock,
+		Properties:     c.properties,
+		PropertiesFile: c.propsFile,
+		HeaderFooter:   convert.HeaderFooterOptions(c.headerFooter),
+		Cover:          c.cover,
+		Vars:           c.vars,
+		VarsFile:       c.varsFile,
+		Template:       c.template,
+		IncludeRoot:    c.includeRoot,
+		IncludeBase:    c.includeBase,
+		Watermark:      convert.WatermarkOptions(c.watermark),
+		Classification: c.classification,
+		Bibliography:   c.bibliography,
+		CSL:            c.csl,
+		Math:           c.math,
+		Highlight:      convert.HighlightOptions{Style: c.highlight, Disabled: c.noHighlight, LineNumbers: c.lineNumbers},
+		Admonitions:    convert.AdmonitionOptions{Mode: c.admonitionMode, Styles: c.admonitionStyles},
+		Diagrams:       convert.DiagramOptions{Renderers: c.diagramRenderers, Format: c.diagramFormat, CacheDir: c.diagramCache},
+		Page:           convert.PageOptions{Paper: c.paper, Margins: c.margins, Orientation: c.orientation},
+		BlankLines:     convert.BlankLineMode(c.blankLines),
+		Tables:         convert.TableOptions{Style: c.tableStyle, RepeatHeader: c.tableHeaderRepeat, Banded: c.tableBanded},
+		Notes:          convert.NoteOptions{Kind: c.notes, Format: c.noteFormat, Restart: c.noteRestart},
+		Fonts:          convert.FontOptions{Embed: c.embedFonts, Dirs: c.fontDirs},
+		Validate:       c.validate,
+	}
+	if c.converter != nil {
+		opts.Converter = toInternal{c: c.converter}
+	}
+	return opts
+}
+
+func fromAppResult(res app.Result) Result {
+	out := Result{
+		SuccessCount: res.SuccessCount,
+		FailureCount: res.FailureCount,
+		NotRunCount:  res.NotRunCount,
+		WarningCount: res.WarningCount,
+		RetryCount:   res.RetryCount,
+		Stopped:      res.Stopped,
+		Warnings:     append([]string{}, res.Warnings...),
+		Failures:     make([]Failure, 0, len(res.Failures)),
+		Diagnostics:  make([]Diagnostic, 0, len(res.Diagnostics)+len(res.ConvertDiagnostics)),
+		Files:        make([]FileResult, 0, len(res.Tasks)),
+		OutputPaths:  append([]string{}, res.OutputPaths...),
+		PandocPath:   res.PandocPath,
+		PandocVer:    res.PandocVer,
+	}
+	for _, f := range res.Failures {
+		out.Failures = append(out.Failures, Failure{Source: f.Source, Reason: f.Reason, Attempts: f.Attempts})
+	}
+	for _, diags := range [][]job.Diagnostic{res.Diagnostics, res.ConvertDiagnostics} {
+		for _, d := range diags {
+			out.Diagnostics = append(out.Diagnostics, Diagnostic(d))
+		}
+	}
+	for _, t := range res.Tasks {
+		out.Files = append(out.Files, FileResult{Source: t.Source, Target: t.Target, Status: t.Status, Attempts: t.Attempts, Includes: t.Includes, Fonts: publicFonts(t.Fonts)})
+	}
+	return out
+}
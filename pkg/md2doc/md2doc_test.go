@@ -0,0 +1,197 @@
+package md2doc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hooziwang/syl-md2doc/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func writingConverter(content string) ConverterFunc {
+	return func(ctx context.Context, task Task) TaskResult {
+		if err := os.MkdirAll(filepath.Dir(task.TargetPath), 0o755); err != nil {
+			return TaskResult{Task: task, Err: err}
+		}
+		src, err := os.ReadFile(task.SourcePath)
+		if err != nil {
+			return TaskResult{Task: task, Err: err}
+		}
+		if strings.Contains(string(src), "BROKEN") {
+			return TaskResult{Task: task, Err: errors.New("broken")}
+		}
+		return TaskResult{Task: task, Err: os.WriteFile(task.TargetPath, []byte(content+string(src)), 0o644)}
+	}
+}
+
+func TestConvertReaderToWriter(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	res, err := Convert(context.Background(), strings.NewReader("# hi"), out, WithConverter(writingConverter("docx:")))
+	require.NoError(t, err)
+	require.Equal(t, "docx:# hi", out.String())
+	require.Equal(t, 1, res.SuccessCount)
+	require.Nil(t, res.OutputPaths)
+}
+
+func TestConvertReaderFailure(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	res, err := Convert(context.Background(), strings.NewReader("BROKEN"), out, WithConverter(writingConverter("")))
+	require.ErrorIs(t, err, ErrNoOutput)
+	require.Equal(t, 1, res.FailureCount)
+	require.Zero(t, out.Len())
+}
+
+func TestConvertBatchWithOptions(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("# a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "b.md"), []byte("BROKEN"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "c.md"), []byte("# c"), 0o644))
+
+	res, err := ConvertBatch(context.Background(), []string{"a.md", "b.md", "c.md"},
+		WithWorkDir(tmp),
+		WithOutput(filepath.Join(tmp, "out")),
+		WithJobs(1),
+		WithMaxFailures(1),
+		WithConverter(writingConverter("x")),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.SuccessCount)
+	require.Equal(t, 1, res.FailureCount)
+	require.Equal(t, 1, res.NotRunCount)
+	require.True(t, res.Stopped)
+	require.Len(t, res.Files, 3)
+	require.Equal(t, StatusNotRun, res.Files[2].Status)
+}
+
+func TestConvertBatchRetriesTransientErrors(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("# a"), 0o644))
+
+	var calls int32
+	inner := writingConverter("x")
+	conv := ConverterFunc(func(ctx context.Context, task Task) TaskResult {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return TaskResult{Task: task, Err: Transient(errors.New("out of memory"))}
+		}
+		return inner(ctx, task)
+	})
+	res, err := ConvertBatch(context.Background(), []string{"a.md"}, WithWorkDir(tmp), WithOutput(filepath.Join(tmp, "out")), WithRetries(2, 0), WithConverter(conv))
+	require.NoError(t, err)
+	require.Equal(t, 1, res.SuccessCount)
+	require.Equal(t, 1, res.RetryCount)
+	require.Equal(t, 2, res.Files[0].Attempts)
+}
+
+func TestConvertBatchRequiresInput(t *testing.T) {
+	_, err := ConvertBatch(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestConvertBatchReportsConverterDiagnostics(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("见 [@x]\n"), 0o644))
+	inner := writingConverter("")
+	conv := ConverterFunc(func(ctx context.Context, task Task) TaskResult {
+		res := inner(ctx, task)
+		res.Diagnostics = []Diagnostic{{Source: task.SourcePath, Line: 1, Severity: "warn", Code: "unresolved-citation", Message: "引用键未在参考文献中找到：@x"}}
+		return res
+	})
+	res, err := ConvertBatch(context.Background(), []string{"a.md"}, WithWorkDir(tmp), WithOutput(filepath.Join(tmp, "out")), WithConverter(conv))
+	require.NoError(t, err)
+	require.Len(t, res.Diagnostics, 1)
+	require.Equal(t, "unresolved-citation", res.Diagnostics[0].Code)
+	require.Equal(t, 1, res.WarningCount)
+}
+
+func TestConvertBatchReportsIncludesPerFile(t *testing.T) {
+	tmp := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("!include legal.md\n"), 0o644))
+	inner := writingConverter("")
+	conv := ConverterFunc(func(ctx context.Context, task Task) TaskResult {
+		res := inner(ctx, task)
+		res.Includes = []string{filepath.Join(tmp, "legal.md")}
+		return res
+	})
+	res, err := ConvertBatch(context.Background(), []string{"a.md"}, WithWorkDir(tmp), WithOutput(filepath.Join(tmp, "out")), WithConverter(conv))
+	require.NoError(t, err)
+	require.Len(t, res.Files, 1)
+	require.Equal(t, []string{filepath.Join(tmp, "legal.md")}, res.Files[0].Includes)
+}
+
+func TestValidateDocx(t *testing.T) {
+	require.Empty(t, ValidateDocx(filepath.Join("..", "..", "internal", "convert", "templates", "default-reference.docx")))
+
+	broken := filepath.Join(t.TempDir(), "broken.docx")
+	require.NoError(t, os.WriteFile(broken, []byte("not a zip"), 0o644))
+	diags := ValidateDocx(broken)
+	require.Len(t, diags, 1)
+	require.Equal(t, Diagnostic{Source: broken, Severity: "error", Code: "docx-zip", Message: diags[0].Message}, diags[0])
+}
+
+type targetsRecorder struct {
+	targets map[string]string
+}
+
+func (r *targetsRecorder) Convert(ctx context.Context, task job.Task) job.Result {
+	r.targets = task.Targets
+	return job.Result{Task: task}
+}
+
+func TestToInternalKeepsLinkTargetsForBuiltinConverter(t *testing.T) {
+	rec := &targetsRecorder{}
+	conv := toInternal{c: fromInternal{c: rec}}
+	targets := map[string]string{"/abs/b.md": "/abs/out/b.docx"}
+	conv.Convert(context.Background(), job.Task{SourcePath: "/abs/a.md", TargetPath: "/abs/out/a.docx", Targets: targets})
+	require.Equal(t, targets, rec.targets)
+}
+
+// copyingPandoc 把交给 pandoc 的（预处理后的）Markdown 原样写到输出，便于检查预处理结果。
+const copyingPandoc = "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 'pandoc 3.1.11'; exit 0; fi\nsrc=\"$1\"\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"-o\" ]; then cp \"$src\" \"$2\"; exit 0; fi\n  shift\ndone\n"
+
+func TestConvertResolvesIncludesAgainstResourceDir(t *testing.T) {
+	tmp := t.TempDir()
+	pandoc := filepath.Join(tmp, "fake-pandoc.sh")
+	require.NoError(t, os.WriteFile(pandoc, []byte(copyingPandoc), 0o755))
+	docs := filepath.Join(tmp, "docs")
+	require.NoError(t, os.MkdirAll(filepath.Join(docs, "shared"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "shared", "legal.md"), []byte("法律声明\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "secret.md"), []byte("secret\n"), 0o644))
+
+	out := bytes.NewBuffer(nil)
+	_, err := Convert(context.Background(), strings.NewReader("# 标题\n!include shared/legal.md\n"), out, WithPandocPath(pandoc), WithResourceDir(docs))
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "法律声明")
+
+	_, err = Convert(context.Background(), strings.NewReader("!include ../secret.md\n"), bytes.NewBuffer(nil), WithPandocPath(pandoc), WithResourceDir(docs))
+	require.ErrorContains(t, err, "包含文件超出允许的目录")
+
+	out.Reset()
+	_, err = Convert(context.Background(), strings.NewReader("!include ../secret.md\n"), out, WithPandocPath(pandoc), WithResourceDir(docs), WithIncludeRoot(tmp))
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "secret")
+}
+
+func TestNewPandocConverterValidatesOptions(t *testing.T) {
+	tmp := t.TempDir()
+	pandoc := filepath.Join(tmp, "fake-pandoc.sh")
+	require.NoError(t, os.WriteFile(pandoc, []byte(copyingPandoc), 0o755))
+
+	_, err := NewPandocConverter(WithPandocPath(pandoc), WithPaper("B99"))
+	require.Error(t, err)
+	_, err = NewPandocConverter(WithPandocPath(pandoc), WithVarsFile("missing.yaml"), WithWorkDir(tmp))
+	require.ErrorContains(t, err, "读取变量文件失败")
+	_, err = NewPandocConverter(WithPandocPath(filepath.Join(tmp, "missing-pandoc")))
+	require.ErrorContains(t, err, "pandoc")
+
+	conv, err := NewPandocConverter(WithPandocPath(pandoc), WithWorkDir(tmp))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "a.md"), []byte("# a\n"), 0o644))
+	res := conv.Convert(context.Background(), Task{SourcePath: filepath.Join(tmp, "a.md"), TargetPath: filepath.Join(tmp, "a.docx")})
+	require.NoError(t, res.Err)
+}
@@ -0,0 +1,334 @@
+package md2doc
+
+import "time"
+
+// Option 以函数式选项配置转换，字段与命令行参数一一对应。
+type Option func(*config)
+
+type config struct {
+	outputArg     string
+	jobs          int
+	referenceDocx string
+	pandocPath    string
+	workDir       string
+	resourceDir   string
+	verbose       bool
+	retries       int
+	retryBackoff  time.Duration
+	maxFailures   int
+	lint          bool
+	lintBlock     bool
+	properties    map[string]string
+	propsFile     string
+	headerFooter  HeaderFooter
+	cover         string
+	vars          map[string]string
+	varsFile      string
+	template      bool
+	includeRoot   string
+	// includeBase 只由 Convert 设置为资源目录，使流式输入中的包含指令有可解析的目录。
+	includeBase       string
+	watermark         Watermark
+	classification    string
+	bibliography      []string
+	csl               string
+	math              bool
+	highlight         string
+	noHighlight       bool
+	lineNumbers       bool
+	admonitionMode    string
+	admonitionStyles  map[string]string
+	diagramRenderers  map[string]string
+	diagramFormat     string
+	diagramCache      string
+	paper             string
+	margins           string
+	orientation       string
+	blankLines        string
+	tableStyle        string
+	tableHeaderRepeat bool
+	tableBanded       bool
+	notes             string
+	noteFormat        string
+	noteRestart       string
+	embedFonts        bool
+	fontDirs          []string
+	validate          bool
+	converter         Converter
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// WithOutput 指定输出目录或输出文件（同 --output）。
+func WithOutput(path string) Option {
+	return func(c *config) { c.outputArg = path }
+}
+
+// WithJobs 指定并发任务数（同 --jobs），<= 0 时使用 CPU 核数。
+func WithJobs(n int) Option {
+	return func(c *config) { c.jobs = n }
+}
+
+// WithReferenceDocx 指定 Word 模板（同 --reference-docx）。
+func WithReferenceDocx(path string) Option {
+	return func(c *config) { c.referenceDocx = path }
+}
+
+// WithPandocPath 指定 pandoc 可执行文件（同 --pandoc-path）。
+func WithPandocPath(path string) Option {
+	return func(c *config) { c.pandocPath = path }
+}
+
+// WithWorkDir 指定解析相对路径时使用的目录，默认当前目录。
+func WithWorkDir(dir string) Option {
+	return func(c *config) { c.workDir = dir }
+}
+
+// WithResourceDir 指定 Convert 解析 Markdown 中相对图片路径的目录。
+func WithResourceDir(dir string) Option {
+	return func(c *config) { c.resourceDir = dir }
+}
+
+// WithVerbose 透传 pandoc 的标准输出（同 --verbose）。
+func WithVerbose(v bool) Option {
+	return func(c *config) { c.verbose = v }
+}
+
+// WithRetries 设置临时性失败的重试次数与首次退避时长（同 --retries/--retry-backoff）。
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *config) {
+		c.retries = n
+		c.retryBackoff = backoff
+	}
+}
+
+// WithMaxFailures 设置失败阈值（同 --max-failures），1 等价于 --fail-fast。
+func WithMaxFailures(n int) Option {
+	return func(c *config) { c.maxFailures = n }
+}
+
+// WithLint 转换前执行 lint；block 为 true 时存在 error 级诊断的文件不再转换（同 --lint/--lint-block）。
+func WithLint(block bool) Option {
+	return func(c *config) {
+		c.lint = true
+		c.lintBlock = block
+	}
+}
+
+// WithProperty 设置 docx 文档属性（同 --set-property），优先级高于 front matter 与属性文件。
+func WithProperty(key, value string) Option {
+	return func(c *config) {
+		if c.properties == nil {
+			c.properties = make(map[string]string)
+		}
+		c.properties[key] = value
+	}
+}
+
+// WithPropertiesFile 指定 YAML 文档属性文件（同 --properties-file）。
+func WithPropertiesFile(path string) Option {
+	return func(c *config) { c.propsFile = path }
+}
+
+// HeaderFooter 是页眉页脚模板（同 --header/--footer 等参数），模板语法见 README。
+type HeaderFooter struct {
+	Header             string
+	Footer             string
+	FirstHeader        string
+	FirstFooter        string
+	EvenHeader         string
+	EvenFooter         string
+	DifferentFirstPage bool
+}
+
+// WithHeaderFooter 设置页眉页脚模板。
+func WithHeaderFooter(hf HeaderFooter) Option {
+	return func(c *config) { c.headerFooter = hf }
+}
+
+// WithCover 指定封面模板（同 --cover）：Markdown 模板或 .docx 片段。
+func WithCover(path string) Option {
+	return func(c *config) { c.cover = path }
+}
+
+// WithVar 设置模板变量（同 --var），优先级高于 front matter 与文档属性。
+func WithVar(key, value string) Option {
+	return func(c *config) {
+		if c.vars == nil {
+			c.vars = make(map[string]string)
+		}
+		c.vars[key] = value
+	}
+}
+
+// WithVarsFile 设置 YAML 变量文件（同 --vars-file），优先级低于 WithVar、高于 front matter。
+func WithVarsFile(path string) Option {
+	return func(c *config) { c.varsFile = path }
+}
+
+// WithIncludeRoot 设置包含指令允许读取的目录（同 --include-root），默认为主文档所在目录；相对路径基于 WithWorkDir。
+func WithIncludeRoot(dir string) Option {
+	return func(c *config) { c.includeRoot = dir }
+}
+
+// WithTemplate 处理正文中的 {{ name }} 变量与 {{ if }} 条件块（同 --template）；设置了 WithVar 或 WithVarsFile 时自动启用。
+func WithTemplate(enabled bool) Option {
+	return func(c *config) { c.template = enabled }
+}
+
+// Watermark 是文字水印（同 --watermark 等参数）。Opacity 为 0 时取 0.5，Color 为空时取 #C0C0C0；
+// Angle 为逆时针角度，零值表示水平（命令行默认 45）。
+type Watermark struct {
+	Text    string
+	Opacity float64
+	Angle   float64
+	Color   string
+}
+
+// WithWatermark 设置文字水印。
+func WithWatermark(w Watermark) Option {
+	return func(c *config) { c.watermark = w }
+}
+
+// WithClassification 设置密级标识（同 --classification）。
+func WithClassification(text string) Option {
+	return func(c *config) { c.classification = text }
+}
+
+// WithBibliography 追加参考文献文件（同 --bibliography），启用 citeproc 处理 [@key] 引用。
+func WithBibliography(paths ...string) Option {
+	return func(c *config) { c.bibliography = append(c.bibliography, paths...) }
+}
+
+// WithCSL 指定 CSL 引用样式文件（同 --csl）。
+func WithCSL(path string) Option {
+	return func(c *config) { c.csl = path }
+}
+
+// WithMath 启用 $...$ / $$...$$ 公式（同 --math），公式语法错误记入 Result.Diagnostics。
+func WithMath(enabled bool) Option {
+	return func(c *config) { c.math = enabled }
+}
+
+// WithHighlightStyle 设置代码高亮主题（同 --highlight-style）：内置主题名或 .theme 主题文件。
+func WithHighlightStyle(style string) Option {
+	return func(c *config) { c.highlight = style }
+}
+
+// WithNoHighlight 关闭代码块语法高亮（同 --no-highlight）。
+func WithNoHighlight() Option {
+	return func(c *config) { c.noHighlight = true }
+}
+
+// WithCodeLineNumbers 为代码块加行号（同 --code-line-numbers）。
+func WithCodeLineNumbers(enabled bool) Option {
+	return func(c *config) { c.lineNumbers = enabled }
+}
+
+// WithAdmonitionMode 设置提示块渲染方式（同 --admonition-mode）："box" 或 "style"。
+func WithAdmonitionMode(mode string) Option {
+	return func(c *config) { c.admonitionMode = mode }
+}
+
+// WithAdmonitionStyle 设置提示块段落样式名（同 --admonition-style），kind 为 note/tip/important/warning/caution/title。
+func WithAdmonitionStyle(kind, style string) Option {
+	return func(c *config) {
+		if c.admonitionStyles == nil {
+			c.admonitionStyles = make(map[string]string)
+		}
+		c.admonitionStyles[kind] = style
+	}
+}
+
+// WithDiagramRenderer 设置图表渲染命令（同 --diagram-renderer），kind 为 mermaid 或 plantuml。
+func WithDiagramRenderer(kind, command string) Option {
+	return func(c *config) {
+		if c.diagramRenderers == nil {
+			c.diagramRenderers = make(map[string]string)
+		}
+		c.diagramRenderers[kind] = command
+	}
+}
+
+// WithDiagramFormat 设置图表图片格式（同 --diagram-format）："png" 或 "svg"。
+func WithDiagramFormat(format string) Option {
+	return func(c *config) { c.diagramFormat = format }
+}
+
+// WithDiagramCache 设置图表渲染缓存目录（同 --diagram-cache）。
+func WithDiagramCache(dir string) Option {
+	return func(c *config) { c.diagramCache = dir }
+}
+
+// WithPaper 设置纸张大小（同 --paper），如 "A4"、"Letter"。
+func WithPaper(paper string) Option {
+	return func(c *config) { c.paper = paper }
+}
+
+// WithMargins 设置页边距（同 --margins），如 "2.5cm" 或 "25mm,20mm"。
+func WithMargins(margins string) Option {
+	return func(c *config) { c.margins = margins }
+}
+
+// WithOrientation 设置页面方向（同 --orientation）："portrait" 或 "landscape"。
+func WithOrientation(orientation string) Option {
+	return func(c *config) { c.orientation = orientation }
+}
+
+// WithBlankLines 设置空行处理方式（同 --blank-lines）："preserve"、"collapse" 或 "extra-only"。
+func WithBlankLines(mode string) Option {
+	return func(c *config) { c.blankLines = mode }
+}
+
+// WithTableStyle 设置表格样式名（同 --table-style），样式需存在于参考模板。
+func WithTableStyle(style string) Option {
+	return func(c *config) { c.tableStyle = style }
+}
+
+// WithTableHeaderRepeat 让表格跨页时重复表头行（同 --table-header-repeat）。
+func WithTableHeaderRepeat(enabled bool) Option {
+	return func(c *config) { c.tableHeaderRepeat = enabled }
+}
+
+// WithTableBanded 打开表格条带行（同 --table-banded）。
+func WithTableBanded(enabled bool) Option {
+	return func(c *config) { c.tableBanded = enabled }
+}
+
+// WithNotes 设置 Markdown 脚注的形式（同 --notes）："footnotes" 或 "endnotes"。
+func WithNotes(kind string) Option {
+	return func(c *config) { c.notes = kind }
+}
+
+// WithNoteNumbering 设置脚注/尾注的编号格式与重新编号位置（同 --note-format、--note-restart），空字符串保持默认。
+func WithNoteNumbering(format, restart string) Option {
+	return func(c *config) { c.noteFormat, c.noteRestart = format, restart }
+}
+
+// WithFontDirs 追加字体目录（同 --font-dir），用于嵌入字体与字体报告；相对路径基于 WithWorkDir。
+func WithFontDirs(dirs ...string) Option {
+	return func(c *config) { c.fontDirs = append(c.fontDirs, dirs...) }
+}
+
+// WithEmbedFonts 把文档用到且能在字体目录中找到的字体嵌入 docx（同 --embed-fonts）。
+func WithEmbedFonts(enabled bool) Option {
+	return func(c *config) { c.embedFonts = enabled }
+}
+
+// WithValidate 校验每个输出 docx 的结构（同 --validate），问题记入 Diagnostics，存在错误时该文件记为失败。
+func WithValidate(enabled bool) Option {
+	return func(c *config) { c.validate = enabled }
+}
+
+// WithConverter 替换默认的 pandoc 转换器。
+func WithConverter(conv Converter) Option {
+	return func(c *config) { c.converter = conv }
+}
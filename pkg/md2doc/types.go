@@ -0,0 +1,178 @@
+package md2doc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hooziwang/syl-md2doc/internal/app"
+	"github.com/hooziwang/syl-md2doc/internal/convert"
+	"github.com/hooziwang/syl-md2doc/internal/job"
+)
+
+const (
+	StatusSuccess = app.TaskStatusSuccess
+	StatusFailed  = app.TaskStatusFailed
+	StatusNotRun  = app.TaskStatusNotRun
+)
+
+const (
+	SeverityError = job.SeverityError
+	SeverityWarn  = job.SeverityWarn
+)
+
+// ErrNoOutput 表示 Convert 没有产出 docx（例如转换失败或被 lint 阻断）。
+var ErrNoOutput = errors.New("未生成 docx")
+
+// Task 是单个文件的转换任务。
+type Task struct {
+	SourcePath string
+	TargetPath string
+}
+
+// TaskResult 是 Converter 对单个任务的转换结果。
+type TaskResult struct {
+	Task     Task
+	Warnings []string
+	// Diagnostics 是定位到源文件行号的结构化告警（如 unresolved-citation）。
+	Diagnostics []Diagnostic
+	// Includes 是文档通过包含指令引用的文件，自定义转换器可按需填写。
+	Includes []string
+	// Fonts 是输出文档引用的字体及查找结果（WithVerbose 或 WithEmbedFonts 时填写）。
+	Fonts []FontUsage
+	Err   error
+}
+
+// Converter 把 Task.SourcePath 转换为 Task.TargetPath；实现需可并发调用。
+type Converter interface {
+	Convert(ctx context.Context, task Task) TaskResult
+}
+
+// ConverterFunc 让普通函数满足 Converter。
+type ConverterFunc func(ctx context.Context, task Task) TaskResult
+
+func (f ConverterFunc) Convert(ctx context.Context, task Task) TaskResult {
+	return f(ctx, task)
+}
+
+// Transient 把错误标记为临时性失败，配合 WithRetries 触发重试。
+func Transient(err error) error {
+	return job.Transient(err)
+}
+
+// NewPandocConverter 按 opts 创建内置的 pandoc 转换器，便于在自定义 Converter 中包装复用。
+// 选项的校验与 ConvertBatch 相同（pandoc 是否可用、属性与变量文件、页面与提示块设置等）；
+// WithOutput、WithJobs、WithRetries 等批量选项与 WithConverter 不生效。
+func NewPandocConverter(opts ...Option) (Converter, error) {
+	cfg := newConfig(opts)
+	cwd := cfg.workDir
+	if cwd == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("读取当前目录失败：%w", err)
+		}
+		cwd = wd
+	}
+	pc, _, err := app.NewConverter(cfg.appOptions(nil), cwd)
+	if err != nil {
+		return nil, err
+	}
+	return fromInternal{c: pc}, nil
+}
+
+type Failure struct {
+	Source   string
+	Reason   string
+	Attempts int
+}
+
+type Diagnostic struct {
+	Source   string
+	Line     int
+	Severity string
+	Code     string
+	Message  string
+}
+
+// FontUsage 是输出文档引用的一个字体；Path 为空表示未找到，打开文档时会被替换。
+type FontUsage struct {
+	Name     string
+	Path     string
+	Embedded bool
+}
+
+type FileResult struct {
+	Source   string
+	Target   string
+	Status   string
+	Attempts int
+	// Includes 是该文件通过包含指令依赖的其他文件（绝对路径）。
+	Includes []string
+	Fonts    []FontUsage
+}
+
+// Result 是批量转换的汇总结果。
+type Result struct {
+	SuccessCount int
+	FailureCount int
+	NotRunCount  int
+	WarningCount int
+	RetryCount   int
+	Stopped      bool
+	Warnings     []string
+	Failures     []Failure
+	// Diagnostics 包含 lint 诊断与转换阶段的结构化告警（如 unresolved-citation）。
+	Diagnostics []Diagnostic
+	Files       []FileResult
+	OutputPaths []string
+	PandocPath  string
+	PandocVer   string
+}
+
+// toInternal 把公开 Converter 适配为内部接口。
+type toInternal struct {
+	c Converter
+}
+
+func (a toInternal) Convert(ctx context.Context, task job.Task) job.Result {
+	if inner, ok := a.c.(fromInternal); ok {
+		// 内置转换器直接接收内部任务，保留本批次的链接映射。
+		return inner.c.Convert(ctx, task)
+	}
+	res := a.c.Convert(ctx, Task{SourcePath: task.SourcePath, TargetPath: task.TargetPath})
+	diags := make([]job.Diagnostic, 0, len(res.Diagnostics))
+	for _, d := range res.Diagnostics {
+		diags = append(diags, job.Diagnostic(d))
+	}
+	fonts := make([]job.FontUsage, 0, len(res.Fonts))
+	for _, f := range res.Fonts {
+		fonts = append(fonts, job.FontUsage(f))
+	}
+	return job.Result{Task: task, Warnings: res.Warnings, Diagnostics: diags, Includes: res.Includes, Fonts: fonts, Error: res.Err}
+}
+
+// fromInternal 把内部转换器适配为公开 Converter。
+type fromInternal struct {
+	c convert.Converter
+}
+
+func (a fromInternal) Convert(ctx context.Context, task Task) TaskResult {
+	res := a.c.Convert(ctx, job.Task{SourcePath: task.SourcePath, TargetPath: task.TargetPath})
+	diags := make([]Diagnostic, 0, len(res.Diagnostics))
+	for _, d := range res.Diagnostics {
+		diags = append(diags, Diagnostic(d))
+	}
+	return TaskResult{Task: task, Warnings: res.Warnings, Diagnostics: diags, Includes: res.Includes, Fonts: publicFonts(res.Fonts), Err: res.Error}
+}
+
+func publicFonts(fonts []job.FontUsage) []FontUsage {
+	if len(fonts) == 0 {
+		return nil
+	}
+	out := make([]FontUsage, 0, len(fonts))
+	for _, f := range fonts {
+		out = append(out, FontUsage(f))
+	}
+	return out
+}